@@ -0,0 +1,19 @@
+package aead
+
+import (
+	"errors"
+)
+
+var (
+	// ErrInvalidKeySize is an error when the key has unsupported size.
+	ErrInvalidKeySize = errors.New("invalid key size")
+
+	// ErrNewCipher is the cipher initialization error.
+	ErrNewCipher = errors.New("new cipher")
+
+	// ErrOpen is an error when the ciphertext can not be authenticated.
+	ErrOpen = errors.New("message authentication failed")
+
+	// ErrUnknownSuite is an error when the suite identifier is unknown.
+	ErrUnknownSuite = errors.New("unknown suite")
+)
@@ -0,0 +1,211 @@
+package aead
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	// gcmSIVMaxDataSize is the max size of the plaintext and the additional data.
+	gcmSIVMaxDataSize = 1 << 36
+
+	// gcmSIVKeyDerivationBlockSize is the part of each encrypted block, which is used as
+	// the derived key material.
+	gcmSIVKeyDerivationBlockSize = 8
+
+	bitsPerByte = 8
+)
+
+// gcmSIV is AES-GCM-SIV of RFC 8452. The authentication and the encryption keys are derived
+// from the key and the nonce, and the tag computed over the plaintext is the initial counter.
+type gcmSIV struct {
+	block   cipher.Block
+	keySize int
+}
+
+func newGCMSIV(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize && len(key) != aes.BlockSize {
+		return nil, errors.Join(ErrNewCipher, ErrInvalidKeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Join(ErrNewCipher, err)
+	}
+
+	aead := &gcmSIV{
+		block:   block,
+		keySize: len(key),
+	}
+
+	return aead, nil
+}
+
+func (*gcmSIV) NonceSize() int {
+	return gcmNonceSize
+}
+
+func (*gcmSIV) Overhead() int {
+	return tagSize
+}
+
+func (c *gcmSIV) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmNonceSize {
+		panic("aead: incorrect nonce length given to AES-GCM-SIV")
+	}
+
+	if len(ciphertext) < tagSize ||
+		uint64(len(ciphertext)) > gcmSIVMaxDataSize+tagSize ||
+		uint64(len(additionalData)) > gcmSIVMaxDataSize {
+		return nil, ErrOpen
+	}
+
+	var tag [tagSize]byte
+
+	copy(tag[:], ciphertext[len(ciphertext)-tagSize:])
+	ciphertext = ciphertext[:len(ciphertext)-tagSize]
+
+	authKey, encryptionBlock := c.deriveKeys(nonce)
+
+	result, plaintext := sliceForAppend(dst, len(ciphertext))
+	gcmSIVCTR(encryptionBlock, tag, plaintext, ciphertext)
+
+	expectedTag := gcmSIVTag(authKey, encryptionBlock, nonce, plaintext, additionalData)
+	if subtle.ConstantTimeCompare(expectedTag[:], tag[:]) != 1 {
+		clear(plaintext)
+
+		return nil, ErrOpen
+	}
+
+	return result, nil
+}
+
+func (c *gcmSIV) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != gcmNonceSize {
+		panic("aead: incorrect nonce length given to AES-GCM-SIV")
+	}
+
+	if uint64(len(plaintext)) > gcmSIVMaxDataSize ||
+		uint64(len(additionalData)) > gcmSIVMaxDataSize {
+		panic("aead: message too large for AES-GCM-SIV")
+	}
+
+	authKey, encryptionBlock := c.deriveKeys(nonce)
+
+	// Note that the tag is computed before the encryption, so the plaintext may be
+	// encrypted in place.
+	tag := gcmSIVTag(authKey, encryptionBlock, nonce, plaintext, additionalData)
+
+	result, ciphertext := sliceForAppend(dst, len(plaintext)+tagSize)
+	gcmSIVCTR(encryptionBlock, tag, ciphertext[:len(plaintext)], plaintext)
+	copy(ciphertext[len(plaintext):], tag[:])
+
+	return result
+}
+
+// deriveKeys derives the POLYVAL key and the AES encryption key of passed nonce.
+func (c *gcmSIV) deriveKeys(nonce []byte) ([polyvalBlockSize]byte, cipher.Block) {
+	var (
+		input       [aes.BlockSize]byte
+		output      [aes.BlockSize]byte
+		keyMaterial [polyvalBlockSize + keySize]byte
+	)
+
+	copy(input[4:], nonce)
+
+	keyMaterialLen := polyvalBlockSize + c.keySize
+
+	for counter := 0; counter*gcmSIVKeyDerivationBlockSize < keyMaterialLen; counter++ {
+		binary.LittleEndian.PutUint32(input[:4], uint32(counter))
+		c.block.Encrypt(output[:], input[:])
+
+		offset := counter * gcmSIVKeyDerivationBlockSize
+		copy(keyMaterial[offset:], output[:gcmSIVKeyDerivationBlockSize])
+	}
+
+	var authKey [polyvalBlockSize]byte
+
+	copy(authKey[:], keyMaterial[:polyvalBlockSize])
+
+	encryptionBlock, err := aes.NewCipher(keyMaterial[polyvalBlockSize:keyMaterialLen])
+	if err != nil {
+		// The derived key always has the size of the valid key.
+		panic(err)
+	}
+
+	clear(output[:])
+	clear(keyMaterial[:])
+
+	return authKey, encryptionBlock
+}
+
+// gcmSIVTag computes the tag of passed plaintext and additional data.
+func gcmSIVTag(
+	authKey [polyvalBlockSize]byte,
+	encryptionBlock cipher.Block,
+	nonce []byte,
+	plaintext []byte,
+	additionalData []byte,
+) [tagSize]byte {
+	hash := newPolyval(authKey[:])
+	hash.updatePadded(additionalData)
+	hash.updatePadded(plaintext)
+
+	var lengths [polyvalBlockSize]byte
+
+	binary.LittleEndian.PutUint64(lengths[:8], uint64(len(additionalData))*bitsPerByte)
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(plaintext))*bitsPerByte)
+	hash.updateBlock(lengths[:])
+
+	tag := hash.digest()
+	subtle.XORBytes(tag[:gcmNonceSize], tag[:gcmNonceSize], nonce)
+
+	const clearMostSignificantBit = 0x7f
+
+	tag[tagSize-1] &= clearMostSignificantBit
+
+	encryptionBlock.Encrypt(tag[:], tag[:])
+
+	return tag
+}
+
+// gcmSIVCTR encrypts src to dst with AES-CTR, which starts at passed tag with the most
+// significant bit set and increments the first 32 bits of the counter as little-endian.
+func gcmSIVCTR(block cipher.Block, tag [tagSize]byte, dst, src []byte) {
+	const setMostSignificantBit = 0x80
+
+	counter := tag
+	counter[tagSize-1] |= setMostSignificantBit
+
+	var keyStream [aes.BlockSize]byte
+
+	for len(src) > 0 {
+		block.Encrypt(keyStream[:], counter[:])
+
+		processed := subtle.XORBytes(dst, src, keyStream[:])
+		dst, src = dst[processed:], src[processed:]
+
+		binary.LittleEndian.PutUint32(counter[:4], binary.LittleEndian.Uint32(counter[:4])+1)
+	}
+
+	clear(keyStream[:])
+}
+
+// sliceForAppend extends passed slice by n bytes and returns the extended slice and
+// the appended part.
+func sliceForAppend(in []byte, n int) ([]byte, []byte) {
+	total := len(in) + n
+
+	var head []byte
+	if cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+
+	return head, head[len(in):]
+}
@@ -0,0 +1,196 @@
+package aead
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("DecodeString(%q): expected no error but got %v", s, err)
+	}
+
+	return data
+}
+
+func TestPolyval(t *testing.T) {
+	t.Parallel()
+
+	// The example of RFC 8452, appendix A.
+	hash := newPolyval(mustDecodeHex(t, "25629347589242761d31f826ba4b757b"))
+	hash.updateBlock(mustDecodeHex(t, "4f4f95668c83dfb6401762bb2d01a262"))
+	hash.updateBlock(mustDecodeHex(t, "d1a24ddd2721d006bbe45f20d3c9f362"))
+
+	digest := hash.digest()
+	expected := mustDecodeHex(t, "f7a3b47b846119fae5b7866cf5e5b77e")
+
+	if !bytes.Equal(digest[:], expected) {
+		t.Fatalf("digest(): expected %x but got %x", expected, digest)
+	}
+}
+
+// gcmSIVTests are the test vectors of RFC 8452, appendix C.
+var gcmSIVTests = []struct {
+	name           string
+	key            string
+	nonce          string
+	plaintext      string
+	additionalData string
+	result         string
+}{
+	{
+		"AES-128 empty",
+		"01000000000000000000000000000000",
+		"030000000000000000000000",
+		"",
+		"",
+		"dc20e2d83f25705bb49e439eca56de25",
+	},
+	{
+		"AES-256 empty",
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"030000000000000000000000",
+		"",
+		"",
+		"07f5f4169bbf55a8400cd47ea6fd400f",
+	},
+	{
+		"AES-256 8 bytes",
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"030000000000000000000000",
+		"0100000000000000",
+		"",
+		"c2ef328e5c71c83b843122130f7364b761e0b97427e3df28",
+	},
+	{
+		"AES-256 12 bytes",
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"030000000000000000000000",
+		"010000000000000000000000",
+		"",
+		"9aab2aeb3faa0a34aea8e2b18ca50da9ae6559e48fd10f6e5c9ca17e",
+	},
+	{
+		"AES-256 16 bytes",
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"030000000000000000000000",
+		"01000000000000000000000000000000",
+		"",
+		"85a01b63025ba19b7fd3ddfc033b3e76c9eac6fa700942702e90862383c6c366",
+	},
+	{
+		"AES-256 additional data",
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"030000000000000000000000",
+		"0200000000000000",
+		"01",
+		"1de22967237a813291213f267e3b452f02d01ae33e4ec854",
+	},
+}
+
+func TestGCMSIV(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range gcmSIVTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			aead, err := newGCMSIV(mustDecodeHex(t, test.key))
+			if err != nil {
+				t.Fatalf("newGCMSIV(): expected no error but got %v", err)
+			}
+
+			var (
+				nonce          = mustDecodeHex(t, test.nonce)
+				plaintext      = mustDecodeHex(t, test.plaintext)
+				additionalData = mustDecodeHex(t, test.additionalData)
+				expected       = mustDecodeHex(t, test.result)
+			)
+
+			ciphertext := aead.Seal(nil, nonce, plaintext, additionalData)
+			if !bytes.Equal(ciphertext, expected) {
+				t.Fatalf("Seal(): expected %x but got %x", expected, ciphertext)
+			}
+
+			decrypted, err := aead.Open(nil, nonce, ciphertext, additionalData)
+			if err != nil {
+				t.Fatalf("Open(): expected no error but got %v", err)
+			}
+
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Fatalf("Open(): expected %x but got %x", plaintext, decrypted)
+			}
+		})
+	}
+}
+
+func TestGCMSIVInPlace(t *testing.T) {
+	t.Parallel()
+
+	aead, err := newGCMSIV(make([]byte, keySize))
+	if err != nil {
+		t.Fatalf("newGCMSIV(): expected no error but got %v", err)
+	}
+
+	nonce := make([]byte, gcmNonceSize)
+	plaintext := bytes.Repeat([]byte("in place"), 10)
+	expected := aead.Seal(nil, nonce, plaintext, nil)
+
+	buffer := make([]byte, len(plaintext), len(plaintext)+tagSize)
+	copy(buffer, plaintext)
+
+	ciphertext := aead.Seal(buffer[:0], nonce, buffer, nil)
+	if !bytes.Equal(ciphertext, expected) {
+		t.Fatalf("Seal(): expected %x but got %x", expected, ciphertext)
+	}
+
+	decrypted, err := aead.Open(ciphertext[:0], nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("Open(): expected no error but got %v", err)
+	}
+
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("Open(): expected %x but got %x", plaintext, decrypted)
+	}
+}
+
+func TestGCMSIVOpenForged(t *testing.T) {
+	t.Parallel()
+
+	aead, err := newGCMSIV(make([]byte, keySize))
+	if err != nil {
+		t.Fatalf("newGCMSIV(): expected no error but got %v", err)
+	}
+
+	nonce := make([]byte, gcmNonceSize)
+	ciphertext := aead.Seal(nil, nonce, []byte("data"), []byte("auth"))
+
+	for i := range ciphertext {
+		forged := bytes.Clone(ciphertext)
+		forged[i] ^= 0x01
+
+		_, err = aead.Open(nil, nonce, forged, []byte("auth"))
+		if !errors.Is(err, ErrOpen) {
+			t.Fatalf("Open(): expected error %v for byte %d but got %v", ErrOpen, i, err)
+		}
+	}
+
+	_, err = aead.Open(nil, nonce, ciphertext[:tagSize-1], nil)
+	if !errors.Is(err, ErrOpen) {
+		t.Fatalf("Open(): expected error %v for short ciphertext but got %v", ErrOpen, err)
+	}
+}
+
+func TestNewGCMSIVInvalidKeySize(t *testing.T) {
+	t.Parallel()
+
+	_, err := newGCMSIV(make([]byte, 24))
+	if !errors.Is(err, ErrInvalidKeySize) {
+		t.Fatalf("newGCMSIV(): expected error %v but got %v", ErrInvalidKeySize, err)
+	}
+}
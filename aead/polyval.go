@@ -0,0 +1,130 @@
+package aead
+
+import (
+	"encoding/binary"
+)
+
+// polyvalBlockSize is the block size of POLYVAL.
+const polyvalBlockSize = 16
+
+// polyval is the POLYVAL universal hash of RFC 8452.
+//
+// The field multiplication is the carry-less multiplication emulated with integer
+// multiplication of masked operands, so it does not use lookup tables and runs in
+// constant time.
+type polyval struct {
+	key fieldElement
+	sum fieldElement
+}
+
+// fieldElement is the element of GF(2^128) in the POLYVAL little-endian representation.
+type fieldElement struct {
+	lo uint64
+	hi uint64
+}
+
+func newPolyval(key []byte) polyval {
+	return polyval{
+		key: fieldElement{
+			lo: binary.LittleEndian.Uint64(key[:8]),
+			hi: binary.LittleEndian.Uint64(key[8:polyvalBlockSize]),
+		},
+	}
+}
+
+// digest returns the hash of the data passed so far.
+func (p *polyval) digest() [polyvalBlockSize]byte {
+	var digest [polyvalBlockSize]byte
+
+	binary.LittleEndian.PutUint64(digest[:8], p.sum.lo)
+	binary.LittleEndian.PutUint64(digest[8:], p.sum.hi)
+
+	return digest
+}
+
+// updateBlock hashes one block of passed bytes.
+func (p *polyval) updateBlock(block []byte) {
+	p.sum.lo ^= binary.LittleEndian.Uint64(block[:8])
+	p.sum.hi ^= binary.LittleEndian.Uint64(block[8:polyvalBlockSize])
+	p.sum = polyvalDot(p.sum, p.key)
+}
+
+// updatePadded hashes passed data, which is padded with zeros to the block size.
+func (p *polyval) updatePadded(data []byte) {
+	for len(data) >= polyvalBlockSize {
+		p.updateBlock(data[:polyvalBlockSize])
+		data = data[polyvalBlockSize:]
+	}
+
+	if len(data) > 0 {
+		var block [polyvalBlockSize]byte
+
+		copy(block[:], data)
+		p.updateBlock(block[:])
+	}
+}
+
+// polyvalDot returns a*b*x^-128 in GF(2^128) with the POLYVAL polynomial
+// x^128 + x^127 + x^126 + x^121 + 1.
+func polyvalDot(a, b fieldElement) fieldElement {
+	// Karatsuba multiplication of the 64-bit halves.
+	lowHi, lowLo := clmul64(a.lo, b.lo)
+	highHi, highLo := clmul64(a.hi, b.hi)
+	midHi, midLo := clmul64(a.lo^a.hi, b.lo^b.hi)
+
+	midHi ^= lowHi ^ highHi
+	midLo ^= lowLo ^ highLo
+
+	product0 := lowLo
+	product1 := lowHi ^ midLo
+	product2 := highLo ^ midHi
+	product3 := highHi
+
+	// Montgomery reduction, which adds multiples of the polynomial to clear the low
+	// 128 bits of the product.
+	product2 ^= product0 ^ product0>>1 ^ product0>>2 ^ product0>>7
+	product1 ^= product0<<63 ^ product0<<62 ^ product0<<57
+	product3 ^= product1 ^ product1>>1 ^ product1>>2 ^ product1>>7
+	product2 ^= product1<<63 ^ product1<<62 ^ product1<<57
+
+	return fieldElement{
+		lo: product2,
+		hi: product3,
+	}
+}
+
+// clmul64 returns the 128-bit carry-less product of passed values.
+func clmul64(x, y uint64) (hi, lo uint64) {
+	xLo, xHi := uint32(x), uint32(x>>32)
+	yLo, yHi := uint32(y), uint32(y>>32)
+
+	low := clmul32(xLo, yLo)
+	high := clmul32(xHi, yHi)
+	mid := clmul32(xLo, yHi) ^ clmul32(xHi, yLo)
+
+	return high ^ mid>>32, low ^ mid<<32
+}
+
+// clmul32 returns the 64-bit carry-less product of passed values.
+//
+// The operands are split into every fourth bit, so each partial product sums at most eight
+// one-bit terms per bit position. The sum fits four bits and never carries into the next
+// kept position, and the carries are masked out.
+func clmul32(x, y uint32) uint64 {
+	const (
+		mask0 = 0x1111111111111111
+		mask1 = 0x2222222222222222
+		mask2 = 0x4444444444444444
+		mask3 = 0x8888888888888888
+	)
+
+	x0, x1, x2, x3 := uint64(x)&mask0, uint64(x)&mask1, uint64(x)&mask2, uint64(x)&mask3
+	y0, y1, y2, y3 := uint64(y)&mask0, uint64(y)&mask1, uint64(y)&mask2, uint64(y)&mask3
+
+	z0 := x0*y0 ^ x1*y3 ^ x2*y2 ^ x3*y1
+	z1 := x0*y1 ^ x1*y0 ^ x2*y3 ^ x3*y2
+	z2 := x0*y2 ^ x1*y1 ^ x2*y0 ^ x3*y3
+	z3 := x0*y3 ^ x1*y2 ^ x2*y1 ^ x3*y0
+
+	return z0&mask0 | z1&mask1 | z2&mask2 | z3&mask3
+}
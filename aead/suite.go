@@ -0,0 +1,205 @@
+package aead
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// ID is the stable identifier of the suite, which may be stored and sent over the wire.
+type ID uint8
+
+const (
+	// IDXChaCha20Poly1305 is the identifier of the XChaCha20-Poly1305 suite.
+	IDXChaCha20Poly1305 ID = 1
+
+	// IDAES256GCM is the identifier of the AES-256-GCM suite.
+	IDAES256GCM ID = 2
+
+	// IDAES256GCMSIV is the identifier of the AES-256-GCM-SIV suite.
+	IDAES256GCMSIV ID = 3
+)
+
+const (
+	// MaxKeySize is the max key size of all suites.
+	MaxKeySize = keySize
+
+	// MaxNonceSize is the max nonce size of all suites.
+	MaxNonceSize = chacha20poly1305.NonceSizeX
+)
+
+const (
+	keySize = 32
+
+	// tagSize is the authentication tag size of all suites.
+	tagSize = 16
+
+	// gcmNonceSize is the nonce size of AES-GCM and AES-GCM-SIV.
+	gcmNonceSize = 12
+)
+
+// Suite is the AEAD, which encrypts the headers and the messages of the chains. The zero
+// value is not a valid suite.
+//
+// XChaCha20-Poly1305 is the default suite. AES-256-GCM is meant for hardware AES and FIPS
+// reviews. AES-256-GCM-SIV resists nonce misuse, so a repeated random header nonce reveals
+// only whether the headers are equal.
+type Suite struct {
+	id ID
+}
+
+// XChaCha20Poly1305 returns the XChaCha20-Poly1305 suite.
+func XChaCha20Poly1305() Suite {
+	return Suite{id: IDXChaCha20Poly1305}
+}
+
+// AES256GCM returns the AES-256-GCM suite.
+func AES256GCM() Suite {
+	return Suite{id: IDAES256GCM}
+}
+
+// AES256GCMSIV returns the AES-256-GCM-SIV suite of RFC 8452.
+func AES256GCMSIV() Suite {
+	return Suite{id: IDAES256GCMSIV}
+}
+
+// FromID returns the suite with passed identifier.
+func FromID(id ID) (Suite, error) {
+	switch id {
+	case IDXChaCha20Poly1305, IDAES256GCM, IDAES256GCMSIV:
+		return Suite{id: id}, nil
+	default:
+		return Suite{}, ErrUnknownSuite
+	}
+}
+
+// ID returns the stable identifier of the suite.
+func (s Suite) ID() ID {
+	return s.id
+}
+
+// KeySize returns the key size of the suite.
+func (Suite) KeySize() int {
+	return keySize
+}
+
+// NewCipher returns the AEAD of the suite with passed key.
+func (s Suite) NewCipher(key []byte) (cipher.AEAD, error) {
+	switch s.id {
+	case IDXChaCha20Poly1305:
+		aead, err := chacha20poly1305.NewX(key)
+		if err != nil {
+			return nil, errors.Join(ErrNewCipher, err)
+		}
+
+		return aead, nil
+	case IDAES256GCM:
+		return newAESGCM(key)
+	case IDAES256GCMSIV:
+		return newGCMSIV(key)
+	default:
+		return nil, errors.Join(ErrNewCipher, ErrUnknownSuite)
+	}
+}
+
+// NonceSize returns the nonce size of the suite.
+func (s Suite) NonceSize() int {
+	if s.id == IDXChaCha20Poly1305 {
+		return chacha20poly1305.NonceSizeX
+	}
+
+	return gcmNonceSize
+}
+
+// Open authenticates and decrypts ciphertext with passed key and nonce, authenticates
+// additional data and appends the plaintext to dst.
+//
+// Unlike the cipher of NewCipher, it does not allocate for XChaCha20-Poly1305 if dst has
+// enough capacity.
+func (s Suite) Open(dst, key, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if s.id == IDXChaCha20Poly1305 {
+		aead, err := chacha20poly1305.NewX(key)
+		if err != nil {
+			return nil, errors.Join(ErrNewCipher, err)
+		}
+
+		plaintext, err := aead.Open(dst, nonce, ciphertext, additionalData)
+		if err != nil {
+			return nil, errors.Join(ErrOpen, err)
+		}
+
+		return plaintext, nil
+	}
+
+	aead, err := s.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Note that the nonce is cloned, because passing it to the interface method makes it
+	// escape to the heap, which would allocate for all suites.
+	plaintext, err := aead.Open(dst, bytes.Clone(nonce), ciphertext, additionalData)
+	if err != nil {
+		return nil, errors.Join(ErrOpen, err)
+	}
+
+	return plaintext, nil
+}
+
+// Overhead returns the difference between the ciphertext and the plaintext sizes.
+func (Suite) Overhead() int {
+	return tagSize
+}
+
+// Seal encrypts and authenticates plaintext with passed key and nonce, authenticates
+// additional data and appends the ciphertext to dst.
+//
+// Unlike the cipher of NewCipher, it does not allocate for XChaCha20-Poly1305 if dst has
+// enough capacity.
+func (s Suite) Seal(dst, key, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	if s.id == IDXChaCha20Poly1305 {
+		aead, err := chacha20poly1305.NewX(key)
+		if err != nil {
+			return nil, errors.Join(ErrNewCipher, err)
+		}
+
+		return aead.Seal(dst, nonce, plaintext, additionalData), nil
+	}
+
+	aead, err := s.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Note that the nonce is cloned, because passing it to the interface method makes it
+	// escape to the heap, which would allocate for all suites.
+	return aead.Seal(dst, bytes.Clone(nonce), plaintext, additionalData), nil
+}
+
+// Valid reports whether the suite is known.
+func (s Suite) Valid() bool {
+	_, err := FromID(s.id)
+
+	return err == nil
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, errors.Join(ErrNewCipher, ErrInvalidKeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Join(ErrNewCipher, err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Join(ErrNewCipher, err)
+	}
+
+	return aead, nil
+}
@@ -0,0 +1,120 @@
+package aead
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+var suiteTests = []struct {
+	name              string
+	suite             Suite
+	expectedID        ID
+	expectedNonceSize int
+}{
+	{
+		"XChaCha20-Poly1305",
+		XChaCha20Poly1305(),
+		IDXChaCha20Poly1305,
+		24,
+	},
+	{
+		"AES-256-GCM",
+		AES256GCM(),
+		IDAES256GCM,
+		12,
+	},
+	{
+		"AES-256-GCM-SIV",
+		AES256GCMSIV(),
+		IDAES256GCMSIV,
+		12,
+	},
+}
+
+func TestSuite(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range suiteTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if test.suite.ID() != test.expectedID {
+				t.Fatalf("ID(): expected %d but got %d", test.expectedID, test.suite.ID())
+			}
+
+			if test.suite.NonceSize() != test.expectedNonceSize {
+				t.Fatalf(
+					"NonceSize(): expected %d but got %d",
+					test.expectedNonceSize,
+					test.suite.NonceSize(),
+				)
+			}
+
+			suite, err := FromID(test.expectedID)
+			if err != nil || suite != test.suite {
+				t.Fatalf("FromID(%d): expected %+v but got %+v", test.expectedID, test.suite, suite)
+			}
+
+			var (
+				key       = bytes.Repeat([]byte{0x01}, test.suite.KeySize())
+				nonce     = bytes.Repeat([]byte{0x02}, test.suite.NonceSize())
+				plaintext = []byte("plaintext")
+				auth      = []byte("auth")
+			)
+
+			ciphertext, err := test.suite.Seal([]byte{0xFF}, key, nonce, plaintext, auth)
+			if err != nil {
+				t.Fatalf("Seal(): expected no error but got %v", err)
+			}
+
+			if len(ciphertext) != 1+len(plaintext)+test.suite.Overhead() {
+				t.Fatalf("Seal(): expected appended ciphertext but got %x", ciphertext)
+			}
+
+			cipher, err := test.suite.NewCipher(key)
+			if err != nil {
+				t.Fatalf("NewCipher(): expected no error but got %v", err)
+			}
+
+			decrypted, err := cipher.Open(nil, nonce, ciphertext[1:], auth)
+			if err != nil {
+				t.Fatalf("Open(): expected no error but got %v", err)
+			}
+
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Fatalf("Open(): expected %q but got %q", plaintext, decrypted)
+			}
+
+			ciphertext[len(ciphertext)-1] ^= 0x01
+
+			_, err = test.suite.Open(nil, key, nonce, ciphertext[1:], auth)
+			if !errors.Is(err, ErrOpen) {
+				t.Fatalf("Open(): expected error %v but got %v", ErrOpen, err)
+			}
+
+			_, err = test.suite.Seal(nil, key[1:], nonce, plaintext, auth)
+			if !errors.Is(err, ErrNewCipher) {
+				t.Fatalf("Seal(): expected error %v but got %v", ErrNewCipher, err)
+			}
+		})
+	}
+}
+
+func TestFromIDUnknown(t *testing.T) {
+	t.Parallel()
+
+	_, err := FromID(0)
+	if !errors.Is(err, ErrUnknownSuite) {
+		t.Fatalf("FromID(0): expected error %v but got %v", ErrUnknownSuite, err)
+	}
+
+	if (Suite{}).Valid() {
+		t.Fatal("Valid(): expected zero suite to be invalid")
+	}
+
+	_, err = Suite{}.Seal(nil, nil, nil, nil, nil)
+	if !errors.Is(err, ErrUnknownSuite) {
+		t.Fatalf("Seal(): expected error %v but got %v", ErrUnknownSuite, err)
+	}
+}
@@ -0,0 +1,99 @@
+package ratchet
+
+import (
+	"errors"
+
+	"github.com/platform-source/aegis/receivingchain"
+)
+
+// EncryptAppend encrypts passed data and authenticates it with auth like Encrypt, but appends
+// the encrypted header to dstHeader and the encrypted data to dstData.
+//
+// It does not allocate in the steady state, when the sending chain is not ratcheted and the
+// destination buffers have enough capacity. Otherwise it falls back to Encrypt.
+func (r *Ratchet) EncryptAppend(
+	dstHeader []byte,
+	dstData []byte,
+	data []byte,
+	auth []byte,
+) (encryptedHeader []byte, encryptedData []byte, err error) {
+	if r.needSendingChainRatchet {
+		encryptedHeader, encryptedData, err = r.Encrypt(data, auth)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return append(dstHeader, encryptedHeader...), append(dstData, encryptedData...), nil
+	}
+
+	// Note that the KEM fields are not cloned, because the header is encoded right away.
+	header := r.sendingChain.PrepareHeader(r.localPublicKey)
+	if r.needKEMHeader(header.MessageNumber) {
+		header.KEMPublicKey = r.kem.sendingPublicKey
+		header.KEMCiphertext = r.kem.sendingCiphertext
+	}
+
+	encryptedHeader, encryptedData, err = r.sendingChain.EncryptAppend(
+		dstHeader,
+		dstData,
+		header,
+		data,
+		auth,
+	)
+	if err != nil {
+		return nil, nil, errors.Join(ErrSendingChainEncrypt, err)
+	}
+
+	return encryptedHeader, encryptedData, nil
+}
+
+// DecryptAppend decrypts passed encrypted header and encrypted data and authenticates them
+// with auth like Decrypt, but appends the decrypted data to dst.
+//
+// It does not allocate in the steady state, when the message is the next message of the
+// current receiving chain and dst has enough capacity. Otherwise it falls back to Decrypt.
+func (r *Ratchet) DecryptAppend(
+	dst []byte,
+	encryptedHeader []byte,
+	encryptedData []byte,
+	auth []byte,
+) ([]byte, error) {
+	decryptedData, err := r.decryptNextAppend(dst, encryptedHeader, encryptedData, auth)
+	if !errors.Is(err, receivingchain.ErrNotNextMessage) {
+		return decryptedData, err
+	}
+
+	decryptedData, err = r.Decrypt(encryptedHeader, encryptedData, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(dst, decryptedData...), nil
+}
+
+// decryptNextAppend decrypts the next message of the current receiving chain. Other messages
+// are reported with receivingchain.ErrNotNextMessage and do not change the state.
+func (r *Ratchet) decryptNextAppend(
+	dst []byte,
+	encryptedHeader []byte,
+	encryptedData []byte,
+	auth []byte,
+) ([]byte, error) {
+	decryptedData, err := r.receivingChain.DecryptNextAppend(
+		dst,
+		encryptedHeader,
+		encryptedData,
+		auth,
+	)
+	if err != nil {
+		err = errors.Join(ErrReceivingChainDecrypt, err)
+
+		if !errors.Is(err, receivingchain.ErrNotNextMessage) {
+			return nil, r.decryptFailed(err)
+		}
+
+		return nil, err
+	}
+
+	return decryptedData, nil
+}
@@ -0,0 +1,345 @@
+package ratchet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/platform-source/aegis/padding"
+)
+
+var ratchetAppendTests = []struct {
+	name    string
+	options []Option
+}{
+	{
+		"encrypted headers",
+		nil,
+	},
+	{
+		"plaintext headers",
+		[]Option{WithPlaintextHeaders()},
+	},
+	{
+		"padding",
+		[]Option{WithPadding(padding.Padme()), WithHeaderSize(64)},
+	},
+}
+
+func TestRatchetAppend(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range ratchetAppendTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			testRatchetAppend(t, test.options...)
+		})
+	}
+}
+
+func testRatchetAppend(t *testing.T, options ...Option) {
+	t.Helper()
+
+	sender, recipient := newTestRatchets(t, options...)
+	prefix := []byte("prefix")
+
+	// Note that the out of order messages and replies make the append methods fall back.
+	messages := []struct {
+		from, to *Ratchet
+		data     []byte
+		deferred bool
+	}{
+		{&sender, &recipient, []byte("first"), false},
+		{&sender, &recipient, []byte("second"), true},
+		{&sender, &recipient, []byte("third"), false},
+		{&recipient, &sender, []byte("reply"), false},
+		{&sender, &recipient, []byte("fourth"), false},
+	}
+
+	var deferred []func()
+
+	for _, message := range messages {
+		encryptedHeader, encryptedData, err := message.from.EncryptAppend(
+			prefix,
+			prefix,
+			message.data,
+			prefix,
+		)
+		if err != nil {
+			t.Fatalf("EncryptAppend(%s): expected no error but got %v", message.data, err)
+		}
+
+		if !bytes.HasPrefix(encryptedHeader, prefix) || !bytes.HasPrefix(encryptedData, prefix) {
+			t.Fatalf("EncryptAppend(%s): expected prefix to be kept", message.data)
+		}
+
+		decrypt := func() {
+			data, err := message.to.DecryptAppend(
+				prefix,
+				encryptedHeader[len(prefix):],
+				encryptedData[len(prefix):],
+				prefix,
+			)
+			if err != nil {
+				t.Fatalf("DecryptAppend(%s): expected no error but got %v", message.data, err)
+			}
+
+			if !bytes.Equal(data, append(bytes.Clone(prefix), message.data...)) {
+				t.Fatalf("DecryptAppend(): expected %s but got %s", message.data, data)
+			}
+		}
+
+		if message.deferred {
+			deferred = append(deferred, decrypt)
+
+			continue
+		}
+
+		decrypt()
+	}
+
+	for _, decrypt := range deferred {
+		decrypt()
+	}
+}
+
+func TestRatchetAppendInterop(t *testing.T) {
+	t.Parallel()
+
+	sender, recipient := newTestRatchets(t)
+
+	encryptedHeader, encryptedData, err := sender.EncryptAppend(nil, nil, []byte("data"), nil)
+	if err != nil {
+		t.Fatalf("EncryptAppend(): expected no error but got %v", err)
+	}
+
+	decryptTestMessage(t, &recipient, testMessage{
+		encryptedHeader: encryptedHeader,
+		encryptedData:   encryptedData,
+		data:            []byte("data"),
+	})
+
+	message := encryptTestMessage(t, &sender, []byte("message"))
+
+	data, err := recipient.DecryptAppend(nil, message.encryptedHeader, message.encryptedData, nil)
+	if err != nil {
+		t.Fatalf("DecryptAppend(): expected no error but got %v", err)
+	}
+
+	if !bytes.Equal(data, message.data) {
+		t.Fatalf("DecryptAppend(): expected %s but got %s", message.data, data)
+	}
+}
+
+func TestRatchetDecryptAppendForged(t *testing.T) {
+	t.Parallel()
+
+	sender, recipient := newTestRatchets(t)
+	decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("first")))
+
+	message := encryptTestMessage(t, &sender, []byte("second"))
+	forgedData := bytes.Clone(message.encryptedData)
+	forgedData[0] ^= 0xFF
+
+	_, err := recipient.DecryptAppend(nil, message.encryptedHeader, forgedData, nil)
+	if err == nil {
+		t.Fatal("DecryptAppend(): expected error for forged data but got nil")
+	}
+
+	decryptTestMessage(t, &recipient, message)
+}
+
+//nolint:paralleltest // AllocsPerRun panics in parallel tests.
+func TestRatchetAppendAllocs(t *testing.T) {
+	for _, test := range ratchetAppendTests {
+		t.Run(test.name, func(t *testing.T) {
+			testRatchetAppendAllocs(t, test.options...)
+		})
+	}
+}
+
+func testRatchetAppendAllocs(t *testing.T, options ...Option) {
+	t.Helper()
+
+	sender, recipient := newTestRatchets(t, options...)
+	decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("warm up")))
+
+	var (
+		data            = []byte("data")
+		encryptedHeader = make([]byte, 0, 256)
+		encryptedData   = make([]byte, 0, 256)
+		decryptedData   = make([]byte, 0, 256)
+		err             error
+	)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		encryptedHeader, encryptedData, err = sender.EncryptAppend(
+			encryptedHeader[:0],
+			encryptedData[:0],
+			data,
+			nil,
+		)
+		if err != nil {
+			t.Fatalf("EncryptAppend(): expected no error but got %v", err)
+		}
+
+		decryptedData, err = recipient.DecryptAppend(
+			decryptedData[:0],
+			encryptedHeader,
+			encryptedData,
+			nil,
+		)
+		if err != nil {
+			t.Fatalf("DecryptAppend(): expected no error but got %v", err)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("EncryptAppend() and DecryptAppend(): expected no allocations but got %v", allocs)
+	}
+}
+
+func TestSyncRatchetAppend(t *testing.T) {
+	t.Parallel()
+
+	sender, recipient := newTestRatchets(t)
+	syncSender := NewConcurrent(sender)
+	syncRecipient := NewConcurrent(recipient)
+
+	for _, data := range [][]byte{[]byte("first"), []byte("second")} {
+		encryptedHeader, encryptedData, err := syncSender.EncryptAppend(nil, nil, data, nil)
+		if err != nil {
+			t.Fatalf("EncryptAppend(): expected no error but got %v", err)
+		}
+
+		decryptedData, err := syncRecipient.DecryptAppend(nil, encryptedHeader, encryptedData, nil)
+		if err != nil {
+			t.Fatalf("DecryptAppend(): expected no error but got %v", err)
+		}
+
+		if !bytes.Equal(decryptedData, data) {
+			t.Fatalf("DecryptAppend(): expected %s but got %s", data, decryptedData)
+		}
+	}
+}
+
+func BenchmarkRatchetEncrypt(b *testing.B) {
+	sender, _ := newBenchmarkRatchets(b)
+	data := make([]byte, 1024)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+
+	for b.Loop() {
+		_, _, err := sender.Encrypt(data, nil)
+		if err != nil {
+			b.Fatalf("Encrypt(): expected no error but got %v", err)
+		}
+	}
+}
+
+func BenchmarkRatchetEncryptAppend(b *testing.B) {
+	sender, _ := newBenchmarkRatchets(b)
+	data := make([]byte, 1024)
+	encryptedHeader := make([]byte, 0, 256)
+	encryptedData := make([]byte, 0, 2048)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+
+	for b.Loop() {
+		var err error
+
+		encryptedHeader, encryptedData, err = sender.EncryptAppend(
+			encryptedHeader[:0],
+			encryptedData[:0],
+			data,
+			nil,
+		)
+		if err != nil {
+			b.Fatalf("EncryptAppend(): expected no error but got %v", err)
+		}
+	}
+}
+
+func BenchmarkRatchetDecrypt(b *testing.B) {
+	sender, recipient := newBenchmarkRatchets(b)
+	data := make([]byte, 1024)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+
+	for b.Loop() {
+		b.StopTimer()
+
+		encryptedHeader, encryptedData, err := sender.Encrypt(data, nil)
+		if err != nil {
+			b.Fatalf("Encrypt(): expected no error but got %v", err)
+		}
+
+		b.StartTimer()
+
+		_, err = recipient.Decrypt(encryptedHeader, encryptedData, nil)
+		if err != nil {
+			b.Fatalf("Decrypt(): expected no error but got %v", err)
+		}
+	}
+}
+
+func BenchmarkRatchetDecryptAppend(b *testing.B) {
+	sender, recipient := newBenchmarkRatchets(b)
+	data := make([]byte, 1024)
+	encryptedHeader := make([]byte, 0, 256)
+	encryptedData := make([]byte, 0, 2048)
+	decryptedData := make([]byte, 0, 2048)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+
+	for b.Loop() {
+		b.StopTimer()
+
+		var err error
+
+		encryptedHeader, encryptedData, err = sender.EncryptAppend(
+			encryptedHeader[:0],
+			encryptedData[:0],
+			data,
+			nil,
+		)
+		if err != nil {
+			b.Fatalf("EncryptAppend(): expected no error but got %v", err)
+		}
+
+		b.StartTimer()
+
+		decryptedData, err = recipient.DecryptAppend(
+			decryptedData[:0],
+			encryptedHeader,
+			encryptedData,
+			nil,
+		)
+		if err != nil {
+			b.Fatalf("DecryptAppend(): expected no error but got %v", err)
+		}
+	}
+}
+
+// newBenchmarkRatchets returns the ratchets, which already exchanged a message, so the
+// benchmarks measure the steady state.
+func newBenchmarkRatchets(b *testing.B) (sender, recipient *Ratchet) {
+	b.Helper()
+
+	senderRatchet, recipientRatchet := newTestRatchets(b)
+
+	encryptedHeader, encryptedData, err := senderRatchet.Encrypt([]byte("warm up"), nil)
+	if err != nil {
+		b.Fatalf("Encrypt(): expected no error but got %v", err)
+	}
+
+	_, err = recipientRatchet.Decrypt(encryptedHeader, encryptedData, nil)
+	if err != nil {
+		b.Fatalf("Decrypt(): expected no error but got %v", err)
+	}
+
+	return &senderRatchet, &recipientRatchet
+}
@@ -1,50 +1,126 @@
 package chainscommon
 
 import (
-	"errors"
-	"hash"
-	"io"
-
+	"github.com/platform-source/aegis/aead"
 	"github.com/platform-source/aegis/keys"
 	"golang.org/x/crypto/blake2b"
-	cipher "golang.org/x/crypto/chacha20poly1305"
-	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// MaxMessageCipherKeyAndNonceSize is the max size of the cipher key and the cipher nonce
+	// of all suites, so they may be derived to a fixed size buffer.
+	MaxMessageCipherKeyAndNonceSize = aead.MaxKeySize + aead.MaxNonceSize
+
+	// StreamNonceSuffixSize is the size of the stream nonce suffix, which is the big-endian
+	// chunk counter and the last chunk flag. The rest of the nonce is the derived prefix.
+	StreamNonceSuffixSize = 5
 )
 
 var (
-	messageCipherKDFOutputLen = cipher.KeySize + cipher.NonceSizeX
-	messageCipherKDFSalt      = make([]byte, messageCipherKDFOutputLen)
-	messageCipherKDFInfo      = []byte("message cipher")
+	messageCipherKDFSalt = make([]byte, MaxMessageCipherKeyAndNonceSize)
+	messageCipherKDFInfo = []byte("message cipher")
+	streamCipherKDFInfo  = []byte("stream cipher")
 )
 
-// DeriveMessageCipherKeyAndNonce derives a new cipher key and cipher nonce to encrypt a message.
-func DeriveMessageCipherKeyAndNonce(messageKey keys.Message) (key []byte, nonce []byte, err error) {
-	var newHasherErr error
+// AdvanceChainAppend derives the next master key and the message key from passed master
+// key with HMAC-BLAKE2b. The keys are appended to passed buffers, so it does not allocate
+// if the buffers have enough capacity.
+func AdvanceChainAppend(
+	dstMasterKey []byte,
+	dstMessageKey []byte,
+	masterKey keys.Master,
+) (keys.Master, keys.Message) {
+	const (
+		masterKeyByte  = 0x02
+		messageKeyByte = 0x01
+	)
 
-	kdf := hkdf.New(
-		func() hash.Hash {
-			hasher, err := blake2b.New512(nil)
-			newHasherErr = err
+	newMasterKey := keys.Master{
+		Bytes: AppendHMAC(dstMasterKey, masterKey.Bytes, []byte{masterKeyByte}),
+	}
 
-			return hasher
-		},
-		messageKey.Bytes,
-		messageCipherKDFSalt,
+	messageKey := keys.Message{
+		Bytes: AppendHMAC(dstMessageKey, masterKey.Bytes, []byte{messageKeyByte}),
+	}
+
+	return newMasterKey, messageKey
+}
+
+// AppendMessageCipherKeyAndNonce appends a new cipher key and cipher nonce of passed suite
+// to encrypt a message to dst. The key is the first suite.KeySize() bytes of the appended
+// data.
+//
+// It does not allocate if dst has enough capacity.
+func AppendMessageCipherKeyAndNonce(
+	dst []byte,
+	suite aead.Suite,
+	messageKey keys.Message,
+) []byte {
+	return appendKDFOutput(
+		dst,
+		messageKey,
 		messageCipherKDFInfo,
+		suite.KeySize()+suite.NonceSize(),
 	)
-	kdfOutput := make([]byte, messageCipherKDFOutputLen)
+}
 
-	_, err = io.ReadFull(kdf, kdfOutput)
-	if err != nil {
-		return nil, nil, errors.Join(ErrKDF, err)
-	}
+// DeriveMessageCipherKeyAndNonce derives a new cipher key and cipher nonce to encrypt a message
+// with XChaCha20-Poly1305.
+func DeriveMessageCipherKeyAndNonce(messageKey keys.Message) (key []byte, nonce []byte, err error) {
+	return DeriveSuiteMessageCipherKeyAndNonce(aead.XChaCha20Poly1305(), messageKey)
+}
 
-	if newHasherErr != nil {
-		return nil, nil, errors.Join(ErrNewHasher, newHasherErr)
-	}
+// DeriveSuiteMessageCipherKeyAndNonce derives a new cipher key and cipher nonce of passed
+// suite to encrypt a message.
+func DeriveSuiteMessageCipherKeyAndNonce(
+	suite aead.Suite,
+	messageKey keys.Message,
+) (key []byte, nonce []byte, err error) {
+	kdfOutput := AppendMessageCipherKeyAndNonce(nil, suite, messageKey)
 
-	key = kdfOutput[:cipher.KeySize]
-	nonce = kdfOutput[cipher.KeySize:]
+	key = kdfOutput[:suite.KeySize()]
+	nonce = kdfOutput[suite.KeySize():]
 
 	return key, nonce, nil
 }
+
+// DeriveStreamCipherKeyAndNoncePrefix derives a new cipher key and cipher nonce prefix of
+// passed suite to encrypt the chunks of a stream.
+func DeriveStreamCipherKeyAndNoncePrefix(
+	suite aead.Suite,
+	messageKey keys.Message,
+) (key []byte, noncePrefix []byte, err error) {
+	noncePrefixSize := suite.NonceSize() - StreamNonceSuffixSize
+	kdfOutput := appendKDFOutput(
+		nil,
+		messageKey,
+		streamCipherKDFInfo,
+		suite.KeySize()+noncePrefixSize,
+	)
+
+	key = kdfOutput[:suite.KeySize()]
+	noncePrefix = kdfOutput[suite.KeySize():]
+
+	return key, noncePrefix, nil
+}
+
+// appendKDFOutput appends outputLen bytes of HKDF-BLAKE2b output of the message key to dst.
+// The output fits the first HKDF expand block, so the expand step is a single HMAC.
+func appendKDFOutput(dst []byte, messageKey keys.Message, info []byte, outputLen int) []byte {
+	var pseudoRandomKey [blake2b.Size]byte
+
+	AppendHMAC(pseudoRandomKey[:0], messageCipherKDFSalt, messageKey.Bytes)
+
+	const firstBlockCounter = 0x01
+
+	var expandBlock [blake2b.Size]byte
+
+	AppendHMAC(expandBlock[:0], pseudoRandomKey[:], info, []byte{firstBlockCounter})
+
+	dst = append(dst, expandBlock[:outputLen]...)
+
+	clear(pseudoRandomKey[:])
+	clear(expandBlock[:])
+
+	return dst
+}
@@ -1,11 +1,19 @@
 package chainscommon
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/platform-source/aegis/aead"
 	"github.com/platform-source/aegis/keys"
 )
 
+var testSuites = []aead.Suite{
+	aead.XChaCha20Poly1305(),
+	aead.AES256GCM(),
+	aead.AES256GCMSIV(),
+}
+
 var deriveMessageCipherKeyAndNonceTests = []struct {
 	name       string
 	messageKey keys.Message
@@ -60,3 +68,100 @@ func TestDeriveMessageCipherKeyAndNonce(t *testing.T) {
 		})
 	}
 }
+
+func TestDeriveSuiteMessageCipherKeyAndNonce(t *testing.T) {
+	t.Parallel()
+
+	messageKey := keys.Message{
+		Bytes: []byte{1, 2, 3},
+	}
+
+	for _, suite := range testSuites {
+		key, nonce, err := DeriveSuiteMessageCipherKeyAndNonce(suite, messageKey)
+		if err != nil {
+			t.Fatalf(
+				"DeriveSuiteMessageCipherKeyAndNonce(%+v): expected no error but got %v",
+				messageKey,
+				err,
+			)
+		}
+
+		if len(key) != suite.KeySize() || len(nonce) != suite.NonceSize() {
+			t.Fatalf(
+				"DeriveSuiteMessageCipherKeyAndNonce(%+v): expected key and nonce sizes %d "+
+					"and %d but got %d and %d",
+				messageKey,
+				suite.KeySize(),
+				suite.NonceSize(),
+				len(key),
+				len(nonce),
+			)
+		}
+	}
+
+	key, nonce, err := DeriveMessageCipherKeyAndNonce(messageKey)
+	if err != nil {
+		t.Fatalf(
+			"DeriveMessageCipherKeyAndNonce(%+v): expected no error but got %v",
+			messageKey,
+			err,
+		)
+	}
+
+	suiteKey, suiteNonce, _ := DeriveSuiteMessageCipherKeyAndNonce(
+		aead.XChaCha20Poly1305(),
+		messageKey,
+	)
+	if !bytes.Equal(key, suiteKey) || !bytes.Equal(nonce, suiteNonce) {
+		t.Fatalf(
+			"DeriveMessageCipherKeyAndNonce(%+v): expected XChaCha20-Poly1305 key and nonce",
+			messageKey,
+		)
+	}
+}
+
+func TestDeriveStreamCipherKeyAndNoncePrefix(t *testing.T) {
+	t.Parallel()
+
+	messageKey := keys.Message{
+		Bytes: []byte{1, 2, 3},
+	}
+
+	for _, suite := range testSuites {
+		key, noncePrefix, err := DeriveStreamCipherKeyAndNoncePrefix(suite, messageKey)
+		if err != nil {
+			t.Fatalf(
+				"DeriveStreamCipherKeyAndNoncePrefix(%+v): expected no error but got %v",
+				messageKey,
+				err,
+			)
+		}
+
+		expectedNoncePrefixSize := suite.NonceSize() - StreamNonceSuffixSize
+		if len(noncePrefix) != expectedNoncePrefixSize {
+			t.Fatalf(
+				"DeriveStreamCipherKeyAndNoncePrefix(%+v): expected nonce prefix size %d "+
+					"but got %d",
+				messageKey,
+				expectedNoncePrefixSize,
+				len(noncePrefix),
+			)
+		}
+
+		messageCipherKey, _, err := DeriveSuiteMessageCipherKeyAndNonce(suite, messageKey)
+		if err != nil {
+			t.Fatalf(
+				"DeriveSuiteMessageCipherKeyAndNonce(%+v): expected no error but got %v",
+				messageKey,
+				err,
+			)
+		}
+
+		if bytes.Equal(key, messageCipherKey) {
+			t.Fatalf(
+				"DeriveStreamCipherKeyAndNoncePrefix(%+v): returned the message cipher key",
+				messageKey,
+			)
+		}
+	}
+}
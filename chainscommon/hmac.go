@@ -0,0 +1,91 @@
+package chainscommon
+
+import (
+	"crypto/hmac"
+	"hash"
+	"slices"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	hmacInnerPad = 0x36
+	hmacOuterPad = 0x5c
+
+	// hmacMaxInlineMessageLen is the max length of the message, which is hashed without
+	// heap allocations.
+	hmacMaxInlineMessageLen = 2 * blake2b.BlockSize
+)
+
+// AppendHMAC appends HMAC-BLAKE2b-512 of the concatenated message parts to dst.
+//
+// It does not allocate if dst has enough capacity and the message is short, which is
+// always the case for the chain keys.
+func AppendHMAC(dst []byte, key []byte, messageParts ...[]byte) []byte {
+	messageLen := 0
+	for _, part := range messageParts {
+		messageLen += len(part)
+	}
+
+	if messageLen > hmacMaxInlineMessageLen {
+		return appendStreamingHMAC(dst, key, messageParts)
+	}
+
+	var keyBlock [blake2b.BlockSize]byte
+
+	if len(key) > blake2b.BlockSize {
+		keySum := blake2b.Sum512(key)
+		copy(keyBlock[:], keySum[:])
+	} else {
+		copy(keyBlock[:], key)
+	}
+
+	var inner [blake2b.BlockSize + hmacMaxInlineMessageLen]byte
+
+	for index, keyByte := range keyBlock {
+		inner[index] = keyByte ^ hmacInnerPad
+	}
+
+	innerLen := blake2b.BlockSize
+	for _, part := range messageParts {
+		innerLen += copy(inner[innerLen:], part)
+	}
+
+	innerSum := blake2b.Sum512(inner[:innerLen])
+
+	var outer [blake2b.BlockSize + blake2b.Size]byte
+
+	for index, keyByte := range keyBlock {
+		outer[index] = keyByte ^ hmacOuterPad
+	}
+
+	copy(outer[blake2b.BlockSize:], innerSum[:])
+
+	sum := blake2b.Sum512(outer[:])
+	dst = append(dst, sum[:]...)
+
+	clear(keyBlock[:])
+	clear(inner[:])
+	clear(outer[:])
+
+	return dst
+}
+
+// appendStreamingHMAC computes HMAC of long messages. Note that the key and the message
+// are copied before they are passed to the hash interface, because otherwise the arguments
+// of AppendHMAC would be moved to the heap even for inline messages.
+func appendStreamingHMAC(dst []byte, key []byte, messageParts [][]byte) []byte {
+	mac := hmac.New(
+		func() hash.Hash {
+			// Note that the error is returned only for invalid keys, and the key is nil.
+			hasher, _ := blake2b.New512(nil)
+
+			return hasher
+		},
+		slices.Clone(key),
+	)
+
+	_, _ = mac.Write(slices.Concat(messageParts...))
+
+	return append(dst, mac.Sum(nil)...)
+}
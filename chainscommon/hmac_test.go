@@ -0,0 +1,107 @@
+package chainscommon
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"hash"
+	"io"
+	"testing"
+
+	"github.com/platform-source/aegis/aead"
+	"github.com/platform-source/aegis/keys"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/hkdf"
+)
+
+func newTestHasher() hash.Hash {
+	hasher, _ := blake2b.New512(nil)
+
+	return hasher
+}
+
+var appendHMACTests = []struct {
+	name    string
+	key     []byte
+	message []byte
+}{
+	{
+		"empty key and message",
+		nil,
+		nil,
+	},
+	{
+		"chain key",
+		bytes.Repeat([]byte{1}, blake2b.Size),
+		[]byte{0x01},
+	},
+	{
+		"long key",
+		bytes.Repeat([]byte{2}, blake2b.BlockSize+1),
+		[]byte("message"),
+	},
+	{
+		"long message",
+		bytes.Repeat([]byte{3}, blake2b.Size),
+		bytes.Repeat([]byte{4}, hmacMaxInlineMessageLen+1),
+	},
+}
+
+func TestAppendHMAC(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range appendHMACTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mac := hmac.New(newTestHasher, test.key)
+			_, _ = mac.Write(test.message)
+			expected := mac.Sum([]byte{0xFF})
+
+			middle := len(test.message) / 2
+
+			sum := AppendHMAC([]byte{0xFF}, test.key, test.message[:middle], test.message[middle:])
+			if !bytes.Equal(sum, expected) {
+				t.Fatalf("AppendHMAC(): expected %x but got %x", expected, sum)
+			}
+		})
+	}
+}
+
+func TestAppendMessageCipherKeyAndNonce(t *testing.T) {
+	t.Parallel()
+
+	messageKey := keys.Message{
+		Bytes: bytes.Repeat([]byte{1}, blake2b.Size),
+	}
+
+	for _, suite := range testSuites {
+		kdf := hkdf.New(newTestHasher, messageKey.Bytes, messageCipherKDFSalt, messageCipherKDFInfo)
+		expected := make([]byte, suite.KeySize()+suite.NonceSize())
+
+		_, err := io.ReadFull(kdf, expected)
+		if err != nil {
+			t.Fatalf("io.ReadFull(): expected no error but got %v", err)
+		}
+
+		output := AppendMessageCipherKeyAndNonce(nil, suite, messageKey)
+		if !bytes.Equal(output, expected) {
+			t.Fatalf("AppendMessageCipherKeyAndNonce(): expected %x but got %x", expected, output)
+		}
+	}
+}
+
+//nolint:paralleltest // AllocsPerRun panics in parallel tests.
+func TestAppendMessageCipherKeyAndNonceAllocs(t *testing.T) {
+	messageKey := keys.Message{
+		Bytes: bytes.Repeat([]byte{1}, blake2b.Size),
+	}
+
+	buffer := make([]byte, 0, MaxMessageCipherKeyAndNonceSize)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buffer = AppendMessageCipherKeyAndNonce(buffer[:0], aead.XChaCha20Poly1305(), messageKey)
+	})
+	if allocs != 0 {
+		t.Fatalf("AppendMessageCipherKeyAndNonce(): expected no allocations but got %v", allocs)
+	}
+}
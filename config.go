@@ -2,23 +2,43 @@ package ratchet
 
 import (
 	"errors"
+	"slices"
 
+	"github.com/platform-source/aegis/aead"
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/padding"
 	"github.com/platform-source/aegis/receivingchain"
 	"github.com/platform-source/aegis/rootchain"
 	"github.com/platform-source/aegis/sendingchain"
 	"github.com/platform-source/tools/check"
 )
 
+// defaultKEMHeaderMessages is the default count of the first messages of the sending chain,
+// which headers carry the KEM data.
+const defaultKEMHeaderMessages = 1
+
 type config struct {
-	crypto           Crypto
-	receivingOptions []receivingchain.Option
-	rootOptions      []rootchain.Option
-	sendingOptions   []sendingchain.Option
+	aead               aead.Suite
+	crypto             Crypto
+	headerSize         int
+	kem                KEM
+	kemHeaderMessages  uint64
+	kemRatchetInterval uint64
+	observer           Observer
+	padding            padding.Scheme
+	plaintextHeaders   bool
+	receivingOptions   []receivingchain.Option
+	rootOptions        []rootchain.Option
+	sendingOptions     []sendingchain.Option
+	secureAllocator    *keys.SecureAllocator
+	suite              Suite
+	suiteSet           bool
 }
 
 func newConfig(options ...Option) (config, error) {
 	cfg := config{
-		crypto: newDefaultCrypto(),
+		kem:               newDefaultKEM(),
+		kemHeaderMessages: defaultKEMHeaderMessages,
 	}
 
 	err := cfg.applyOptions(options...)
@@ -26,6 +46,13 @@ func newConfig(options ...Option) (config, error) {
 		return config{}, errors.Join(ErrApplyOptions, err)
 	}
 
+	err = cfg.applySuite()
+	if err != nil {
+		return config{}, errors.Join(ErrApplySuite, err)
+	}
+
+	cfg.applyChainOptions()
+
 	return cfg, nil
 }
 
@@ -40,10 +67,139 @@ func (cfg *config) applyOptions(options ...Option) error {
 	return nil
 }
 
+// applyChainOptions passes the crypto of the suite, the header mode, the padding, the observer
+// and the secure allocator to the chains.
+// The crypto of the suite set with WithSuite is passed with WithSuiteCrypto, so the chains
+// reject the crypto set with the chain options and never mix crypto of different suites.
+// Otherwise the AEAD set with WithAEAD is passed before the chain options, so the crypto set
+// with the chain options overrides it. Note that the slices of options are cloned or clipped,
+// so the options passed by the caller are not modified.
+func (cfg *config) applyChainOptions() {
+	switch {
+	case cfg.suiteSet:
+		cfg.rootOptions = append(
+			slices.Clip(cfg.rootOptions),
+			rootchain.WithSuiteCrypto(cfg.suite.rootCrypto),
+		)
+		cfg.sendingOptions = append(
+			slices.Clip(cfg.sendingOptions),
+			sendingchain.WithSuiteCrypto(cfg.suite.sendingCrypto),
+		)
+		cfg.receivingOptions = append(
+			slices.Clip(cfg.receivingOptions),
+			receivingchain.WithSuiteCrypto(cfg.suite.receivingCrypto),
+		)
+	case cfg.aead.Valid():
+		cfg.rootOptions = slices.Insert(
+			slices.Clone(cfg.rootOptions),
+			0,
+			rootchain.WithAEAD(cfg.aead),
+		)
+		cfg.sendingOptions = slices.Insert(
+			slices.Clone(cfg.sendingOptions),
+			0,
+			sendingchain.WithAEAD(cfg.aead),
+		)
+		cfg.receivingOptions = slices.Insert(
+			slices.Clone(cfg.receivingOptions),
+			0,
+			receivingchain.WithAEAD(cfg.aead),
+		)
+	}
+
+	if cfg.plaintextHeaders {
+		cfg.sendingOptions = append(
+			slices.Clip(cfg.sendingOptions),
+			sendingchain.WithPlaintextHeaders(),
+		)
+		cfg.receivingOptions = append(
+			slices.Clip(cfg.receivingOptions),
+			receivingchain.WithPlaintextHeaders(),
+		)
+	}
+
+	if cfg.padding.Valid() {
+		cfg.sendingOptions = append(
+			slices.Clip(cfg.sendingOptions),
+			sendingchain.WithPadding(cfg.padding),
+		)
+		cfg.receivingOptions = append(
+			slices.Clip(cfg.receivingOptions),
+			receivingchain.WithPadding(),
+		)
+	}
+
+	if cfg.observer.OnHeaderDecrypted != nil || cfg.observer.OnSkippedKey != nil {
+		cfg.receivingOptions = append(
+			slices.Clip(cfg.receivingOptions),
+			receivingchain.WithObserver(cfg.observer.receivingChainObserver()),
+		)
+	}
+
+	if cfg.headerSize > 0 {
+		cfg.sendingOptions = append(
+			slices.Clip(cfg.sendingOptions),
+			sendingchain.WithHeaderSize(cfg.headerSize),
+		)
+	}
+
+	if cfg.secureAllocator != nil {
+		cfg.rootOptions = append(
+			slices.Clip(cfg.rootOptions),
+			rootchain.WithAllocator(cfg.secureAllocator),
+		)
+		cfg.sendingOptions = append(
+			slices.Clip(cfg.sendingOptions),
+			sendingchain.WithAllocator(cfg.secureAllocator),
+		)
+		cfg.receivingOptions = append(
+			slices.Clip(cfg.receivingOptions),
+			receivingchain.WithAllocator(cfg.secureAllocator),
+		)
+	}
+}
+
+// applySuite resolves the suite. WithAEAD selects the suite of the package and WithCrypto
+// replaces its Diffie-Hellman crypto, so they can not be combined with WithSuite. The suite
+// without header encryption enables the plaintext headers.
+func (cfg *config) applySuite() error {
+	switch {
+	case cfg.suiteSet && (cfg.crypto != nil || cfg.aead.Valid()):
+		return ErrMixedSuite
+	case !cfg.suiteSet:
+		cfg.suite = suiteForAEAD(cfg.aead)
+	}
+
+	if cfg.crypto == nil {
+		cfg.crypto = cfg.suite.crypto
+	}
+
+	if cfg.suite.plaintextHeaders {
+		cfg.plaintextHeaders = true
+	}
+
+	return nil
+}
+
 // Option is a way to modify config default values.
 type Option func(cfg *config) error
 
-// WithCrypto sets passed crypto to the config.
+// WithAEAD selects the suite of the package with passed AEAD, e.g. AES256GCMSuite for
+// aead.AES256GCM. It can not be combined with WithSuite.
+func WithAEAD(suite aead.Suite) Option {
+	return func(cfg *config) error {
+		if !suite.Valid() {
+			return ErrInvalidAEAD
+		}
+
+		cfg.aead = suite
+
+		return nil
+	}
+}
+
+// WithCrypto sets passed Diffie-Hellman crypto, which replaces the crypto of the suite
+// of the package. It can not be combined with WithSuite.
 func WithCrypto(crypto Crypto) Option {
 	return func(cfg *config) error {
 		if check.IsNil(crypto) {
@@ -56,6 +212,99 @@ func WithCrypto(crypto Crypto) Option {
 	}
 }
 
+// WithHeaderSize pads the encoded headers to passed size before encryption, so all encrypted
+// headers have the same length. The headers of the post-quantum KEM steps are longer than the
+// others, so they are hidden only if passed size covers the KEM public key and ciphertext.
+func WithHeaderSize(size int) Option {
+	return func(cfg *config) error {
+		if size <= 0 {
+			return ErrInvalidHeaderSize
+		}
+
+		cfg.headerSize = size
+
+		return nil
+	}
+}
+
+// WithKEM sets passed key encapsulation mechanism to the config.
+func WithKEM(kem KEM) Option {
+	return func(cfg *config) error {
+		if check.IsNil(kem) {
+			return ErrKEMIsNil
+		}
+
+		cfg.kem = kem
+
+		return nil
+	}
+}
+
+// WithKEMHeaderMessages sets the count of the first messages of the sending chain, which
+// headers carry the KEM public key and ciphertext. Zero count attaches them to all messages of
+// the chain. The default count is 1.
+//
+// Please note that the keys of the chain depend on the KEM shared key, so the later messages
+// of the chain fail to decrypt until one of the first messages is decrypted.
+func WithKEMHeaderMessages(count uint64) Option {
+	return func(cfg *config) error {
+		cfg.kemHeaderMessages = count
+
+		return nil
+	}
+}
+
+// WithKEMRatchetInterval enables the sparse post-quantum ratchet. KEM shared key
+// is mixed into the root chain once per passed number of sending chains. Zero
+// interval disables the post-quantum ratchet.
+//
+// Please note that the post-quantum ratchet steps are performed only if both
+// participants enable it.
+func WithKEMRatchetInterval(interval uint64) Option {
+	return func(cfg *config) error {
+		cfg.kemRatchetInterval = interval
+
+		return nil
+	}
+}
+
+// WithObserver sets passed observer of the ratchet events.
+func WithObserver(observer Observer) Option {
+	return func(cfg *config) error {
+		cfg.observer = observer
+
+		return nil
+	}
+}
+
+// WithPadding pads the messages with passed scheme inside the ciphertext, so the length of
+// the ciphertext does not reveal the exact length of the message. Both participants must use
+// the padding, but the recipient removes the padding of any scheme.
+func WithPadding(scheme padding.Scheme) Option {
+	return func(cfg *config) error {
+		if !scheme.Valid() {
+			return ErrInvalidPadding
+		}
+
+		cfg.padding = scheme
+
+		return nil
+	}
+}
+
+// WithPlaintextHeaders enables the double ratchet without header encryption. The headers
+// are sent in clear and authenticated as the associated data of the message, so they are
+// never trial-decrypted and the skipped keys are found by the public key and the message
+// number of the header. The header keys passed to the constructors are not used. Both
+// participants must use the same mode.
+func WithPlaintextHeaders() Option {
+	return func(cfg *config) error {
+		cfg.plaintextHeaders = true
+
+		return nil
+	}
+}
+
 // WithReceivingChainOptions sets passed options to the receiving chain.
 func WithReceivingChainOptions(options ...receivingchain.Option) Option {
 	return func(cfg *config) error {
@@ -74,6 +323,37 @@ func WithRootChainOptions(options ...rootchain.Option) Option {
 	}
 }
 
+// WithSecureMemory sets passed secure allocator, which holds the chain keys, the root key and
+// the local private key. The keys are held in the heap if the allocator can not lock the
+// memory, which is reported in the stats.
+func WithSecureMemory(allocator *keys.SecureAllocator) Option {
+	return func(cfg *config) error {
+		if allocator == nil {
+			return ErrSecureAllocatorIsNil
+		}
+
+		cfg.secureAllocator = allocator
+
+		return nil
+	}
+}
+
+// WithSuite sets passed suite, which configures all crypto of the ratchet. The suite can
+// not be combined with WithAEAD, WithCrypto and the crypto options of the chains. Both
+// participants must use the same suite.
+func WithSuite(suite Suite) Option {
+	return func(cfg *config) error {
+		if suite.id == 0 {
+			return ErrInvalidSuite
+		}
+
+		cfg.suite = suite
+		cfg.suiteSet = true
+
+		return nil
+	}
+}
+
 // WithSendingChainOptions sets passed options to the sending chain.
 func WithSendingChainOptions(options ...sendingchain.Option) Option {
 	return func(cfg *config) error {
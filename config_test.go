@@ -5,8 +5,10 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/platform-source/aegis/aead"
 	"github.com/platform-source/aegis/header"
 	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/padding"
 	"github.com/platform-source/aegis/receivingchain"
 	"github.com/platform-source/aegis/rootchain"
 	"github.com/platform-source/aegis/sendingchain"
@@ -59,11 +61,27 @@ func (testSendingChainCrypto) EncryptMessage(_ keys.Message, _, _ []byte) ([]byt
 	return nil, nil
 }
 
+type testKEM struct{}
+
+func (testKEM) Decapsulate(_ keys.Private, _ []byte) (keys.Shared, error) {
+	return keys.Shared{}, nil
+}
+
+func (testKEM) Encapsulate(_ keys.Public) (keys.Shared, []byte, error) {
+	return keys.Shared{}, nil, nil
+}
+
+func (testKEM) GenerateKeyPair() (keys.Private, keys.Public, error) {
+	return keys.Private{}, keys.Public{}, nil
+}
+
 var newConfigTests = []struct {
 	name                        string
 	options                     []Option
 	errCategories               []error
 	expectedCrypto              Crypto
+	expectedKEM                 KEM
+	expectedKEMRatchetInterval  uint64
 	expectedReceivingOptionsLen int
 	expectedRootOptionsLen      int
 	expectedSendingOptionsLen   int
@@ -73,6 +91,8 @@ var newConfigTests = []struct {
 		nil,
 		nil,
 		defaultCrypto{},
+		defaultKEM{},
+		0,
 		0,
 		0,
 		0,
@@ -81,16 +101,199 @@ var newConfigTests = []struct {
 		"all options success",
 		[]Option{
 			WithCrypto(testCrypto{}),
+			WithKEM(testKEM{}),
+			WithKEMRatchetInterval(5),
 			WithReceivingChainOptions(receivingchain.WithCrypto(testReceivingChainCrypto{})),
 			WithRootChainOptions(rootchain.WithCrypto(testRootChainCrypto{})),
 			WithSendingChainOptions(sendingchain.WithCrypto(testSendingChainCrypto{})),
 		},
 		nil,
 		testCrypto{},
+		testKEM{},
+		5,
+		1,
+		1,
+		1,
+	},
+	{
+		"secure memory",
+		[]Option{
+			WithSecureMemory(keys.NewSecureAllocator()),
+		},
+		nil,
+		defaultCrypto{},
+		defaultKEM{},
+		0,
+		1,
+		1,
+		1,
+	},
+	{
+		"plaintext headers",
+		[]Option{
+			WithPlaintextHeaders(),
+		},
+		nil,
+		defaultCrypto{},
+		defaultKEM{},
+		0,
+		1,
+		0,
+		1,
+	},
+	{
+		"observer",
+		[]Option{
+			WithObserver(Observer{
+				OnSkippedKey: func(_ receivingchain.SkippedKeyEvent) {},
+			}),
+		},
+		nil,
+		defaultCrypto{},
+		defaultKEM{},
+		0,
+		1,
+		0,
+		0,
+	},
+	{
+		"padding",
+		[]Option{
+			WithPadding(padding.PowerOfTwo()),
+			WithHeaderSize(64),
+		},
+		nil,
+		defaultCrypto{},
+		defaultKEM{},
+		0,
+		1,
+		0,
+		2,
+	},
+	{
+		"invalid padding",
+		[]Option{
+			WithPadding(padding.Scheme{}),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrInvalidPadding,
+		},
+		nil,
+		nil,
+		0,
+		0,
+		0,
+		0,
+	},
+	{
+		"invalid header size",
+		[]Option{
+			WithHeaderSize(-1),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrInvalidHeaderSize,
+		},
+		nil,
+		nil,
+		0,
+		0,
+		0,
+		0,
+	},
+	{
+		"AEAD",
+		[]Option{
+			WithAEAD(aead.AES256GCM()),
+		},
+		nil,
+		defaultCrypto{},
+		defaultKEM{},
+		0,
+		1,
+		1,
+		1,
+	},
+	{
+		"invalid AEAD",
+		[]Option{
+			WithAEAD(aead.Suite{}),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrInvalidAEAD,
+		},
+		nil,
+		nil,
+		0,
+		0,
+		0,
+		0,
+	},
+	{
+		"suite",
+		[]Option{
+			WithSuite(AES256GCMSuite()),
+		},
+		nil,
+		defaultCrypto{},
+		defaultKEM{},
+		0,
 		1,
 		1,
 		1,
 	},
+	{
+		"invalid suite",
+		[]Option{
+			WithSuite(Suite{}),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrInvalidSuite,
+		},
+		nil,
+		nil,
+		0,
+		0,
+		0,
+		0,
+	},
+	{
+		"suite with AEAD",
+		[]Option{
+			WithSuite(DefaultSuite()),
+			WithAEAD(aead.AES256GCM()),
+		},
+		[]error{
+			ErrApplySuite,
+			ErrMixedSuite,
+		},
+		nil,
+		nil,
+		0,
+		0,
+		0,
+		0,
+	},
+	{
+		"suite with crypto",
+		[]Option{
+			WithSuite(DefaultSuite()),
+			WithCrypto(testCrypto{}),
+		},
+		[]error{
+			ErrApplySuite,
+			ErrMixedSuite,
+		},
+		nil,
+		nil,
+		0,
+		0,
+		0,
+		0,
+	},
 	{
 		"nil crypto",
 		[]Option{
@@ -101,6 +304,40 @@ var newConfigTests = []struct {
 			ErrCryptoIsNil,
 		},
 		nil,
+		nil,
+		0,
+		0,
+		0,
+		0,
+	},
+	{
+		"nil KEM",
+		[]Option{
+			WithKEM(nil),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrKEMIsNil,
+		},
+		nil,
+		nil,
+		0,
+		0,
+		0,
+		0,
+	},
+	{
+		"nil secure allocator",
+		[]Option{
+			WithSecureMemory(nil),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrSecureAllocatorIsNil,
+		},
+		nil,
+		nil,
+		0,
 		0,
 		0,
 		0,
@@ -133,6 +370,14 @@ func TestNewConfig(t *testing.T) {
 				t.Fatal("WithCrypto() option did not set passed crypto")
 			}
 
+			if reflect.TypeOf(cfg.kem) != reflect.TypeOf(test.expectedKEM) {
+				t.Fatal("WithKEM() option did not set passed KEM")
+			}
+
+			if cfg.kemRatchetInterval != test.expectedKEMRatchetInterval {
+				t.Fatal("WithKEMRatchetInterval() option did not set passed interval")
+			}
+
 			if len(cfg.receivingOptions) != test.expectedReceivingOptionsLen {
 				t.Fatal("WithReceivingChainOptions() option did not set passed options")
 			}
@@ -9,3 +9,10 @@ type Crypto interface {
 	ComputeSharedKey(privateKey keys.Private, publicKey keys.Public) (keys.Shared, error)
 	GenerateKeyPair() (keys.Private, keys.Public, error)
 }
+
+// KEM is the key encapsulation mechanism interface for the post-quantum ratchet.
+type KEM interface {
+	Decapsulate(privateKey keys.Private, ciphertext []byte) (keys.Shared, error)
+	Encapsulate(publicKey keys.Public) (keys.Shared, []byte, error)
+	GenerateKeyPair() (keys.Private, keys.Public, error)
+}
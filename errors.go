@@ -2,17 +2,28 @@ package ratchet
 
 import (
 	"errors"
+
+	"github.com/platform-source/aegis/internal/kem"
 )
 
 var (
+	// ErrAdvanceReceivingKEM is the receiving KEM ratchet step error.
+	ErrAdvanceReceivingKEM = errors.New("advance receiving KEM")
+
 	// ErrAdvanceRootChain is the root chain advance error.
 	ErrAdvanceRootChain = errors.New("advance root chain")
 
+	// ErrAdvanceSendingKEM is the sending KEM ratchet step error.
+	ErrAdvanceSendingKEM = errors.New("advance sending KEM")
+
 	// ErrApplyOptions is config options apply error.
 	ErrApplyOptions = errors.New("apply options")
 
-	// ErrAtomicDo is atomic error.
-	ErrAtomicDo = errors.New("atomic do")
+	// ErrApplySuite is the suite resolution error.
+	ErrApplySuite = errors.New("apply suite")
+
+	// ErrBuildBytes is the bytes building error.
+	ErrBuildBytes = errors.New("build bytes")
 
 	// ErrComputeSharedKey is the shared key compute error.
 	ErrComputeSharedKey = errors.New("compute shared key")
@@ -20,18 +31,79 @@ var (
 	// ErrCryptoIsNil is an error when nil crypto was passed.
 	ErrCryptoIsNil = errors.New("crypto is nil")
 
+	// ErrDecapsulate is the KEM decapsulation error.
+	ErrDecapsulate = errors.New("decapsulate")
+
+	// ErrDeriveStreamCipherKeyAndNoncePrefix is the stream cipher key and nonce prefix
+	// derivation error.
+	ErrDeriveStreamCipherKeyAndNoncePrefix = errors.New("derive stream cipher key and nonce prefix")
+
 	// ErrDiffieHellman is the diffie hellman algorithm error.
 	ErrDiffieHellman = errors.New("Diffie-Hellman")
 
+	// ErrEncapsulate is the KEM encapsulation error.
+	ErrEncapsulate = errors.New("encapsulate")
+
+	// ErrEncryptedHeaderTooLong is an error when the encrypted header does not fit the stream.
+	ErrEncryptedHeaderTooLong = errors.New("encrypted header too long")
+
+	// ErrGenerateDecapsulationKey is the KEM decapsulation key generation error.
+	ErrGenerateDecapsulationKey = kem.ErrGenerateDecapsulationKey
+
+	// ErrGenerateKEMKeyPair is the KEM key pair generation error.
+	ErrGenerateKEMKeyPair = errors.New("generate KEM key pair")
+
 	// ErrGenerateKeyPair is the key pair generation error.
 	ErrGenerateKeyPair = errors.New("generate key pair")
 
 	// ErrGeneratePrivateKey is the private key generation error.
 	ErrGeneratePrivateKey = errors.New("generate private key")
 
+	// ErrInvalidAEAD is an error when the AEAD suite is not valid.
+	ErrInvalidAEAD = errors.New("invalid AEAD")
+
+	// ErrInvalidEncoding is an error when encoded state is malformed.
+	ErrInvalidEncoding = errors.New("invalid encoding")
+
+	// ErrInvalidHeaderSize is an error when the header size is not positive.
+	ErrInvalidHeaderSize = errors.New("invalid header size")
+
+	// ErrInvalidPadding is an error when the padding scheme is not valid.
+	ErrInvalidPadding = errors.New("invalid padding")
+
+	// ErrInvalidSuite is an error when the suite is not valid.
+	ErrInvalidSuite = errors.New("invalid suite")
+
+	// ErrKEMIsNil is an error when nil KEM was passed.
+	ErrKEMIsNil = errors.New("KEM is nil")
+
+	// ErrKEMPrivateKeyIsNil is an error when KEM ciphertext was received unexpectedly.
+	ErrKEMPrivateKeyIsNil = errors.New("KEM private key is nil")
+
+	// ErrMarshalReceivingChain is the receiving chain encoding error.
+	ErrMarshalReceivingChain = errors.New("marshal receiving chain")
+
+	// ErrMarshalRootChain is the root chain encoding error.
+	ErrMarshalRootChain = errors.New("marshal root chain")
+
+	// ErrMarshalSendingChain is the sending chain encoding error.
+	ErrMarshalSendingChain = errors.New("marshal sending chain")
+
+	// ErrMixedSuite is an error when the suite is combined with other crypto options.
+	ErrMixedSuite = errors.New("mixed suite")
+
+	// ErrNewCipher is the new cipher creation error.
+	ErrNewCipher = errors.New("new cipher")
+
 	// ErrNewConfig is the config initialization error.
 	ErrNewConfig = errors.New("new config")
 
+	// ErrNewDecapsulationKey is the KEM decapsulation key initialization error.
+	ErrNewDecapsulationKey = kem.ErrNewDecapsulationKey
+
+	// ErrNewEncapsulationKey is the KEM encapsulation key initialization error.
+	ErrNewEncapsulationKey = kem.ErrNewEncapsulationKey
+
 	// ErrNewPrivateKey is the private key initialization error.
 	ErrNewPrivateKey = errors.New("new private key")
 
@@ -47,15 +119,48 @@ var (
 	// ErrNewSendingChain is the sending chain initialization error.
 	ErrNewSendingChain = errors.New("new sending chain")
 
+	// ErrOpenStreamChunk is an error when the stream chunk is forged, reordered or truncated.
+	ErrOpenStreamChunk = errors.New("open stream chunk")
+
 	// ErrRatchetSendingChain is the ratchet sending chain error.
 	ErrRatchetSendingChain = errors.New("ratchet sending chain")
 
+	// ErrReadStream is the stream read error.
+	ErrReadStream = errors.New("read stream")
+
 	// ErrReceivingChainDecrypt is the receiving chain decryption error.
 	ErrReceivingChainDecrypt = errors.New("receiving chain decrypt")
 
 	// ErrRemotePublicKeyIsNil is the remote public key nil error.
 	ErrRemotePublicKeyIsNil = errors.New("remote public key is nil")
 
+	// ErrReservedSuiteID is an error when a custom suite uses a reserved identifier.
+	ErrReservedSuiteID = errors.New("reserved suite ID")
+
+	// ErrSecureAllocatorIsNil is the nil secure allocator error.
+	ErrSecureAllocatorIsNil = errors.New("secure allocator is nil")
+
 	// ErrSendingChainEncrypt is the sending chain encryption error.
 	ErrSendingChainEncrypt = errors.New("sending chain encrypt")
+
+	// ErrStreamTooLong is an error when the stream has more chunks than the chunk counter allows.
+	ErrStreamTooLong = errors.New("stream too long")
+
+	// ErrSuiteMismatch is an error when encoded state was created with another suite.
+	ErrSuiteMismatch = errors.New("suite mismatch")
+
+	// ErrUnmarshalReceivingChain is the receiving chain decoding error.
+	ErrUnmarshalReceivingChain = errors.New("unmarshal receiving chain")
+
+	// ErrUnmarshalRootChain is the root chain decoding error.
+	ErrUnmarshalRootChain = errors.New("unmarshal root chain")
+
+	// ErrUnmarshalSendingChain is the sending chain decoding error.
+	ErrUnmarshalSendingChain = errors.New("unmarshal sending chain")
+
+	// ErrUnsupportedVersion is an error when encoded state has unknown version.
+	ErrUnsupportedVersion = errors.New("unsupported version")
+
+	// ErrWriteStream is the stream write error.
+	ErrWriteStream = errors.New("write stream")
 )
@@ -1,10 +1,9 @@
 module github.com/platform-source/aegis
 
-go 1.23.4
+go 1.24.0
 
 require (
 	github.com/platform-source/tools v0.2.5
 	golang.org/x/crypto v0.36.0
+	golang.org/x/sys v0.31.0
 )
-
-require golang.org/x/sys v0.31.0 // indirect
@@ -4,5 +4,17 @@ import (
 	"errors"
 )
 
-// ErrNotEnoughBytes is an error when not enough bytes passed.
-var ErrNotEnoughBytes = errors.New("not enough bytes")
+var (
+	// ErrFieldTooLong is an error when a variable length field is too long to be encoded.
+	ErrFieldTooLong = errors.New("field too long")
+
+	// ErrMessagesCountTooLarge is an error when the previous messages count is too large to be
+	// encoded.
+	ErrMessagesCountTooLarge = errors.New("messages count too large")
+
+	// ErrNotEnoughBytes is an error when not enough bytes passed.
+	ErrNotEnoughBytes = errors.New("not enough bytes")
+
+	// ErrUnknownFlags is an error when the flags byte of the header is unknown.
+	ErrUnknownFlags = errors.New("unknown flags")
+)
@@ -2,62 +2,178 @@ package header
 
 import (
 	"encoding/binary"
+	"math"
 
 	"github.com/platform-source/aegis/keys"
 	"github.com/platform-source/tools/sizes"
-	"github.com/platform-source/tools/slices"
 )
 
+const (
+	// fixedLen is the length of the message number and the previous messages count.
+	fixedLen = 2 * sizes.Uint64
+
+	// flagsIndex is the index of the flags byte, which is the most significant byte of the
+	// previous messages count. The byte is zero in the base encoding.
+	flagsIndex = fixedLen - 1
+
+	// maxMessagesCount is the max previous messages count, which leaves the flags byte free.
+	maxMessagesCount = 1<<(8*(sizes.Uint64-1)) - 1
+
+	// fieldLenSize is the size of variable length field prefix in bytes.
+	fieldLenSize = 2
+
+	// maxFieldLen is the max length of variable length field in bytes.
+	maxFieldLen = math.MaxUint16
+)
+
+// flagFields is the flag of the extended encoding, where the public key, the KEM public key
+// and the KEM ciphertext are length-prefixed and the header may be padded.
+const flagFields = 0x01
+
 // Header is the message header.
+//
+// KEM public key and ciphertext are set only when the post-quantum ratchet step
+// is performed in the current sending chain.
+//
+// MinEncodedLen is the min length of the encoded header. Shorter headers are padded with
+// zero bytes, which are ignored by Decode, so it is not decoded.
+//
+// The header without KEM fields and padding has the base encoding: the message number, the
+// previous messages count and the public key. Otherwise the flags byte is set and all
+// variable length fields are length-prefixed.
 type Header struct {
 	PublicKey                         keys.Public
 	PreviousSendingChainMessagesCount uint64
 	MessageNumber                     uint64
+	KEMPublicKey                      keys.Public
+	KEMCiphertext                     []byte
+	MinEncodedLen                     int
 }
 
 // Decode decodes header bytes to the struct.
 func Decode(headerBytes []byte) (Header, error) {
-	if len(headerBytes) < 2*sizes.Uint64 {
+	if len(headerBytes) < fixedLen {
 		return Header{}, ErrNotEnoughBytes
 	}
 
+	flags := headerBytes[flagsIndex]
+
 	header := Header{
 		MessageNumber: binary.LittleEndian.Uint64(
 			headerBytes[:sizes.Uint64],
 		),
 		PreviousSendingChainMessagesCount: binary.LittleEndian.Uint64(
-			headerBytes[sizes.Uint64 : 2*sizes.Uint64],
-		),
+			headerBytes[sizes.Uint64:fixedLen],
+		) & maxMessagesCount,
 	}
+	headerBytes = headerBytes[fixedLen:]
 
-	if len(headerBytes) > 2*sizes.Uint64 {
-		header.PublicKey = keys.Public{
-			Bytes: headerBytes[2*sizes.Uint64:],
+	switch flags {
+	case 0:
+		if len(headerBytes) > 0 {
+			header.PublicKey = keys.Public{
+				Bytes: headerBytes,
+			}
 		}
+
+		return header, nil
+	case flagFields:
+	default:
+		return Header{}, ErrUnknownFlags
+	}
+
+	fields := []*[]byte{
+		&header.PublicKey.Bytes,
+		&header.KEMPublicKey.Bytes,
+		&header.KEMCiphertext,
+	}
+
+	for _, field := range fields {
+		if len(headerBytes) < fieldLenSize {
+			return Header{}, ErrNotEnoughBytes
+		}
+
+		fieldLen := int(binary.LittleEndian.Uint16(headerBytes[:fieldLenSize]))
+		headerBytes = headerBytes[fieldLenSize:]
+
+		if len(headerBytes) < fieldLen {
+			return Header{}, ErrNotEnoughBytes
+		}
+
+		if fieldLen > 0 {
+			*field = headerBytes[:fieldLen]
+		}
+
+		headerBytes = headerBytes[fieldLen:]
 	}
 
 	return header, nil
 }
 
-// Encode encodes header struct to the bytes slice.
-func (h Header) Encode() []byte {
-	var messageNumberBytes [sizes.Uint64]byte
-	binary.LittleEndian.PutUint64(
-		messageNumberBytes[:],
-		h.MessageNumber,
-	)
-
-	var previousMessagesCountBytes [sizes.Uint64]byte
-	binary.LittleEndian.PutUint64(
-		previousMessagesCountBytes[:],
-		h.PreviousSendingChainMessagesCount,
-	)
-
-	headerBytes := slices.ConcatBytes(
-		messageNumberBytes[:],
-		previousMessagesCountBytes[:],
-		h.PublicKey.Bytes,
-	)
-
-	return headerBytes
+// AppendEncode appends encoded header to dst. It does not allocate if dst has enough
+// capacity.
+//
+// It returns an error if a variable length field of the extended encoding is longer than
+// 65535 bytes or the previous messages count does not fit 7 bytes.
+func (h Header) AppendEncode(dst []byte) ([]byte, error) {
+	if h.PreviousSendingChainMessagesCount > maxMessagesCount {
+		return nil, ErrMessagesCountTooLarge
+	}
+
+	headerStart := len(dst)
+
+	dst = binary.LittleEndian.AppendUint64(dst, h.MessageNumber)
+	dst = binary.LittleEndian.AppendUint64(dst, h.PreviousSendingChainMessagesCount)
+
+	if !h.extended() {
+		return append(dst, h.PublicKey.Bytes...), nil
+	}
+
+	dst[headerStart+flagsIndex] = flagFields
+
+	for _, field := range [...][]byte{h.PublicKey.Bytes, h.KEMPublicKey.Bytes, h.KEMCiphertext} {
+		if len(field) > maxFieldLen {
+			return nil, ErrFieldTooLong
+		}
+
+		dst = binary.LittleEndian.AppendUint16(dst, uint16(len(field)))
+		dst = append(dst, field...)
+	}
+
+	if paddingLen := h.MinEncodedLen - (len(dst) - headerStart); paddingLen > 0 {
+		dst = append(dst, make([]byte, paddingLen)...)
+	}
+
+	return dst, nil
+}
+
+// Encode encodes header struct to the bytes slice. It returns the same errors as
+// AppendEncode.
+func (h Header) Encode() ([]byte, error) {
+	return h.AppendEncode(make([]byte, 0, h.EncodedLen()))
+}
+
+// EncodedLen returns the length of the encoded header.
+func (h Header) EncodedLen() int {
+	if !h.extended() {
+		return h.baseEncodedLen()
+	}
+
+	headerLen := fixedLen
+	for _, field := range [...][]byte{h.PublicKey.Bytes, h.KEMPublicKey.Bytes, h.KEMCiphertext} {
+		headerLen += fieldLenSize + len(field)
+	}
+
+	return max(headerLen, h.MinEncodedLen)
+}
+
+// extended reports whether the header needs the extended encoding.
+func (h Header) extended() bool {
+	return len(h.KEMPublicKey.Bytes) > 0 ||
+		len(h.KEMCiphertext) > 0 ||
+		h.MinEncodedLen > h.baseEncodedLen()
+}
+
+func (h Header) baseEncodedLen() int {
+	return fixedLen + len(h.PublicKey.Bytes)
 }
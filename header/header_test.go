@@ -37,6 +37,27 @@ var encodeAndDecodeTests = []struct {
 			0x01, 0x02, 0x03, 0x04, 0x05,
 		},
 	},
+	{
+		"header with KEM fields",
+		Header{
+			PublicKey: keys.Public{
+				Bytes: []byte{0x01, 0x02},
+			},
+			PreviousSendingChainMessagesCount: 1,
+			MessageNumber:                     2,
+			KEMPublicKey: keys.Public{
+				Bytes: []byte{0x03, 0x04, 0x05},
+			},
+			KEMCiphertext: []byte{0x06},
+		},
+		[]byte{
+			0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+			0x02, 0x00, 0x01, 0x02,
+			0x03, 0x00, 0x03, 0x04, 0x05,
+			0x01, 0x00, 0x06,
+		},
+	},
 }
 
 func TestEncodeAndDecode(t *testing.T) {
@@ -46,11 +67,41 @@ func TestEncodeAndDecode(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 
-			bytes := test.header.Encode()
+			bytes, err := test.header.Encode()
+			if err != nil {
+				t.Fatalf("%+v.Encode(): expected no error but got %v", test.header, err)
+			}
+
 			if !slices.Equal(bytes, test.bytes) {
 				t.Fatalf("%+v.Encode(): expected %v but got %v", test.header, test.bytes, bytes)
 			}
 
+			if encodedLen := test.header.EncodedLen(); encodedLen != len(test.bytes) {
+				t.Fatalf(
+					"%+v.EncodedLen(): expected %d but got %d",
+					test.header,
+					len(test.bytes),
+					encodedLen,
+				)
+			}
+
+			prefix := []byte{0xFF}
+
+			appended, err := test.header.AppendEncode(prefix)
+			if err != nil {
+				t.Fatalf("%+v.AppendEncode(): expected no error but got %v", test.header, err)
+			}
+
+			if !slices.Equal(appended, append(prefix, test.bytes...)) {
+				t.Fatalf(
+					"%+v.AppendEncode(%v): expected prefixed %v but got %v",
+					test.header,
+					prefix,
+					test.bytes,
+					appended,
+				)
+			}
+
 			header, err := Decode(bytes)
 			if err != nil {
 				t.Fatalf("Decode(%v): expected no error but got %v", bytes, err)
@@ -85,6 +136,38 @@ var decodeTests = []struct {
 			ErrNotEnoughBytes,
 		},
 	},
+	{
+		"missing field length",
+		[]byte{
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+			0x00,
+		},
+		[]error{
+			ErrNotEnoughBytes,
+		},
+	},
+	{
+		"truncated field",
+		[]byte{
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+			0x03, 0x00, 0x01, 0x02,
+		},
+		[]error{
+			ErrNotEnoughBytes,
+		},
+	},
+	{
+		"unknown flags",
+		[]byte{
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02,
+		},
+		[]error{
+			ErrUnknownFlags,
+		},
+	},
 }
 
 func TestDecode(t *testing.T) {
@@ -109,3 +192,89 @@ func TestDecode(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeWithMinEncodedLen(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		minEncodedLen int
+		expectedLen   int
+	}{
+		{"shorter header", 64, 64},
+		{"longer header", 16, 21},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			head := Header{
+				PublicKey:     keys.Public{Bytes: []byte{0x01, 0x02, 0x03, 0x04, 0x05}},
+				MessageNumber: 321,
+				MinEncodedLen: test.minEncodedLen,
+			}
+
+			bytes, err := head.Encode()
+			if err != nil {
+				t.Fatalf("%+v.Encode(): expected no error but got %v", head, err)
+			}
+
+			if len(bytes) != test.expectedLen || head.EncodedLen() != test.expectedLen {
+				t.Fatalf(
+					"%+v.Encode(): expected length %d but got %d",
+					head,
+					test.expectedLen,
+					len(bytes),
+				)
+			}
+
+			decodedHeader, err := Decode(bytes)
+			if err != nil {
+				t.Fatalf("Decode(%v): expected no error but got %v", bytes, err)
+			}
+
+			head.MinEncodedLen = 0
+			if !reflect.DeepEqual(decodedHeader, head) {
+				t.Fatalf("Decode(%v): expected %+v but got %+v", bytes, head, decodedHeader)
+			}
+		})
+	}
+}
+
+func TestAppendEncodeErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		header      Header
+		expectedErr error
+	}{
+		{
+			"too long field",
+			Header{KEMCiphertext: make([]byte, maxFieldLen+1)},
+			ErrFieldTooLong,
+		},
+		{
+			"too large messages count",
+			Header{PreviousSendingChainMessagesCount: maxMessagesCount + 1},
+			ErrMessagesCountTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := test.header.AppendEncode(nil)
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("AppendEncode(): expected error %v but got %v", test.expectedErr, err)
+			}
+
+			_, err = test.header.Encode()
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("Encode(): expected error %v but got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
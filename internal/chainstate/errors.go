@@ -0,0 +1,16 @@
+package chainstate
+
+import (
+	"errors"
+)
+
+var (
+	// ErrBuildBytes is the bytes building error.
+	ErrBuildBytes = errors.New("build bytes")
+
+	// ErrInvalidEncoding is an error when encoded state is malformed.
+	ErrInvalidEncoding = errors.New("invalid encoding")
+
+	// ErrUnsupportedVersion is an error when encoded state has unknown version.
+	ErrUnsupportedVersion = errors.New("unsupported version")
+)
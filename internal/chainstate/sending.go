@@ -0,0 +1,117 @@
+package chainstate
+
+import (
+	"errors"
+
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/tools/convert"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const sendingMarshalVersion = 1
+
+// Sending is the state of the sending chain. It is shared by the sending chain encoding and
+// the packages, which distribute the state of the chain, so the keys never leave the chain
+// by other ways.
+type Sending struct {
+	MasterKey                  *keys.Master
+	HeaderKey                  *keys.Header
+	NextHeaderKey              keys.Header
+	NextMessageNumber          uint64
+	PreviousChainMessagesCount uint64
+}
+
+// MarshalSending encodes passed state of the sending chain to bytes.
+func MarshalSending(state Sending) ([]byte, error) {
+	builder := cryptobyte.NewBuilder(nil)
+	builder.AddUint8(sendingMarshalVersion)
+
+	if state.MasterKey == nil {
+		builder.AddUint8(0)
+	} else {
+		builder.AddUint8(1)
+		builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+			builder.AddBytes(state.MasterKey.Bytes)
+		})
+	}
+
+	if state.HeaderKey == nil {
+		builder.AddUint8(0)
+	} else {
+		builder.AddUint8(1)
+		builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+			builder.AddBytes(state.HeaderKey.Bytes)
+		})
+	}
+
+	builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(state.NextHeaderKey.Bytes)
+	})
+	builder.AddUint64(state.NextMessageNumber)
+	builder.AddUint64(state.PreviousChainMessagesCount)
+
+	data, err := builder.Bytes()
+	if err != nil {
+		return nil, errors.Join(ErrBuildBytes, err)
+	}
+
+	return data, nil
+}
+
+// UnmarshalSending decodes the state of the sending chain from bytes produced by
+// MarshalSending. The keys are cloned, so they do not share memory with passed data.
+func UnmarshalSending(data []byte) (Sending, error) {
+	input := cryptobyte.String(data)
+
+	var version uint8
+	if !input.ReadUint8(&version) {
+		return Sending{}, ErrInvalidEncoding
+	}
+
+	if version != sendingMarshalVersion {
+		return Sending{}, ErrUnsupportedVersion
+	}
+
+	var (
+		state              Sending
+		masterKeyPresent   uint8
+		headerKeyPresent   uint8
+		keyBytes           cryptobyte.String
+		nextHeaderKeyBytes cryptobyte.String
+	)
+
+	if !input.ReadUint8(&masterKeyPresent) {
+		return Sending{}, ErrInvalidEncoding
+	}
+
+	if masterKeyPresent != 0 {
+		if !input.ReadUint16LengthPrefixed(&keyBytes) {
+			return Sending{}, ErrInvalidEncoding
+		}
+
+		state.MasterKey = convert.ToPtr(keys.Master{Bytes: keyBytes}.Clone())
+	}
+
+	if !input.ReadUint8(&headerKeyPresent) {
+		return Sending{}, ErrInvalidEncoding
+	}
+
+	if headerKeyPresent != 0 {
+		if !input.ReadUint16LengthPrefixed(&keyBytes) {
+			return Sending{}, ErrInvalidEncoding
+		}
+
+		state.HeaderKey = convert.ToPtr(keys.Header{Bytes: keyBytes}.Clone())
+	}
+
+	if !input.ReadUint16LengthPrefixed(&nextHeaderKeyBytes) ||
+		!input.ReadUint64(&state.NextMessageNumber) ||
+		!input.ReadUint64(&state.PreviousChainMessagesCount) ||
+		!input.Empty() {
+		return Sending{}, ErrInvalidEncoding
+	}
+
+	state.NextHeaderKey = keys.Header{Bytes: nextHeaderKeyBytes}.Clone()
+
+	return state, nil
+}
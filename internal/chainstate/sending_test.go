@@ -0,0 +1,98 @@
+package chainstate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/platform-source/aegis/keys"
+)
+
+var sendingTests = []struct {
+	name  string
+	state Sending
+}{
+	{
+		"all keys",
+		Sending{
+			MasterKey:                  &keys.Master{Bytes: []byte{1, 2, 3}},
+			HeaderKey:                  &keys.Header{Bytes: []byte{4, 5, 6}},
+			NextHeaderKey:              keys.Header{Bytes: []byte{7, 8, 9}},
+			NextMessageNumber:          10,
+			PreviousChainMessagesCount: 11,
+		},
+	},
+	{
+		"no keys",
+		Sending{
+			NextHeaderKey:     keys.Header{Bytes: []byte{}},
+			NextMessageNumber: 1,
+		},
+	},
+}
+
+func TestSending(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range sendingTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := MarshalSending(test.state)
+			if err != nil {
+				t.Fatalf("MarshalSending(): expected no error but got %v", err)
+			}
+
+			state, err := UnmarshalSending(data)
+			if err != nil {
+				t.Fatalf("UnmarshalSending(%v): expected no error but got %v", data, err)
+			}
+
+			if !reflect.DeepEqual(state, test.state) {
+				t.Fatalf("UnmarshalSending(): expected %+v but got %+v", test.state, state)
+			}
+		})
+	}
+}
+
+var unmarshalSendingErrorTests = []struct {
+	name        string
+	data        []byte
+	expectedErr error
+}{
+	{
+		"nil data",
+		nil,
+		ErrInvalidEncoding,
+	},
+	{
+		"unsupported version",
+		[]byte{0xFF},
+		ErrUnsupportedVersion,
+	},
+	{
+		"truncated master key",
+		[]byte{sendingMarshalVersion, 0x01, 0x00, 0x03, 0x01},
+		ErrInvalidEncoding,
+	},
+}
+
+func TestUnmarshalSendingError(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range unmarshalSendingErrorTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := UnmarshalSending(test.data)
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf(
+					"UnmarshalSending(%v): expected error %v but got %v",
+					test.data,
+					test.expectedErr,
+					err,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package kem
+
+import (
+	"errors"
+)
+
+var (
+	// ErrDecapsulate is the KEM decapsulation error.
+	ErrDecapsulate = errors.New("decapsulate")
+
+	// ErrGenerateDecapsulationKey is the KEM decapsulation key generation error.
+	ErrGenerateDecapsulationKey = errors.New("generate decapsulation key")
+
+	// ErrNewDecapsulationKey is the KEM decapsulation key initialization error.
+	ErrNewDecapsulationKey = errors.New("new decapsulation key")
+
+	// ErrNewEncapsulationKey is the KEM encapsulation key initialization error.
+	ErrNewEncapsulationKey = errors.New("new encapsulation key")
+)
@@ -0,0 +1,65 @@
+package kem
+
+import (
+	"crypto/mlkem"
+	"errors"
+
+	"github.com/platform-source/aegis/keys"
+)
+
+// MLKEM768 is the ML-KEM-768 key encapsulation mechanism. Private keys are
+// stored as seeds.
+type MLKEM768 struct{}
+
+// Decapsulate returns the shared key encapsulated to passed ciphertext.
+func (MLKEM768) Decapsulate(privateKey keys.Private, ciphertext []byte) (keys.Shared, error) {
+	decapsulationKey, err := mlkem.NewDecapsulationKey768(privateKey.Bytes)
+	if err != nil {
+		return keys.Shared{}, errors.Join(ErrNewDecapsulationKey, err)
+	}
+
+	sharedKeyBytes, err := decapsulationKey.Decapsulate(ciphertext)
+	if err != nil {
+		return keys.Shared{}, errors.Join(ErrDecapsulate, err)
+	}
+
+	sharedKey := keys.Shared{
+		Bytes: sharedKeyBytes,
+	}
+
+	return sharedKey, nil
+}
+
+// Encapsulate generates a shared key and its ciphertext for passed public key.
+func (MLKEM768) Encapsulate(publicKey keys.Public) (keys.Shared, []byte, error) {
+	encapsulationKey, err := mlkem.NewEncapsulationKey768(publicKey.Bytes)
+	if err != nil {
+		return keys.Shared{}, nil, errors.Join(ErrNewEncapsulationKey, err)
+	}
+
+	sharedKeyBytes, ciphertext := encapsulationKey.Encapsulate()
+
+	sharedKey := keys.Shared{
+		Bytes: sharedKeyBytes,
+	}
+
+	return sharedKey, ciphertext, nil
+}
+
+// GenerateKeyPair generates a new key pair.
+func (MLKEM768) GenerateKeyPair() (keys.Private, keys.Public, error) {
+	decapsulationKey, err := mlkem.GenerateKey768()
+	if err != nil {
+		return keys.Private{}, keys.Public{}, errors.Join(ErrGenerateDecapsulationKey, err)
+	}
+
+	privateKey := keys.Private{
+		Bytes: decapsulationKey.Bytes(),
+	}
+
+	publicKey := keys.Public{
+		Bytes: decapsulationKey.EncapsulationKey().Bytes(),
+	}
+
+	return privateKey, publicKey, nil
+}
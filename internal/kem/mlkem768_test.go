@@ -0,0 +1,54 @@
+package kem
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/platform-source/aegis/keys"
+)
+
+func TestMLKEM768(t *testing.T) {
+	t.Parallel()
+
+	kem := MLKEM768{}
+
+	privateKey, publicKey, err := kem.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): expected no error but got %v", err)
+	}
+
+	encapsulatedKey, ciphertext, err := kem.Encapsulate(publicKey)
+	if err != nil {
+		t.Fatalf("Encapsulate(): expected no error but got %v", err)
+	}
+
+	decapsulatedKey, err := kem.Decapsulate(privateKey, ciphertext)
+	if err != nil {
+		t.Fatalf("Decapsulate(): expected no error but got %v", err)
+	}
+
+	if !bytes.Equal(encapsulatedKey.Bytes, decapsulatedKey.Bytes) {
+		t.Fatalf(
+			"Decapsulate(): expected shared key %v but got %v",
+			encapsulatedKey.Bytes,
+			decapsulatedKey.Bytes,
+		)
+	}
+}
+
+func TestMLKEM768InvalidKeys(t *testing.T) {
+	t.Parallel()
+
+	kem := MLKEM768{}
+
+	_, err := kem.Decapsulate(keys.Private{Bytes: []byte{1, 2, 3}}, nil)
+	if !errors.Is(err, ErrNewDecapsulationKey) {
+		t.Fatalf("Decapsulate(): expected new decapsulation key error but got %v", err)
+	}
+
+	_, _, err = kem.Encapsulate(keys.Public{Bytes: []byte{1, 2, 3}})
+	if !errors.Is(err, ErrNewEncapsulationKey) {
+		t.Fatalf("Encapsulate(): expected new encapsulation key error but got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package ratchet
+
+import (
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/tools/slices"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// kemState is the state of the sparse post-quantum ratchet.
+type kemState struct {
+	// privateKey is the local KEM private key, which public key was advertised
+	// and waits for the remote ciphertext.
+	privateKey *keys.Private
+
+	// remotePublicKey is the remote KEM public key, which was not used yet.
+	remotePublicKey *keys.Public
+
+	// sendingChainsCount is the count of sending chains since the last encapsulation.
+	sendingChainsCount uint64
+
+	// sendingPublicKey is the KEM public key sent in the current sending chain headers.
+	sendingPublicKey keys.Public
+
+	// sendingCiphertext is the KEM ciphertext sent in the current sending chain headers.
+	sendingCiphertext []byte
+}
+
+func (st kemState) clone() kemState {
+	st.privateKey = st.privateKey.ClonePtr()
+	st.remotePublicKey = st.remotePublicKey.ClonePtr()
+	st.sendingPublicKey = st.sendingPublicKey.Clone()
+	st.sendingCiphertext = slices.CloneBytes(st.sendingCiphertext)
+
+	return st
+}
+
+func (st kemState) marshal(builder *cryptobyte.Builder) {
+	if st.privateKey == nil {
+		addOptionalBytes(builder, nil, false)
+	} else {
+		addOptionalBytes(builder, st.privateKey.Bytes, true)
+	}
+
+	if st.remotePublicKey == nil {
+		addOptionalBytes(builder, nil, false)
+	} else {
+		addOptionalBytes(builder, st.remotePublicKey.Bytes, true)
+	}
+
+	builder.AddUint64(st.sendingChainsCount)
+	builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(st.sendingPublicKey.Bytes)
+	})
+	builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(st.sendingCiphertext)
+	})
+}
+
+func (st *kemState) unmarshal(input *cryptobyte.String) bool {
+	var (
+		privateKeyBytes        []byte
+		privateKeyPresent      bool
+		remotePublicKeyBytes   []byte
+		remotePublicKeyPresent bool
+		sendingPublicKeyBytes  cryptobyte.String
+		sendingCiphertext      cryptobyte.String
+	)
+
+	if !readOptionalBytes(input, &privateKeyBytes, &privateKeyPresent) ||
+		!readOptionalBytes(input, &remotePublicKeyBytes, &remotePublicKeyPresent) ||
+		!input.ReadUint64(&st.sendingChainsCount) ||
+		!input.ReadUint16LengthPrefixed(&sendingPublicKeyBytes) ||
+		!input.ReadUint16LengthPrefixed(&sendingCiphertext) {
+		return false
+	}
+
+	if privateKeyPresent {
+		st.privateKey = &keys.Private{Bytes: privateKeyBytes}
+	}
+
+	if remotePublicKeyPresent {
+		st.remotePublicKey = &keys.Public{Bytes: remotePublicKeyBytes}
+	}
+
+	if len(sendingPublicKeyBytes) > 0 {
+		st.sendingPublicKey = keys.Public{Bytes: slices.CloneBytes(sendingPublicKeyBytes)}
+	}
+
+	if len(sendingCiphertext) > 0 {
+		st.sendingCiphertext = slices.CloneBytes(sendingCiphertext)
+	}
+
+	return true
+}
@@ -0,0 +1,44 @@
+package keys
+
+import (
+	"github.com/platform-source/tools/slices"
+)
+
+// Allocator allocates memory for key bytes. Keys cloned into the memory of the allocator
+// keep it in their clones and release it with Wipe.
+type Allocator interface {
+	// Alloc must return zeroed memory of passed size.
+	Alloc(size int) []byte
+
+	// Free must zero and release passed memory returned by Alloc. Other memory must be
+	// zeroed only.
+	Free(memory []byte)
+}
+
+// cloneBytesTo clones passed bytes into the memory of passed allocator or into the heap if
+// the allocator is nil.
+func cloneBytesTo(allocator Allocator, bytes []byte) []byte {
+	if allocator == nil {
+		return slices.CloneBytes(bytes)
+	}
+
+	if len(bytes) == 0 {
+		return nil
+	}
+
+	memory := allocator.Alloc(len(bytes))
+	copy(memory, bytes)
+
+	return memory
+}
+
+// wipeBytes zeroes passed bytes and releases them to passed allocator if it is not nil.
+func wipeBytes(allocator Allocator, bytes []byte) {
+	if allocator == nil {
+		clear(bytes)
+
+		return
+	}
+
+	allocator.Free(bytes)
+}
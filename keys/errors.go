@@ -0,0 +1,25 @@
+package keys
+
+import (
+	"errors"
+)
+
+var (
+	// ErrAdviseMemory is the memory advice error.
+	ErrAdviseMemory = errors.New("advise memory")
+
+	// ErrLockMemory is the memory lock error.
+	ErrLockMemory = errors.New("lock memory")
+
+	// ErrMapMemory is the memory mapping error.
+	ErrMapMemory = errors.New("map memory")
+
+	// ErrProtectMemory is the memory protection error.
+	ErrProtectMemory = errors.New("protect memory")
+
+	// ErrSecureMemoryUnsupported is an error when the platform does not support secure memory.
+	ErrSecureMemoryUnsupported = errors.New("secure memory unsupported")
+
+	// ErrUnmapMemory is the memory unmapping error.
+	ErrUnmapMemory = errors.New("unmap memory")
+)
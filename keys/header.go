@@ -26,3 +26,14 @@ func (hk *Header) ClonePtr() *Header {
 
 	return &clone
 }
+
+// Wipe zeroes header key bytes and drops them. Value copies of the key share the
+// zeroed memory.
+func (hk *Header) Wipe() {
+	if hk == nil {
+		return
+	}
+
+	clear(hk.Bytes)
+	hk.Bytes = nil
+}
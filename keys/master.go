@@ -1,17 +1,22 @@
 package keys
 
-import (
-	"github.com/platform-source/tools/slices"
-)
-
 // Master is the master key to derive new message keys.
 type Master struct {
 	Bytes []byte
+
+	allocator Allocator
 }
 
 // Clone clones master key.
 func (mk Master) Clone() Master {
-	mk.Bytes = slices.CloneBytes(mk.Bytes)
+	return mk.CloneTo(mk.allocator)
+}
+
+// CloneTo clones master key into the memory of passed allocator. Nil allocator clones it into
+// the heap.
+func (mk Master) CloneTo(allocator Allocator) Master {
+	mk.Bytes = cloneBytesTo(allocator, mk.Bytes)
+	mk.allocator = allocator
 
 	return mk
 }
@@ -26,3 +31,15 @@ func (mk *Master) ClonePtr() *Master {
 
 	return &clone
 }
+
+// Wipe zeroes master key bytes, releases them to the allocator and drops them. Value copies
+// of the key share the zeroed memory.
+func (mk *Master) Wipe() {
+	if mk == nil {
+		return
+	}
+
+	wipeBytes(mk.allocator, mk.Bytes)
+	mk.Bytes = nil
+	mk.allocator = nil
+}
@@ -15,3 +15,14 @@ func (mk Message) Clone() Message {
 
 	return mk
 }
+
+// Wipe zeroes message key bytes and drops them. Value copies of the key share the
+// zeroed memory.
+func (mk *Message) Wipe() {
+	if mk == nil {
+		return
+	}
+
+	clear(mk.Bytes)
+	mk.Bytes = nil
+}
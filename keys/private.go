@@ -1,17 +1,45 @@
 package keys
 
-import (
-	"github.com/platform-source/tools/slices"
-)
-
 // Private key is participant's private key.
 type Private struct {
 	Bytes []byte
+
+	allocator Allocator
 }
 
 // Clone clones private key.
 func (pk Private) Clone() Private {
-	pk.Bytes = slices.CloneBytes(pk.Bytes)
+	return pk.CloneTo(pk.allocator)
+}
+
+// CloneTo clones private key into the memory of passed allocator. Nil allocator clones it into
+// the heap.
+func (pk Private) CloneTo(allocator Allocator) Private {
+	pk.Bytes = cloneBytesTo(allocator, pk.Bytes)
+	pk.allocator = allocator
 
 	return pk
 }
+
+// ClonePtr clones private key pointer.
+func (pk *Private) ClonePtr() *Private {
+	if pk == nil {
+		return nil
+	}
+
+	clone := pk.Clone()
+
+	return &clone
+}
+
+// Wipe zeroes private key bytes, releases them to the allocator and drops them. Value copies
+// of the key share the zeroed memory.
+func (pk *Private) Wipe() {
+	if pk == nil {
+		return
+	}
+
+	wipeBytes(pk.allocator, pk.Bytes)
+	pk.Bytes = nil
+	pk.allocator = nil
+}
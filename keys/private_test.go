@@ -39,3 +39,65 @@ func TestPrivateClone(t *testing.T) {
 		})
 	}
 }
+
+var privateClonePtrTests = []struct {
+	name string
+	key  *Private
+}{
+	{
+		"nil ptr to private key",
+		nil,
+	},
+	{
+		"ptr to zero private key",
+		&Private{},
+	},
+	{
+		"ptr to non-empty private key",
+		&Private{
+			Bytes: []byte{1, 2, 3, 4, 5},
+		},
+	},
+}
+
+func TestPrivateClonePtr(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range privateClonePtrTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			clone := test.key.ClonePtr()
+			if !reflect.DeepEqual(clone, test.key) {
+				t.Fatalf("%+v.ClonePtr() returned different value %+v", test.key, clone)
+			}
+
+			if clone != nil && len(clone.Bytes) > 0 && &clone.Bytes[0] == &test.key.Bytes[0] {
+				t.Fatalf("%+v.Clone() returned same bytes memory %p", test.key, &clone.Bytes[0])
+			}
+		})
+	}
+}
+
+func TestPrivateWipe(t *testing.T) {
+	t.Parallel()
+
+	key := Private{
+		Bytes: []byte{1, 2, 3, 4, 5},
+	}
+	keyBytes := key.Bytes
+
+	key.Wipe()
+
+	if key.Bytes != nil {
+		t.Fatalf("Wipe(): expected nil bytes but got %v", key.Bytes)
+	}
+
+	if !reflect.DeepEqual(keyBytes, make([]byte, len(keyBytes))) {
+		t.Fatalf("Wipe(): expected zeroed bytes but got %v", keyBytes)
+	}
+
+	var nilKey *Private
+
+	nilKey.Wipe()
+}
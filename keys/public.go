@@ -26,3 +26,14 @@ func (pk *Public) ClonePtr() *Public {
 
 	return &clone
 }
+
+// Wipe zeroes public key bytes and drops them. Value copies of the key share the
+// zeroed memory.
+func (pk *Public) Wipe() {
+	if pk == nil {
+		return
+	}
+
+	clear(pk.Bytes)
+	pk.Bytes = nil
+}
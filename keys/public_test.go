@@ -78,3 +78,26 @@ func TestPublicClonePtr(t *testing.T) {
 		})
 	}
 }
+
+func TestPublicWipe(t *testing.T) {
+	t.Parallel()
+
+	key := Public{
+		Bytes: []byte{1, 2, 3, 4, 5},
+	}
+	keyBytes := key.Bytes
+
+	key.Wipe()
+
+	if key.Bytes != nil {
+		t.Fatalf("Wipe(): expected nil bytes but got %v", key.Bytes)
+	}
+
+	if !reflect.DeepEqual(keyBytes, make([]byte, len(keyBytes))) {
+		t.Fatalf("Wipe(): expected zeroed bytes but got %v", keyBytes)
+	}
+
+	var nilKey *Public
+
+	nilKey.Wipe()
+}
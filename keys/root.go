@@ -1,17 +1,34 @@
 package keys
 
-import (
-	"github.com/platform-source/tools/slices"
-)
-
 // Root is the key of ratchet root chain.
 type Root struct {
 	Bytes []byte
+
+	allocator Allocator
 }
 
 // Clone clones root key.
 func (rk Root) Clone() Root {
-	rk.Bytes = slices.CloneBytes(rk.Bytes)
+	return rk.CloneTo(rk.allocator)
+}
+
+// CloneTo clones root key into the memory of passed allocator. Nil allocator clones it into
+// the heap.
+func (rk Root) CloneTo(allocator Allocator) Root {
+	rk.Bytes = cloneBytesTo(allocator, rk.Bytes)
+	rk.allocator = allocator
 
 	return rk
 }
+
+// Wipe zeroes root key bytes, releases them to the allocator and drops them. Value copies
+// of the key share the zeroed memory.
+func (rk *Root) Wipe() {
+	if rk == nil {
+		return
+	}
+
+	wipeBytes(rk.allocator, rk.Bytes)
+	rk.Bytes = nil
+	rk.allocator = nil
+}
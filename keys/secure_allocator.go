@@ -0,0 +1,268 @@
+package keys
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"unsafe"
+)
+
+// secureSlotSize is the size of the memory slot for small allocations. It fits all keys
+// of the default crypto, so they share pages.
+const secureSlotSize = 64
+
+// secureQuarantineDivisor is the part of the arena slots, which are kept in the quarantine
+// after they are freed, e.g. a quarter of the slots.
+const secureQuarantineDivisor = 4
+
+// SecureAllocatorStats are the statistics of the secure allocator.
+type SecureAllocatorStats struct {
+	// LockedBytes is the size of the locked memory mapped by the allocator.
+	LockedBytes uint64
+
+	// FallbackAllocs is the count of allocations made in the heap, because the memory could
+	// not be mapped or locked.
+	FallbackAllocs uint64
+
+	// FallbackErr is the error of the last fallback to the heap.
+	FallbackErr error
+
+	// StaleWrites is the count of the freed slots, which are written after they are freed,
+	// e.g. through a value copy of a wiped key. Such slots are never reused.
+	StaleWrites uint64
+}
+
+// SecureAllocator allocates key memory, which is locked in RAM, so it never hits swap, and
+// excluded from core dumps. Each mapping is surrounded by inaccessible guard pages. Small
+// allocations share pages, larger ones get their own.
+//
+// If the memory can not be mapped or locked, e.g. because of RLIMIT_MEMLOCK or on platforms
+// other than Linux, the heap is used instead and the fallback is reported in the stats.
+// The failure is cached, so the next allocations fall back without system calls until
+// Close.
+//
+// Value copies of a key share its memory, so the freed slots are zeroed and quarantined:
+// a small slot is reused only after a quarter of the slots of its arena are freed after
+// it, and only if it is still zeroed. Otherwise the slot is written through a stale copy
+// and never reused. A stale copy must not be wiped after its slot is reused, because it
+// would free the memory of another key.
+//
+// The allocator is safe for concurrent use. Keys must be wiped to release the memory.
+type SecureAllocator struct {
+	mu       sync.Mutex
+	arenas   []*secureArena
+	pageSize int
+	stats    SecureAllocatorStats
+	mapErr   error
+}
+
+// NewSecureAllocator creates a new secure allocator. The memory is mapped on demand.
+func NewSecureAllocator() *SecureAllocator {
+	return &SecureAllocator{
+		pageSize: os.Getpagesize(),
+	}
+}
+
+// Alloc returns zeroed memory of passed size.
+func (a *SecureAllocator) Alloc(size int) []byte {
+	if size <= 0 {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	slotSize := a.getSlotSize(size)
+
+	for _, arena := range a.arenas {
+		if arena.slotSize != slotSize {
+			continue
+		}
+
+		memory, ok := arena.alloc(size, &a.stats)
+		if ok {
+			return memory
+		}
+	}
+
+	if a.mapErr != nil {
+		return a.fallback(size, a.mapErr)
+	}
+
+	arena, err := newSecureArena(slotSize, a.pageSize)
+	if err != nil {
+		a.mapErr = err
+
+		return a.fallback(size, err)
+	}
+
+	a.arenas = append(a.arenas, arena)
+	a.stats.LockedBytes += uint64(len(arena.memory))
+
+	memory, _ := arena.alloc(size, &a.stats)
+
+	return memory
+}
+
+// Close zeroes and unmaps all memory of the allocator. Keys allocated before must not be
+// used after the call.
+func (a *SecureAllocator) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var err error
+
+	for _, arena := range a.arenas {
+		clear(arena.memory)
+		err = errors.Join(err, unmapSecureMemory(arena.region))
+	}
+
+	a.arenas = nil
+	a.stats.LockedBytes = 0
+	a.mapErr = nil
+
+	return err
+}
+
+// Free zeroes passed memory and releases it if it was returned by Alloc.
+func (a *SecureAllocator) Free(memory []byte) {
+	clear(memory)
+
+	if len(memory) == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, arena := range a.arenas {
+		if arena.free(memory) {
+			return
+		}
+	}
+}
+
+// Stats returns the statistics of the allocator.
+func (a *SecureAllocator) Stats() SecureAllocatorStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.stats
+}
+
+// fallback allocates passed size in the heap and records passed error of the secure memory.
+func (a *SecureAllocator) fallback(size int, err error) []byte {
+	a.stats.FallbackAllocs++
+	a.stats.FallbackErr = err
+
+	return make([]byte, size)
+}
+
+// getSlotSize returns the slot size for passed allocation size. Large allocations take
+// whole pages.
+func (a *SecureAllocator) getSlotSize(size int) int {
+	if size <= secureSlotSize {
+		return secureSlotSize
+	}
+
+	return (size + a.pageSize - 1) / a.pageSize * a.pageSize
+}
+
+// secureArena is the locked memory mapping split into slots of the same size. The freed
+// slots wait in the quarantine from the oldest one before they are free again.
+type secureArena struct {
+	region        []byte
+	memory        []byte
+	slotSize      int
+	freeSlots     []int
+	usedSlots     []bool
+	quarantine    []int
+	maxQuarantine int
+}
+
+func newSecureArena(slotSize int, pageSize int) (*secureArena, error) {
+	size := (slotSize + pageSize - 1) / pageSize * pageSize
+
+	region, memory, err := mapSecureMemory(size, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	slotsCount := size / slotSize
+	arena := &secureArena{
+		region:        region,
+		memory:        memory,
+		slotSize:      slotSize,
+		freeSlots:     make([]int, 0, slotsCount),
+		usedSlots:     make([]bool, slotsCount),
+		quarantine:    make([]int, 0, slotsCount),
+		maxQuarantine: slotsCount / secureQuarantineDivisor,
+	}
+
+	for slot := slotsCount - 1; slot >= 0; slot-- {
+		arena.freeSlots = append(arena.freeSlots, slot)
+	}
+
+	return arena, nil
+}
+
+// alloc allocates passed size in a free slot. The slots written after they are freed are
+// dropped and counted in passed stats.
+func (arena *secureArena) alloc(size int, stats *SecureAllocatorStats) ([]byte, bool) {
+	for len(arena.freeSlots) > 0 {
+		slot := arena.freeSlots[len(arena.freeSlots)-1]
+		arena.freeSlots = arena.freeSlots[:len(arena.freeSlots)-1]
+
+		start := slot * arena.slotSize
+		if !isZeroed(arena.memory[start : start+arena.slotSize]) {
+			stats.StaleWrites++
+
+			continue
+		}
+
+		arena.usedSlots[slot] = true
+
+		// Note that the capacity is limited, so appends never overwrite the next slot.
+		return arena.memory[start : start+size : start+size], true
+	}
+
+	return nil, false
+}
+
+// free releases the slot of passed memory and reports whether the memory belongs to
+// the arena.
+func (arena *secureArena) free(memory []byte) bool {
+	address := uintptr(unsafe.Pointer(unsafe.SliceData(memory)))
+	start := uintptr(unsafe.Pointer(unsafe.SliceData(arena.memory)))
+
+	if address < start || address >= start+uintptr(len(arena.memory)) {
+		return false
+	}
+
+	slot := int(address-start) / arena.slotSize
+	if !arena.usedSlots[slot] {
+		return true
+	}
+
+	clear(arena.memory[slot*arena.slotSize : (slot+1)*arena.slotSize])
+	arena.usedSlots[slot] = false
+	arena.quarantine = append(arena.quarantine, slot)
+
+	if len(arena.quarantine) > arena.maxQuarantine {
+		arena.freeSlots = append(arena.freeSlots, arena.quarantine[0])
+		arena.quarantine = append(arena.quarantine[:0], arena.quarantine[1:]...)
+	}
+
+	return true
+}
+
+// isZeroed reports whether all bytes of passed memory are zero.
+func isZeroed(memory []byte) bool {
+	for _, b := range memory {
+		if b != 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,227 @@
+package keys
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func newTestSecureAllocator(t *testing.T) *SecureAllocator {
+	t.Helper()
+
+	allocator := NewSecureAllocator()
+
+	t.Cleanup(func() {
+		err := allocator.Close()
+		if err != nil {
+			t.Errorf("Close(): expected no error but got %v", err)
+		}
+	})
+
+	return allocator
+}
+
+var secureAllocatorAllocTests = []struct {
+	name string
+	size int
+}{
+	{
+		"zero size",
+		0,
+	},
+	{
+		"slot size",
+		secureSlotSize,
+	},
+	{
+		"several pages",
+		3*4096 + 1,
+	},
+}
+
+func TestSecureAllocatorAlloc(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range secureAllocatorAllocTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			allocator := newTestSecureAllocator(t)
+
+			memory := allocator.Alloc(test.size)
+			if len(memory) != test.size || cap(memory) != test.size {
+				t.Fatalf(
+					"Alloc(%d): expected len and cap %d but got %d and %d",
+					test.size,
+					test.size,
+					len(memory),
+					cap(memory),
+				)
+			}
+
+			if !bytes.Equal(memory, make([]byte, test.size)) {
+				t.Fatalf("Alloc(%d): expected zeroed memory", test.size)
+			}
+
+			for i := range memory {
+				memory[i] = 0xFF
+			}
+
+			allocator.Free(memory)
+
+			if !bytes.Equal(memory, make([]byte, test.size)) {
+				t.Fatalf("Free(): expected zeroed memory")
+			}
+
+			stats := allocator.Stats()
+			if test.size > 0 && stats.LockedBytes == 0 && stats.FallbackAllocs != 1 {
+				t.Fatalf("Stats(): expected locked memory or fallback but got %+v", stats)
+			}
+		})
+	}
+}
+
+var secureAllocatorReuseTests = []struct {
+	name          string
+	staleWrite    bool
+	expectedReuse bool
+}{
+	{
+		"quarantined slot",
+		false,
+		true,
+	},
+	{
+		"slot written after free",
+		true,
+		false,
+	},
+}
+
+func TestSecureAllocatorReuse(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range secureAllocatorReuseTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			allocator := newTestSecureAllocator(t)
+
+			first := allocator.Alloc(32)
+			second := allocator.Alloc(32)
+
+			if allocator.Stats().FallbackAllocs > 0 {
+				t.Skipf("memory can not be locked: %v", allocator.Stats().FallbackErr)
+			}
+
+			if &first[0] == &second[0] {
+				t.Fatal("Alloc(): expected different memory for different allocations")
+			}
+
+			allocator.Free(first)
+			allocator.Free(first)
+
+			if test.staleWrite {
+				first[0] = 1
+			}
+
+			others := make([][]byte, allocator.arenas[0].maxQuarantine)
+			for i := range others {
+				others[i] = allocator.Alloc(16)
+				if &others[i][0] == &first[0] {
+					t.Fatalf("Alloc(): expected quarantined memory not to be reused by %d", i)
+				}
+			}
+
+			for _, other := range others {
+				allocator.Free(other)
+			}
+
+			third := allocator.Alloc(16)
+			if (&third[0] == &first[0]) != test.expectedReuse {
+				t.Fatalf("Alloc(): expected reuse of freed memory %t", test.expectedReuse)
+			}
+
+			expectedStaleWrites := uint64(0)
+			if test.staleWrite {
+				expectedStaleWrites = 1
+			}
+
+			if allocator.Stats().StaleWrites != expectedStaleWrites {
+				t.Fatalf(
+					"Stats(): expected %d stale writes but got %d",
+					expectedStaleWrites,
+					allocator.Stats().StaleWrites,
+				)
+			}
+		})
+	}
+}
+
+func TestSecureAllocatorQuarantineStaleKey(t *testing.T) {
+	t.Parallel()
+
+	allocator := newTestSecureAllocator(t)
+
+	key := Master{Bytes: bytes.Repeat([]byte{1}, 32)}.CloneTo(allocator)
+	if allocator.Stats().FallbackAllocs > 0 {
+		t.Skipf("memory can not be locked: %v", allocator.Stats().FallbackErr)
+	}
+
+	// Note that the value copy still points to the slot of the key after the key is wiped.
+	staleKey := key
+	key.Wipe()
+
+	newKey := Master{Bytes: bytes.Repeat([]byte{2}, 32)}.CloneTo(allocator)
+	if &newKey.Bytes[0] == &staleKey.Bytes[0] {
+		t.Fatal("CloneTo(): expected quarantined memory of the wiped key not to be reused")
+	}
+
+	if !bytes.Equal(staleKey.Bytes, make([]byte, 32)) {
+		t.Fatalf("CloneTo(): expected zeroed memory of the stale key but got %v", staleKey.Bytes)
+	}
+}
+
+func TestSecureAllocatorCachedFallback(t *testing.T) {
+	t.Parallel()
+
+	allocator := newTestSecureAllocator(t)
+	allocator.mapErr = ErrSecureMemoryUnsupported
+
+	for range 2 {
+		memory := allocator.Alloc(32)
+		if len(memory) != 32 {
+			t.Fatalf("Alloc(): expected 32 bytes but got %d", len(memory))
+		}
+	}
+
+	stats := allocator.Stats()
+	if stats.FallbackAllocs != 2 ||
+		!errors.Is(stats.FallbackErr, ErrSecureMemoryUnsupported) ||
+		len(allocator.arenas) != 0 {
+		t.Fatalf("Stats(): expected cached fallback but got %+v", stats)
+	}
+}
+
+func TestKeyCloneToAllocator(t *testing.T) {
+	t.Parallel()
+
+	allocator := newTestSecureAllocator(t)
+
+	key := Master{Bytes: []byte{1, 2, 3}}.CloneTo(allocator)
+	if !bytes.Equal(key.Bytes, []byte{1, 2, 3}) || key.allocator != allocator {
+		t.Fatalf("CloneTo(): expected key in allocator memory but got %+v", key)
+	}
+
+	clone := key.Clone()
+	if clone.allocator != allocator || &clone.Bytes[0] == &key.Bytes[0] {
+		t.Fatalf("Clone(): expected key clone in other allocator memory but got %+v", clone)
+	}
+
+	keyBytes := key.Bytes
+	key.Wipe()
+
+	if !bytes.Equal(keyBytes, []byte{0, 0, 0}) || key.allocator != nil {
+		t.Fatalf("Wipe(): expected key to be wiped but got %v", keyBytes)
+	}
+}
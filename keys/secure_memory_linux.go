@@ -0,0 +1,62 @@
+//go:build linux
+
+package keys
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// mapSecureMemory maps passed size of memory, which is locked in RAM and excluded from core
+// dumps, between two inaccessible guard pages. It returns the whole mapping and the memory.
+func mapSecureMemory(size int, pageSize int) ([]byte, []byte, error) {
+	region, err := unix.Mmap(
+		-1,
+		0,
+		size+2*pageSize,
+		unix.PROT_NONE,
+		unix.MAP_PRIVATE|unix.MAP_ANONYMOUS,
+	)
+	if err != nil {
+		return nil, nil, errors.Join(ErrMapMemory, err)
+	}
+
+	memory := region[pageSize : pageSize+size : pageSize+size]
+
+	err = lockSecureMemory(memory)
+	if err != nil {
+		return nil, nil, errors.Join(err, unmapSecureMemory(region))
+	}
+
+	return region, memory, nil
+}
+
+func lockSecureMemory(memory []byte) error {
+	err := unix.Mprotect(memory, unix.PROT_READ|unix.PROT_WRITE)
+	if err != nil {
+		return errors.Join(ErrProtectMemory, err)
+	}
+
+	err = unix.Mlock(memory)
+	if err != nil {
+		return errors.Join(ErrLockMemory, err)
+	}
+
+	err = unix.Madvise(memory, unix.MADV_DONTDUMP)
+	if err != nil {
+		return errors.Join(ErrAdviseMemory, err)
+	}
+
+	return nil
+}
+
+// unmapSecureMemory unmaps passed mapping. The memory is unlocked by the kernel.
+func unmapSecureMemory(region []byte) error {
+	err := unix.Munmap(region)
+	if err != nil {
+		return errors.Join(ErrUnmapMemory, err)
+	}
+
+	return nil
+}
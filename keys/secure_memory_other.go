@@ -0,0 +1,11 @@
+//go:build !linux
+
+package keys
+
+func mapSecureMemory(_ int, _ int) ([]byte, []byte, error) {
+	return nil, nil, ErrSecureMemoryUnsupported
+}
+
+func unmapSecureMemory(_ []byte) error {
+	return nil
+}
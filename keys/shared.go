@@ -4,3 +4,14 @@ package keys
 type Shared struct {
 	Bytes []byte
 }
+
+// Wipe zeroes shared key bytes and drops them. Value copies of the key share the
+// zeroed memory.
+func (sk *Shared) Wipe() {
+	if sk == nil {
+		return
+	}
+
+	clear(sk.Bytes)
+	sk.Bytes = nil
+}
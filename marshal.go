@@ -0,0 +1,231 @@
+package ratchet
+
+import (
+	"errors"
+
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/receivingchain"
+	"github.com/platform-source/aegis/rootchain"
+	"github.com/platform-source/aegis/sendingchain"
+	"github.com/platform-source/tools/slices"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const marshalVersion = 1
+
+// MarshalBinary encodes the whole ratchet state to bytes, so the session can be
+// restored with Unmarshal after the process restart.
+//
+// Note that the config is not encoded, so the same options must be passed to Unmarshal.
+// Only the suite identifier is encoded, so a state is never restored with another suite.
+func (r Ratchet) MarshalBinary() ([]byte, error) {
+	rootChainBytes, err := r.rootChain.MarshalBinary()
+	if err != nil {
+		return nil, errors.Join(ErrMarshalRootChain, err)
+	}
+
+	sendingChainBytes, err := r.sendingChain.MarshalBinary()
+	if err != nil {
+		return nil, errors.Join(ErrMarshalSendingChain, err)
+	}
+
+	receivingChainBytes, err := r.receivingChain.MarshalBinary()
+	if err != nil {
+		return nil, errors.Join(ErrMarshalReceivingChain, err)
+	}
+
+	builder := cryptobyte.NewBuilder(nil)
+	builder.AddUint8(marshalVersion)
+	builder.AddUint8(uint8(r.cfg.suite.id))
+	builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(r.localPrivateKey.Bytes)
+	})
+	builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(r.localPublicKey.Bytes)
+	})
+
+	if r.remotePublicKey == nil {
+		addOptionalBytes(builder, nil, false)
+	} else {
+		addOptionalBytes(builder, r.remotePublicKey.Bytes, true)
+	}
+
+	if r.needSendingChainRatchet {
+		builder.AddUint8(1)
+	} else {
+		builder.AddUint8(0)
+	}
+
+	for _, chainBytes := range [][]byte{rootChainBytes, sendingChainBytes, receivingChainBytes} {
+		builder.AddUint32LengthPrefixed(func(builder *cryptobyte.Builder) {
+			builder.AddBytes(chainBytes)
+		})
+	}
+
+	r.kem.marshal(builder)
+	builder.AddUint64(r.epochs)
+
+	data, err := builder.Bytes()
+	if err != nil {
+		return nil, errors.Join(ErrBuildBytes, err)
+	}
+
+	return data, nil
+}
+
+// Unmarshal decodes the ratchet state from bytes produced by MarshalBinary. Options
+// must be the same as for the original ratchet, so custom crypto and storages are
+// attached again.
+func Unmarshal(data []byte, options ...Option) (Ratchet, error) {
+	input := cryptobyte.String(data)
+
+	var version uint8
+	if !input.ReadUint8(&version) {
+		return Ratchet{}, ErrInvalidEncoding
+	}
+
+	if version != marshalVersion {
+		return Ratchet{}, ErrUnsupportedVersion
+	}
+
+	var suiteID uint8
+	if !input.ReadUint8(&suiteID) {
+		return Ratchet{}, ErrInvalidEncoding
+	}
+
+	var (
+		ratchet                 Ratchet
+		localPrivateKeyBytes    cryptobyte.String
+		localPublicKeyBytes     cryptobyte.String
+		remotePublicKeyBytes    []byte
+		remotePublicKeyPresent  bool
+		needSendingChainRatchet uint8
+		err                     error
+	)
+
+	if !input.ReadUint16LengthPrefixed(&localPrivateKeyBytes) ||
+		!input.ReadUint16LengthPrefixed(&localPublicKeyBytes) ||
+		!readOptionalBytes(&input, &remotePublicKeyBytes, &remotePublicKeyPresent) {
+		return Ratchet{}, ErrInvalidEncoding
+	}
+
+	ratchet.localPrivateKey = keys.Private{Bytes: localPrivateKeyBytes}.Clone()
+	ratchet.localPublicKey = keys.Public{Bytes: localPublicKeyBytes}.Clone()
+
+	if remotePublicKeyPresent {
+		ratchet.remotePublicKey = &keys.Public{Bytes: remotePublicKeyBytes}
+	}
+
+	if !input.ReadUint8(&needSendingChainRatchet) {
+		return Ratchet{}, ErrInvalidEncoding
+	}
+
+	ratchet.needSendingChainRatchet = needSendingChainRatchet != 0
+
+	ratchet.cfg, err = newConfig(options...)
+	if err != nil {
+		return Ratchet{}, errors.Join(ErrNewConfig, err)
+	}
+
+	if SuiteID(suiteID) != ratchet.cfg.suite.id {
+		return Ratchet{}, ErrSuiteMismatch
+	}
+
+	ratchet.protectLocalPrivateKey()
+
+	err = ratchet.unmarshalChains(&input)
+	if err != nil {
+		return Ratchet{}, err
+	}
+
+	if !ratchet.kem.unmarshal(&input) {
+		return Ratchet{}, ErrInvalidEncoding
+	}
+
+	if !input.ReadUint64(&ratchet.epochs) {
+		return Ratchet{}, ErrInvalidEncoding
+	}
+
+	if !input.Empty() {
+		return Ratchet{}, ErrInvalidEncoding
+	}
+
+	return ratchet, nil
+}
+
+func (r *Ratchet) unmarshalChains(input *cryptobyte.String) error {
+	var rootChainBytes, sendingChainBytes, receivingChainBytes []byte
+
+	if !readUint32LengthPrefixed(input, &rootChainBytes) ||
+		!readUint32LengthPrefixed(input, &sendingChainBytes) ||
+		!readUint32LengthPrefixed(input, &receivingChainBytes) {
+		return ErrInvalidEncoding
+	}
+
+	var err error
+
+	r.rootChain, err = rootchain.Unmarshal(rootChainBytes, r.cfg.rootOptions...)
+	if err != nil {
+		return errors.Join(ErrUnmarshalRootChain, err)
+	}
+
+	r.sendingChain, err = sendingchain.Unmarshal(sendingChainBytes, r.cfg.sendingOptions...)
+	if err != nil {
+		return errors.Join(ErrUnmarshalSendingChain, err)
+	}
+
+	r.receivingChain, err = receivingchain.Unmarshal(
+		receivingChainBytes,
+		r.cfg.receivingOptions...,
+	)
+	if err != nil {
+		return errors.Join(ErrUnmarshalReceivingChain, err)
+	}
+
+	return nil
+}
+
+// addOptionalBytes adds presence flag and, if bytes are present, length-prefixed bytes.
+func addOptionalBytes(builder *cryptobyte.Builder, bytes []byte, present bool) {
+	if !present {
+		builder.AddUint8(0)
+
+		return
+	}
+
+	builder.AddUint8(1)
+	builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(bytes)
+	})
+}
+
+// readOptionalBytes reads bytes added by addOptionalBytes. Read bytes are copied.
+func readOptionalBytes(input *cryptobyte.String, out *[]byte, present *bool) bool {
+	var (
+		flag  uint8
+		bytes cryptobyte.String
+	)
+
+	if !input.ReadUint8(&flag) {
+		return false
+	}
+
+	*present = flag != 0
+	if !*present {
+		return true
+	}
+
+	if !input.ReadUint16LengthPrefixed(&bytes) {
+		return false
+	}
+
+	*out = slices.CloneBytes(bytes)
+
+	return true
+}
+
+func readUint32LengthPrefixed(input *cryptobyte.String, out *[]byte) bool {
+	var length uint32
+
+	return input.ReadUint32(&length) && input.ReadBytes(out, int(length))
+}
@@ -0,0 +1,119 @@
+package ratchet
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRatchetMarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	sender, recipient := newTestRatchets(t)
+
+	skipped := encryptTestMessage(t, &sender, []byte("skipped"))
+	delivered := encryptTestMessage(t, &sender, []byte("delivered"))
+	decryptTestMessage(t, &recipient, delivered)
+
+	reply := encryptTestMessage(t, &recipient, []byte("reply"))
+
+	senderBytes, err := sender.MarshalBinary()
+	if err != nil {
+		t.Fatalf("sender.MarshalBinary(): expected no error but got %v", err)
+	}
+
+	recipientBytes, err := recipient.MarshalBinary()
+	if err != nil {
+		t.Fatalf("recipient.MarshalBinary(): expected no error but got %v", err)
+	}
+
+	restoredSender, err := Unmarshal(senderBytes)
+	if err != nil {
+		t.Fatalf("Unmarshal(sender): expected no error but got %v", err)
+	}
+
+	restoredRecipient, err := Unmarshal(recipientBytes)
+	if err != nil {
+		t.Fatalf("Unmarshal(recipient): expected no error but got %v", err)
+	}
+
+	decryptTestMessage(t, &restoredSender, reply)
+	decryptTestMessage(t, &restoredRecipient, skipped)
+
+	next := encryptTestMessage(t, &restoredSender, []byte("next"))
+	decryptTestMessage(t, &restoredRecipient, next)
+}
+
+var unmarshalTests = []struct {
+	name          string
+	data          []byte
+	errCategories []error
+}{
+	{
+		"nil data",
+		nil,
+		[]error{
+			ErrInvalidEncoding,
+		},
+	},
+	{
+		"unsupported version",
+		[]byte{0xFF},
+		[]error{
+			ErrUnsupportedVersion,
+		},
+	},
+	{
+		"truncated data",
+		[]byte{marshalVersion, 0x00, 0x20, 0x01},
+		[]error{
+			ErrInvalidEncoding,
+		},
+	},
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range unmarshalTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Unmarshal(test.data)
+
+			for _, errCategory := range test.errCategories {
+				if !errors.Is(err, errCategory) {
+					t.Fatalf(
+						"Unmarshal(%v): expected error %v but got %v",
+						test.data,
+						errCategory,
+						err,
+					)
+				}
+			}
+		})
+	}
+}
+
+func TestRatchetMarshalBinaryWithKEM(t *testing.T) {
+	t.Parallel()
+
+	sender, recipient := newTestRatchets(t, WithKEMRatchetInterval(1))
+
+	decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("ping")))
+
+	reply := encryptTestMessage(t, &recipient, []byte("reply"))
+
+	recipientBytes, err := recipient.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary(): expected no error but got %v", err)
+	}
+
+	recipient, err = Unmarshal(recipientBytes, WithKEMRatchetInterval(1))
+	if err != nil {
+		t.Fatalf("Unmarshal(): expected no error but got %v", err)
+	}
+
+	decryptTestMessage(t, &sender, reply)
+	decryptTestMessage(t, &sender, encryptTestMessage(t, &recipient, []byte("next reply")))
+	decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("pong")))
+}
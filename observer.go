@@ -0,0 +1,88 @@
+package ratchet
+
+import (
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/receivingchain"
+)
+
+// Observer receives the events of the ratchet, e.g. to emit log/slog records or metrics.
+// Nil callbacks are skipped. The callbacks are called synchronously, so they must be fast
+// and must not call the ratchet.
+//
+// Please note that the events carry no secret keys, so they are safe to log.
+type Observer struct {
+	// OnDecryptFailed is called when the message or the first chunk of the stream fails to
+	// decrypt.
+	OnDecryptFailed func(event receivingchain.DecryptFailedEvent)
+
+	// OnHeaderDecrypted is called when the header is decrypted with the current or the next
+	// header key of the receiving chain.
+	OnHeaderDecrypted func(event receivingchain.HeaderDecryptedEvent)
+
+	// OnRatchetStep is called when the Diffie-Hellman ratchet step is committed.
+	OnRatchetStep func(event RatchetStepEvent)
+
+	// OnSkippedKey is called when a skipped key is stored, consumed or evicted. Evictions
+	// are reported only by the default storage.
+	OnSkippedKey func(event receivingchain.SkippedKeyEvent)
+}
+
+// RatchetStepEvent is the event of the Diffie-Hellman ratchet step.
+type RatchetStepEvent struct {
+	// Epoch is the count of the ratchet steps including this one.
+	Epoch uint64
+
+	// Sending reports whether the sending chain is ratcheted before the encryption.
+	// Otherwise, the receiving chain is ratcheted by the message of the remote participant.
+	Sending bool
+
+	// LocalPublicKey is the local public key after the step.
+	LocalPublicKey keys.Public
+
+	// RemotePublicKey is the remote public key after the step.
+	RemotePublicKey keys.Public
+}
+
+// receivingChainObserver returns the observer of the receiving chain events. The failures
+// are reported by the ratchet, which joins the errors of the skipped keys and chain keys.
+func (o Observer) receivingChainObserver() receivingchain.Observer {
+	return receivingchain.Observer{
+		OnHeaderDecrypted: o.OnHeaderDecrypted,
+		OnSkippedKey:      o.OnSkippedKey,
+	}
+}
+
+// decryptFailed wraps passed decryption error into receivingchain.DecryptError and reports
+// it to the observer.
+func (r *Ratchet) decryptFailed(err error) error {
+	decryptErr := receivingchain.NewDecryptError(err)
+
+	if r.cfg.observer.OnDecryptFailed != nil {
+		r.cfg.observer.OnDecryptFailed(receivingchain.DecryptFailedEvent{
+			Stage: decryptErr.Stage,
+			Err:   decryptErr,
+		})
+	}
+
+	return decryptErr
+}
+
+// observeRatchetStep reports the ratchet step to the observer if the epochs count differs
+// from passed count.
+func (r *Ratchet) observeRatchetStep(previousEpochs uint64, sending bool) {
+	if r.cfg.observer.OnRatchetStep == nil || r.epochs == previousEpochs {
+		return
+	}
+
+	event := RatchetStepEvent{
+		Epoch:          r.epochs,
+		Sending:        sending,
+		LocalPublicKey: r.localPublicKey.Clone(),
+	}
+
+	if r.remotePublicKey != nil {
+		event.RemotePublicKey = r.remotePublicKey.Clone()
+	}
+
+	r.cfg.observer.OnRatchetStep(event)
+}
@@ -0,0 +1,85 @@
+package ratchet
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/platform-source/aegis/receivingchain"
+)
+
+func TestRatchetObserver(t *testing.T) {
+	t.Parallel()
+
+	// Note that both ratchets report to the same observer.
+	var events []string
+
+	observer := Observer{
+		OnDecryptFailed: func(event receivingchain.DecryptFailedEvent) {
+			events = append(events, fmt.Sprintf("decrypt failed at %v", event.Stage))
+		},
+		OnHeaderDecrypted: func(event receivingchain.HeaderDecryptedEvent) {
+			events = append(
+				events,
+				fmt.Sprintf("header %d with next key %t", event.MessageNumber, event.NextHeaderKey),
+			)
+		},
+		OnRatchetStep: func(event RatchetStepEvent) {
+			events = append(events, fmt.Sprintf("step %d sending %t", event.Epoch, event.Sending))
+		},
+		OnSkippedKey: func(event receivingchain.SkippedKeyEvent) {
+			events = append(events, fmt.Sprintf("key %d %v", event.MessageNumber, event.Kind))
+		},
+	}
+
+	sender, recipient := newTestRatchets(
+		t,
+		WithObserver(observer),
+		WithReceivingChainOptions(receivingchain.WithMaxSkippedKeys(1)),
+	)
+
+	messages := make([]testMessage, 4)
+	for i := range messages {
+		messages[i] = encryptTestMessage(t, &sender, []byte("message"))
+	}
+
+	decryptTestMessage(t, &recipient, messages[2])
+	decryptTestMessage(t, &recipient, messages[1])
+	decryptTestMessage(t, &recipient, messages[3])
+
+	tampered := messages[3]
+	tampered.encryptedData = slices.Clone(tampered.encryptedData)
+	tampered.encryptedData[0] ^= 1
+
+	_, err := recipient.Decrypt(messages[3].encryptedHeader, tampered.encryptedData, nil)
+	if err == nil {
+		t.Fatal("Decrypt(): expected error for tampered message")
+	}
+
+	_, err = recipient.Decrypt(tampered.encryptedData, tampered.encryptedData, nil)
+	if err == nil {
+		t.Fatal("Decrypt(): expected error for unknown header")
+	}
+
+	decryptTestMessage(t, &sender, encryptTestMessage(t, &recipient, []byte("reply")))
+
+	expectedEvents := []string{
+		"header 2 with next key true",
+		"key 0 stored",
+		"key 0 evicted",
+		"key 1 stored",
+		"step 1 sending false",
+		"key 1 consumed",
+		"header 3 with next key false",
+		"header 3 with next key false",
+		"decrypt failed at message",
+		"decrypt failed at header",
+		"step 2 sending true",
+		"header 0 with next key true",
+		"step 2 sending false",
+	}
+
+	if !slices.Equal(events, expectedEvents) {
+		t.Fatalf("Observer: expected events %q but got %q", expectedEvents, events)
+	}
+}
@@ -0,0 +1,10 @@
+package padding
+
+import (
+	"errors"
+)
+
+var (
+	// ErrInvalidPadding is an error when the padding of the data is malformed.
+	ErrInvalidPadding = errors.New("invalid padding")
+)
@@ -0,0 +1,120 @@
+package padding
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// marker is the first byte of the padding, which is followed by zero bytes like in
+// ISO/IEC 7816-4, so the padding is removed without knowing the scheme.
+const marker = 0x80
+
+type kind uint8
+
+const (
+	kindPadme kind = iota + 1
+	kindPowerOfTwo
+	kindBlock
+)
+
+// Scheme is the padding scheme, which hides the length of the data inside the ciphertext.
+// The zero value is not a valid scheme.
+//
+// Padmé leaks O(log log L) bits of the length L with at most 12% overhead. Power of two
+// buckets leak O(log log L) bits too, but with up to 100% overhead. Block padding has
+// constant overhead and hides the length only within the block.
+type Scheme struct {
+	kind      kind
+	blockSize int
+}
+
+// Padme returns the Padmé scheme of the PURBs paper.
+func Padme() Scheme {
+	return Scheme{kind: kindPadme}
+}
+
+// PowerOfTwo returns the scheme, which pads the data to the next power of two.
+func PowerOfTwo() Scheme {
+	return Scheme{kind: kindPowerOfTwo}
+}
+
+// Block returns the scheme, which pads the data to the multiple of passed block size.
+// The block size must be positive.
+func Block(size int) Scheme {
+	return Scheme{kind: kindBlock, blockSize: size}
+}
+
+// AppendPad appends padded data to dst. It does not allocate if dst has enough capacity.
+func (s Scheme) AppendPad(dst []byte, data []byte) []byte {
+	paddedLen := s.PaddedLen(len(data))
+
+	dst = slices.Grow(dst, paddedLen)
+	dst = append(dst, data...)
+	dst = append(dst, marker)
+
+	paddingStart := len(dst)
+	dst = dst[:paddingStart+paddedLen-len(data)-1]
+	clear(dst[paddingStart:])
+
+	return dst
+}
+
+// PaddedLen returns the length of the padded data with passed length. The padding always
+// has at least one byte.
+func (s Scheme) PaddedLen(dataLen int) int {
+	minLen := dataLen + 1
+
+	switch s.kind {
+	case kindPadme:
+		return padme(minLen)
+	case kindPowerOfTwo:
+		return 1 << bits.Len(uint(minLen-1))
+	case kindBlock:
+		return (minLen + s.blockSize - 1) / s.blockSize * s.blockSize
+	default:
+		return minLen
+	}
+}
+
+// Valid reports whether the scheme is known.
+func (s Scheme) Valid() bool {
+	switch s.kind {
+	case kindPadme, kindPowerOfTwo:
+		return true
+	case kindBlock:
+		return s.blockSize > 0
+	default:
+		return false
+	}
+}
+
+// Unpad returns passed padded data without the padding of any scheme. The data is not
+// copied.
+func Unpad(paddedData []byte) ([]byte, error) {
+	for i := len(paddedData) - 1; i >= 0; i-- {
+		switch paddedData[i] {
+		case 0:
+			continue
+		case marker:
+			return paddedData[:i], nil
+		default:
+			return nil, ErrInvalidPadding
+		}
+	}
+
+	return nil, ErrInvalidPadding
+}
+
+// padme rounds passed length up, so only the log2 of its exponent bits are kept after the
+// most significant bit.
+func padme(length int) int {
+	if length < 2 {
+		return length
+	}
+
+	exponent := bits.Len(uint(length)) - 1
+	lastBits := exponent - bits.Len(uint(exponent))
+	mask := 1<<lastBits - 1
+
+	return (length + mask) &^ mask
+}
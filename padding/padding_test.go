@@ -0,0 +1,125 @@
+package padding
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSchemePaddedLen(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		scheme        Scheme
+		dataLens      []int
+		expectedLens  []int
+		expectedValid bool
+	}{
+		{
+			"Padmé",
+			Padme(),
+			[]int{0, 1, 8, 99, 999, 1 << 20},
+			[]int{1, 2, 10, 104, 1024, 1<<20 + 1<<15},
+			true,
+		},
+		{
+			"power of two",
+			PowerOfTwo(),
+			[]int{0, 1, 7, 8, 1000},
+			[]int{1, 2, 8, 16, 1024},
+			true,
+		},
+		{"block", Block(16), []int{0, 15, 16, 100}, []int{16, 16, 32, 112}, true},
+		{"zero block", Block(0), nil, nil, false},
+		{"zero scheme", Scheme{}, nil, nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if test.scheme.Valid() != test.expectedValid {
+				t.Fatalf("Valid(): expected %t but got %t", test.expectedValid, test.scheme.Valid())
+			}
+
+			for i, dataLen := range test.dataLens {
+				paddedLen := test.scheme.PaddedLen(dataLen)
+				if paddedLen != test.expectedLens[i] {
+					t.Fatalf(
+						"PaddedLen(%d): expected %d but got %d",
+						dataLen,
+						test.expectedLens[i],
+						paddedLen,
+					)
+				}
+
+				data := bytes.Repeat([]byte{marker}, dataLen)
+
+				paddedData := test.scheme.AppendPad([]byte("dst"), data)
+				if len(paddedData) != len("dst")+paddedLen {
+					t.Fatalf(
+						"AppendPad(%d): expected length %d but got %d",
+						dataLen,
+						len("dst")+paddedLen,
+						len(paddedData),
+					)
+				}
+
+				unpaddedData, err := Unpad(paddedData[len("dst"):])
+				if err != nil {
+					t.Fatalf("Unpad(%d): expected no error but got %v", dataLen, err)
+				}
+
+				if !bytes.Equal(unpaddedData, data) {
+					t.Fatalf("Unpad(%d): expected %v but got %v", dataLen, data, unpaddedData)
+				}
+			}
+		})
+	}
+}
+
+//nolint:paralleltest // AllocsPerRun panics in parallel tests.
+func TestSchemeAppendPadAllocs(t *testing.T) {
+	data := []byte("data")
+	dst := make([]byte, 0, Padme().PaddedLen(len(data)))
+
+	allocs := testing.AllocsPerRun(10, func() {
+		_ = Padme().AppendPad(dst, data)
+	})
+	if allocs != 0 {
+		t.Fatalf("AppendPad(): expected no allocations but got %v", allocs)
+	}
+}
+
+func TestUnpad(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		paddedData   []byte
+		expectedData []byte
+		expectedErr  error
+	}{
+		{"marker only", []byte{marker}, []byte{}, nil},
+		{"zero bytes", []byte{1, marker, 0, 0}, []byte{1}, nil},
+		{"no marker", []byte{1, 2}, nil, ErrInvalidPadding},
+		{"only zero bytes", []byte{0, 0}, nil, ErrInvalidPadding},
+		{"empty", nil, nil, ErrInvalidPadding},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := Unpad(test.paddedData)
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("Unpad(): expected error %v but got %v", test.expectedErr, err)
+			}
+
+			if !bytes.Equal(data, test.expectedData) {
+				t.Fatalf("Unpad(): expected %v but got %v", test.expectedData, data)
+			}
+		})
+	}
+}
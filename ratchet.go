@@ -3,16 +3,19 @@ package ratchet
 import (
 	"errors"
 
+	"github.com/platform-source/aegis/header"
 	"github.com/platform-source/aegis/keys"
 	"github.com/platform-source/aegis/receivingchain"
 	"github.com/platform-source/aegis/rootchain"
 	"github.com/platform-source/aegis/sendingchain"
-	"github.com/platform-source/tools/atomic"
+	"github.com/platform-source/tools/convert"
+	"github.com/platform-source/tools/slices"
 )
 
 // Ratchet is the participant of the conversation.
 //
-// Please note that the structure is not safe for concurrent programs.
+// Please note that the structure is not safe for concurrent programs. Use SyncRatchet
+// in that case.
 type Ratchet struct {
 	localPrivateKey         keys.Private
 	localPublicKey          keys.Public
@@ -21,11 +24,15 @@ type Ratchet struct {
 	sendingChain            sendingchain.Chain
 	receivingChain          receivingchain.Chain
 	needSendingChainRatchet bool
+	epochs                  uint64
+	kem                     kemState
 	cfg                     config
 }
 
 // NewRecipient created a new ratchet recipient.
 //
+// The ratchet takes ownership of passed keys and wipes them once they are replaced.
+//
 // TODO: try to reduce arguments count.
 func NewRecipient(
 	localPrivateKey keys.Private,
@@ -47,6 +54,8 @@ func NewRecipient(
 		return Ratchet{}, errors.Join(ErrNewConfig, err)
 	}
 
+	ratchet.protectLocalPrivateKey()
+
 	ratchet.rootChain, err = rootchain.New(rootKey, ratchet.cfg.rootOptions...)
 	if err != nil {
 		return Ratchet{}, errors.Join(ErrNewRootChain, err)
@@ -80,6 +89,8 @@ func NewRecipient(
 
 // NewSender creates a new ratchet sender.
 //
+// The ratchet takes ownership of passed keys and wipes them once they are replaced.
+//
 // TODO: try to reduce arguments count.
 func NewSender(
 	remotePublicKey keys.Public,
@@ -104,17 +115,29 @@ func NewSender(
 		return Ratchet{}, errors.Join(ErrGenerateKeyPair, err)
 	}
 
+	ratchet.protectLocalPrivateKey()
+
 	sharedKey, err := ratchet.cfg.crypto.ComputeSharedKey(ratchet.localPrivateKey, remotePublicKey)
 	if err != nil {
 		return Ratchet{}, errors.Join(ErrComputeSharedKey, err)
 	}
+	defer sharedKey.Wipe()
 
 	ratchet.rootChain, err = rootchain.New(rootKey, ratchet.cfg.rootOptions...)
 	if err != nil {
 		return Ratchet{}, errors.Join(ErrNewRootChain, err)
 	}
 
-	sendingChainKey, sendingChainNextHeaderKey, err := ratchet.rootChain.Advance(sharedKey)
+	kemSharedKey, err := ratchet.advanceSendingKEM()
+	if err != nil {
+		return Ratchet{}, errors.Join(ErrAdvanceSendingKEM, err)
+	}
+	defer kemSharedKey.Wipe()
+
+	sendingChainKey, sendingChainNextHeaderKey, err := ratchet.advanceRootChain(
+		sharedKey,
+		kemSharedKey,
+	)
 	if err != nil {
 		return Ratchet{}, errors.Join(ErrAdvanceRootChain, err)
 	}
@@ -153,85 +176,326 @@ func (r Ratchet) Clone() Ratchet {
 	r.rootChain = r.rootChain.Clone()
 	r.sendingChain = r.sendingChain.Clone()
 	r.receivingChain = r.receivingChain.Clone()
+	r.kem = r.kem.clone()
 
 	return r
 }
 
 // Decrypt decrypts passed encrypted header and encrypted data and authenticates them with auth.
+// The errors are returned as *receivingchain.DecryptError.
 func (r *Ratchet) Decrypt(
 	encryptedHeader []byte,
 	encryptedData []byte,
 	auth []byte,
 ) ([]byte, error) {
-	var (
-		decryptedData []byte
-		err           error
-	)
+	decryptedData, skippedKeysErr := r.decryptWithSkippedKeys(encryptedHeader, encryptedData, auth)
+	if skippedKeysErr == nil {
+		return decryptedData, nil
+	}
 
-	err = atomic.Do(r, r.Clone(), func(r *Ratchet) error {
-		decryptedData, err = r.receivingChain.Decrypt(
-			encryptedHeader,
-			encryptedData,
-			auth,
-			r.ratchetReceivingChain,
-		)
-		if err != nil {
-			return errors.Join(ErrReceivingChainDecrypt, err)
-		}
+	// Note that the message of the found skipped key is not decrypted with chain keys.
+	if errors.Is(skippedKeysErr, receivingchain.ErrOpenMessage) {
+		return nil, r.decryptFailed(skippedKeysErr)
+	}
 
-		return nil
-	})
+	decryptedData, err := r.decryptWithChainKeys(encryptedHeader, encryptedData, auth)
 	if err != nil {
-		return nil, errors.Join(ErrAtomicDo, err)
+		return nil, r.decryptFailed(errors.Join(skippedKeysErr, err))
 	}
 
 	return decryptedData, nil
 }
 
+// Destroy wipes all keys of the ratchet including the skipped keys and resets it. The ratchet
+// must not be used after the call.
+func (r *Ratchet) Destroy() {
+	r.wipeWithoutReceivingChain()
+	r.receivingChain.Wipe()
+
+	*r = Ratchet{}
+}
+
 // Encrypt encrypts passed data and authenticates it with auth.
 func (r *Ratchet) Encrypt(
 	data []byte,
 	auth []byte,
 ) (encryptedHeader []byte, encryptedData []byte, err error) {
-	err = atomic.Do(r, r.Clone(), func(rDirty *Ratchet) error {
-		err = rDirty.ratchetSendingChainIfNeeded()
-		if err != nil {
-			return errors.Join(ErrRatchetSendingChain, err)
-		}
+	return r.encryptWithSendingChain(
+		func(chain *sendingchain.Chain, head header.Header) ([]byte, []byte, error) {
+			return chain.Encrypt(head, data, auth)
+		},
+	)
+}
+
+// LocalPublicKey returns a clone of the public key of the current local key pair.
+func (r Ratchet) LocalPublicKey() keys.Public {
+	return r.localPublicKey.Clone()
+}
+
+// RemotePublicKey returns a clone of the public key of the remote participant. It is nil
+// for the recipient until the first message is received.
+func (r Ratchet) RemotePublicKey() *keys.Public {
+	return r.remotePublicKey.ClonePtr()
+}
+
+// advanceRootChain advances the root chain with passed shared key and mixes
+// KEM shared key into it if it is not empty. Each advance starts a new epoch.
+func (r *Ratchet) advanceRootChain(
+	sharedKey keys.Shared,
+	kemSharedKey keys.Shared,
+) (keys.Master, keys.Header, error) {
+	var (
+		masterKey     keys.Master
+		nextHeaderKey keys.Header
+		err           error
+	)
+
+	if len(kemSharedKey.Bytes) == 0 {
+		masterKey, nextHeaderKey, err = r.rootChain.Advance(sharedKey)
+	} else {
+		masterKey, nextHeaderKey, err = r.rootChain.AdvanceWithKEM(sharedKey, kemSharedKey)
+	}
+
+	if err != nil {
+		return keys.Master{}, keys.Header{}, err
+	}
+
+	r.epochs++
+
+	return masterKey, nextHeaderKey, nil
+}
+
+// advanceReceivingKEM handles KEM data of the first header of the new receiving
+// chain and returns the decapsulated shared key if the header contains ciphertext.
+// Otherwise, the returned key is empty.
+func (r *Ratchet) advanceReceivingKEM(head header.Header) (keys.Shared, error) {
+	if r.cfg.kemRatchetInterval == 0 {
+		return keys.Shared{}, nil
+	}
+
+	if len(head.KEMPublicKey.Bytes) > 0 {
+		r.kem.remotePublicKey = convert.ToPtr(head.KEMPublicKey.Clone())
+	}
+
+	if len(head.KEMCiphertext) == 0 {
+		return keys.Shared{}, nil
+	}
+
+	if r.kem.privateKey == nil {
+		return keys.Shared{}, ErrKEMPrivateKeyIsNil
+	}
 
-		header := rDirty.sendingChain.PrepareHeader(rDirty.localPublicKey)
+	kemSharedKey, err := r.cfg.kem.Decapsulate(*r.kem.privateKey, head.KEMCiphertext)
+	if err != nil {
+		return keys.Shared{}, errors.Join(ErrDecapsulate, err)
+	}
 
-		encryptedHeader, encryptedData, err = rDirty.sendingChain.Encrypt(header, data, auth)
+	// The key pair is consumed, so the new one will be advertised in the next
+	// sending chain.
+	r.kem.privateKey.Wipe()
+	r.kem.privateKey = nil
+
+	return kemSharedKey, nil
+}
+
+// needKEMHeader reports whether the header of the sending chain message with passed number
+// carries the KEM data of the chain.
+func (r *Ratchet) needKEMHeader(messageNumber uint64) bool {
+	return r.cfg.kemHeaderMessages == 0 || messageNumber < r.cfg.kemHeaderMessages
+}
+
+// advanceSendingKEM prepares KEM data for the headers of the new sending chain and
+// returns the encapsulated shared key if the post-quantum ratchet step is due.
+// Otherwise, the returned key is empty.
+func (r *Ratchet) advanceSendingKEM() (keys.Shared, error) {
+	if r.cfg.kemRatchetInterval == 0 {
+		return keys.Shared{}, nil
+	}
+
+	r.kem.sendingPublicKey = keys.Public{}
+	r.kem.sendingCiphertext = nil
+
+	if r.kem.privateKey == nil {
+		privateKey, publicKey, err := r.cfg.kem.GenerateKeyPair()
 		if err != nil {
-			return errors.Join(ErrSendingChainEncrypt, err)
+			return keys.Shared{}, errors.Join(ErrGenerateKEMKeyPair, err)
 		}
 
-		return nil
-	})
+		r.kem.privateKey = &privateKey
+		r.kem.sendingPublicKey = publicKey
+	}
+
+	r.kem.sendingChainsCount++
+
+	if r.kem.remotePublicKey == nil || r.kem.sendingChainsCount < r.cfg.kemRatchetInterval {
+		return keys.Shared{}, nil
+	}
+
+	kemSharedKey, ciphertext, err := r.cfg.kem.Encapsulate(*r.kem.remotePublicKey)
+	if err != nil {
+		return keys.Shared{}, errors.Join(ErrEncapsulate, err)
+	}
+
+	r.kem.remotePublicKey = nil
+	r.kem.sendingChainsCount = 0
+	r.kem.sendingCiphertext = ciphertext
+
+	return kemSharedKey, nil
+}
+
+// cloneWithoutReceivingChain clones all ratchet state except the receiving chain, which is
+// left empty.
+func (r *Ratchet) cloneWithoutReceivingChain() Ratchet {
+	return Ratchet{
+		localPrivateKey:         r.localPrivateKey.Clone(),
+		localPublicKey:          r.localPublicKey.Clone(),
+		remotePublicKey:         r.remotePublicKey.ClonePtr(),
+		rootChain:               r.rootChain.Clone(),
+		sendingChain:            r.sendingChain.Clone(),
+		needSendingChainRatchet: r.needSendingChainRatchet,
+		epochs:                  r.epochs,
+		kem:                     r.kem.clone(),
+		cfg:                     r.cfg,
+	}
+}
+
+// decryptWithChainKeys decrypts passed message with the keys of the receiving
+// chain. The ratchet state is replaced only if decryption succeeds.
+func (r *Ratchet) decryptWithChainKeys(
+	encryptedHeader []byte,
+	encryptedData []byte,
+	auth []byte,
+) ([]byte, error) {
+	return r.receiveWithChainKeys(
+		func(ratchet receivingchain.HeaderRatchetCallback) ([]byte, error) {
+			return r.receivingChain.DecryptWithChainKeys(
+				encryptedHeader,
+				encryptedData,
+				auth,
+				ratchet,
+			)
+		},
+	)
+}
+
+// decryptWithSkippedKeys decrypts passed message with skipped keys. It modifies
+// only the skipped keys storage of the receiving chain.
+func (r *Ratchet) decryptWithSkippedKeys(
+	encryptedHeader []byte,
+	encryptedData []byte,
+	auth []byte,
+) ([]byte, error) {
+	decryptedData, err := r.receivingChain.DecryptWithSkippedKeys(
+		encryptedHeader,
+		encryptedData,
+		auth,
+	)
 	if err != nil {
-		return nil, nil, errors.Join(ErrAtomicDo, err)
+		return nil, errors.Join(ErrReceivingChainDecrypt, err)
 	}
 
+	return decryptedData, nil
+}
+
+// encryptWithSendingChain ratchets the sending chain if needed, prepares the header and
+// passes both to the encrypt callback. The ratchet state is replaced only if the callback
+// succeeds.
+func (r *Ratchet) encryptWithSendingChain(
+	encrypt func(chain *sendingchain.Chain, head header.Header) ([]byte, []byte, error),
+) (encryptedHeader []byte, encryptedData []byte, err error) {
+	// Note that the receiving chain is neither cloned nor replaced here, because
+	// encryption never touches it. The rest of the state is small, so the cost of
+	// the clone does not depend on the count of stored skipped keys.
+	dirty := r.cloneWithoutReceivingChain()
+	previousEpochs := r.epochs
+
+	err = dirty.ratchetSendingChainIfNeeded()
+	if err != nil {
+		dirty.wipeWithoutReceivingChain()
+
+		return nil, nil, errors.Join(ErrRatchetSendingChain, err)
+	}
+
+	header := dirty.sendingChain.PrepareHeader(dirty.localPublicKey)
+	if dirty.needKEMHeader(header.MessageNumber) {
+		header.KEMPublicKey = dirty.kem.sendingPublicKey.Clone()
+		header.KEMCiphertext = slices.CloneBytes(dirty.kem.sendingCiphertext)
+	}
+
+	encryptedHeader, encryptedData, err = encrypt(&dirty.sendingChain, header)
+	if err != nil {
+		dirty.wipeWithoutReceivingChain()
+
+		return nil, nil, errors.Join(ErrSendingChainEncrypt, err)
+	}
+
+	r.replaceWithoutReceivingChain(dirty)
+	r.observeRatchetStep(previousEpochs, true)
+
 	return encryptedHeader, encryptedData, nil
 }
 
-func (r *Ratchet) ratchetReceivingChain(remotePublicKey keys.Public) error {
-	r.remotePublicKey = &remotePublicKey
+// openWithChainKeys passes the message key of the receiving chain to the open callback.
+// The ratchet state is replaced only if the callback succeeds.
+func (r *Ratchet) openWithChainKeys(
+	encryptedHeader []byte,
+	open receivingchain.OpenCallback,
+) ([]byte, error) {
+	return r.receiveWithChainKeys(
+		func(ratchet receivingchain.HeaderRatchetCallback) ([]byte, error) {
+			return r.receivingChain.OpenWithChainKeys(encryptedHeader, open, ratchet)
+		},
+	)
+}
+
+// openWithSkippedKeys passes the skipped message key to the open callback. It modifies
+// only the skipped keys storage of the receiving chain.
+func (r *Ratchet) openWithSkippedKeys(
+	encryptedHeader []byte,
+	open receivingchain.OpenCallback,
+) ([]byte, error) {
+	openedData, err := r.receivingChain.OpenWithSkippedKeys(encryptedHeader, open)
+	if err != nil {
+		return nil, errors.Join(ErrReceivingChainDecrypt, err)
+	}
+
+	return openedData, nil
+}
+
+// protectLocalPrivateKey moves the local private key to the secure memory if it is set.
+func (r *Ratchet) protectLocalPrivateKey() {
+	if r.cfg.secureAllocator == nil {
+		return
+	}
+
+	localPrivateKey := r.localPrivateKey.CloneTo(r.cfg.secureAllocator)
+	r.localPrivateKey.Wipe()
+	r.localPrivateKey = localPrivateKey
+}
+
+func (r *Ratchet) ratchetReceivingChain(head header.Header) (keys.Master, keys.Header, error) {
+	r.remotePublicKey = convert.ToPtr(head.PublicKey.Clone())
 
-	sharedKey, err := r.cfg.crypto.ComputeSharedKey(r.localPrivateKey, remotePublicKey)
+	sharedKey, err := r.cfg.crypto.ComputeSharedKey(r.localPrivateKey, head.PublicKey)
 	if err != nil {
-		return errors.Join(ErrComputeSharedKey, err)
+		return keys.Master{}, keys.Header{}, errors.Join(ErrComputeSharedKey, err)
 	}
+	defer sharedKey.Wipe()
 
-	newMasterKey, newNextHeaderKey, err := r.rootChain.Advance(sharedKey)
+	kemSharedKey, err := r.advanceReceivingKEM(head)
 	if err != nil {
-		return errors.Join(ErrAdvanceRootChain, err)
+		return keys.Master{}, keys.Header{}, errors.Join(ErrAdvanceReceivingKEM, err)
+	}
+	defer kemSharedKey.Wipe()
+
+	newMasterKey, newNextHeaderKey, err := r.advanceRootChain(sharedKey, kemSharedKey)
+	if err != nil {
+		return keys.Master{}, keys.Header{}, errors.Join(ErrAdvanceRootChain, err)
 	}
 
-	r.receivingChain.Upgrade(newMasterKey, newNextHeaderKey)
 	r.needSendingChainRatchet = true
 
-	return nil
+	return newMasterKey, newNextHeaderKey, nil
 }
 
 func (r *Ratchet) ratchetSendingChainIfNeeded() error {
@@ -241,11 +505,15 @@ func (r *Ratchet) ratchetSendingChainIfNeeded() error {
 
 	var err error
 
+	r.localPrivateKey.Wipe()
+
 	r.localPrivateKey, r.localPublicKey, err = r.cfg.crypto.GenerateKeyPair()
 	if err != nil {
 		return errors.Join(ErrGenerateKeyPair, err)
 	}
 
+	r.protectLocalPrivateKey()
+
 	if r.remotePublicKey == nil {
 		return ErrRemotePublicKeyIsNil
 	}
@@ -254,8 +522,15 @@ func (r *Ratchet) ratchetSendingChainIfNeeded() error {
 	if err != nil {
 		return errors.Join(ErrComputeSharedKey, err)
 	}
+	defer sharedKey.Wipe()
 
-	newMasterKey, newNextHeaderKey, err := r.rootChain.Advance(sharedKey)
+	kemSharedKey, err := r.advanceSendingKEM()
+	if err != nil {
+		return errors.Join(ErrAdvanceSendingKEM, err)
+	}
+	defer kemSharedKey.Wipe()
+
+	newMasterKey, newNextHeaderKey, err := r.advanceRootChain(sharedKey, kemSharedKey)
 	if err != nil {
 		return errors.Join(ErrAdvanceRootChain, err)
 	}
@@ -265,3 +540,52 @@ func (r *Ratchet) ratchetSendingChainIfNeeded() error {
 
 	return nil
 }
+
+// receiveWithChainKeys calls passed receive function, which must use the keys of the
+// receiving chain, with the ratchet callback working on a clone of the ratchet state.
+// The state is replaced only if the function succeeds.
+func (r *Ratchet) receiveWithChainKeys(
+	receive func(ratchet receivingchain.HeaderRatchetCallback) ([]byte, error),
+) ([]byte, error) {
+	// Note that the receiving chain stages its own changes, so only the rest of
+	// the state is cloned here.
+	dirty := r.cloneWithoutReceivingChain()
+	previousEpochs := r.epochs
+
+	receivedData, err := receive(dirty.ratchetReceivingChain)
+	if err != nil {
+		dirty.wipeWithoutReceivingChain()
+
+		return nil, errors.Join(ErrReceivingChainDecrypt, err)
+	}
+
+	r.replaceWithoutReceivingChain(dirty)
+	r.observeRatchetStep(previousEpochs, false)
+
+	return receivedData, nil
+}
+
+// replaceWithoutReceivingChain replaces all ratchet state except the receiving chain with
+// the state of passed ratchet. The replaced keys are wiped, so passed ratchet must not share
+// memory with the current one.
+func (r *Ratchet) replaceWithoutReceivingChain(dirty Ratchet) {
+	r.wipeWithoutReceivingChain()
+
+	r.localPrivateKey = dirty.localPrivateKey
+	r.localPublicKey = dirty.localPublicKey
+	r.remotePublicKey = dirty.remotePublicKey
+	r.rootChain = dirty.rootChain
+	r.sendingChain = dirty.sendingChain
+	r.needSendingChainRatchet = dirty.needSendingChainRatchet
+	r.epochs = dirty.epochs
+	r.kem = dirty.kem
+}
+
+// wipeWithoutReceivingChain wipes the secret keys of all ratchet state except the receiving
+// chain.
+func (r *Ratchet) wipeWithoutReceivingChain() {
+	r.localPrivateKey.Wipe()
+	r.rootChain.Wipe()
+	r.sendingChain.Wipe()
+	r.kem.privateKey.Wipe()
+}
@@ -0,0 +1,786 @@
+package ratchet
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/platform-source/aegis/aead"
+	"github.com/platform-source/aegis/header"
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/padding"
+	"github.com/platform-source/aegis/receivingchain"
+	"github.com/platform-source/aegis/sendingchain"
+)
+
+func newTestKey(t testing.TB) []byte {
+	t.Helper()
+
+	key := make([]byte, 32)
+
+	_, err := rand.Read(key)
+	if err != nil {
+		t.Fatalf("rand.Read(): expected no error but got %v", err)
+	}
+
+	return key
+}
+
+func newTestRatchets(t testing.TB, options ...Option) (sender, recipient Ratchet) {
+	t.Helper()
+
+	recipientPrivateKey, recipientPublicKey, err := newDefaultCrypto().GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): expected no error but got %v", err)
+	}
+
+	rootKey := keys.Root{Bytes: newTestKey(t)}
+	senderHeaderKey := keys.Header{Bytes: newTestKey(t)}
+	recipientNextHeaderKey := keys.Header{Bytes: newTestKey(t)}
+
+	sender, err = NewSender(
+		recipientPublicKey,
+		rootKey.Clone(),
+		senderHeaderKey.Clone(),
+		recipientNextHeaderKey.Clone(),
+		options...,
+	)
+	if err != nil {
+		t.Fatalf("NewSender(): expected no error but got %v", err)
+	}
+
+	recipient, err = NewRecipient(
+		recipientPrivateKey,
+		recipientPublicKey,
+		rootKey.Clone(),
+		recipientNextHeaderKey.Clone(),
+		senderHeaderKey.Clone(),
+		options...,
+	)
+	if err != nil {
+		t.Fatalf("NewRecipient(): expected no error but got %v", err)
+	}
+
+	return sender, recipient
+}
+
+type testMessage struct {
+	encryptedHeader []byte
+	encryptedData   []byte
+	data            []byte
+}
+
+func encryptTestMessage(t *testing.T, ratchet *Ratchet, data []byte) testMessage {
+	t.Helper()
+
+	encryptedHeader, encryptedData, err := ratchet.Encrypt(data, nil)
+	if err != nil {
+		t.Fatalf("Encrypt(%v): expected no error but got %v", data, err)
+	}
+
+	message := testMessage{
+		encryptedHeader: encryptedHeader,
+		encryptedData:   encryptedData,
+		data:            data,
+	}
+
+	return message
+}
+
+func decryptTestMessage(t *testing.T, ratchet *Ratchet, message testMessage) {
+	t.Helper()
+
+	data, err := ratchet.Decrypt(message.encryptedHeader, message.encryptedData, nil)
+	if err != nil {
+		t.Fatalf("Decrypt(%v): expected no error but got %v", message.data, err)
+	}
+
+	if !bytes.Equal(data, message.data) {
+		t.Fatalf("Decrypt(): expected %v but got %v", message.data, data)
+	}
+}
+
+func TestRatchetConversation(t *testing.T) {
+	t.Parallel()
+
+	sender, recipient := newTestRatchets(t)
+
+	first := encryptTestMessage(t, &sender, []byte("first"))
+	second := encryptTestMessage(t, &sender, []byte("second"))
+	third := encryptTestMessage(t, &sender, []byte("third"))
+
+	decryptTestMessage(t, &recipient, third)
+	decryptTestMessage(t, &recipient, first)
+
+	reply := encryptTestMessage(t, &recipient, []byte("reply"))
+	decryptTestMessage(t, &sender, reply)
+
+	fourth := encryptTestMessage(t, &sender, []byte("fourth"))
+	decryptTestMessage(t, &recipient, fourth)
+	decryptTestMessage(t, &recipient, second)
+
+	_, err := recipient.Decrypt(second.encryptedHeader, second.encryptedData, nil)
+	if err == nil {
+		t.Fatal("Decrypt(): expected error for already decrypted message")
+	}
+}
+
+func TestRatchetPlaintextHeaders(t *testing.T) {
+	t.Parallel()
+
+	sender, recipient := newTestRatchets(t, WithPlaintextHeaders())
+
+	first := encryptTestMessage(t, &sender, []byte("first"))
+	second := encryptTestMessage(t, &sender, []byte("second"))
+	third := encryptTestMessage(t, &sender, []byte("third"))
+
+	head, err := header.Decode(third.encryptedHeader)
+	if err != nil || head.MessageNumber != 2 {
+		t.Fatalf("Encrypt(): expected plaintext header of the third message but got %v", err)
+	}
+
+	forgedHeader := bytes.Clone(third.encryptedHeader)
+	forgedHeader[0] ^= 1
+
+	_, err = recipient.Decrypt(forgedHeader, third.encryptedData, nil)
+	if err == nil {
+		t.Fatal("Decrypt(): expected error for forged header")
+	}
+
+	decryptTestMessage(t, &recipient, third)
+	decryptTestMessage(t, &recipient, first)
+	decryptTestMessage(t, &sender, encryptTestMessage(t, &recipient, []byte("reply")))
+
+	fourth := encryptTestMessage(t, &sender, []byte("fourth"))
+	decryptTestMessage(t, &recipient, fourth)
+	decryptTestMessage(t, &recipient, second)
+
+	for _, replayed := range []testMessage{second, third, fourth} {
+		_, err = recipient.Decrypt(replayed.encryptedHeader, replayed.encryptedData, nil)
+		if !errors.Is(err, receivingchain.ErrDuplicateMessage) {
+			t.Fatalf("Decrypt(%s): expected duplicate message error but got %v", replayed.data, err)
+		}
+	}
+
+	decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("fifth")))
+}
+
+func TestRatchetPadding(t *testing.T) {
+	t.Parallel()
+
+	const headerSize = 128
+
+	sender, recipient := newTestRatchets(
+		t,
+		WithPadding(padding.Block(32)),
+		WithHeaderSize(headerSize),
+	)
+
+	short := encryptTestMessage(t, &sender, []byte("short"))
+	long := encryptTestMessage(t, &sender, bytes.Repeat([]byte("long"), 7))
+
+	if len(short.encryptedData) != len(long.encryptedData) {
+		t.Fatalf(
+			"Encrypt(): expected same lengths but got %d and %d",
+			len(short.encryptedData),
+			len(long.encryptedData),
+		)
+	}
+
+	suite := aead.XChaCha20Poly1305()
+
+	expectedHeaderLen := suite.NonceSize() + headerSize + suite.Overhead()
+	if len(short.encryptedHeader) != expectedHeaderLen {
+		t.Fatalf(
+			"Encrypt(): expected header length %d but got %d",
+			expectedHeaderLen,
+			len(short.encryptedHeader),
+		)
+	}
+
+	decryptTestMessage(t, &recipient, long)
+	decryptTestMessage(t, &recipient, short)
+	decryptTestMessage(t, &sender, encryptTestMessage(t, &recipient, []byte("reply")))
+
+	sender, recipient = newTestRatchets(
+		t,
+		WithReceivingChainOptions(receivingchain.WithPadding()),
+	)
+
+	message := encryptTestMessage(t, &sender, []byte("data"))
+
+	_, err := recipient.Decrypt(message.encryptedHeader, message.encryptedData, nil)
+	if !errors.Is(err, receivingchain.ErrUnpad) {
+		t.Fatalf("Decrypt(): expected error %v but got %v", receivingchain.ErrUnpad, err)
+	}
+}
+
+var ratchetAEADTests = []struct {
+	name  string
+	suite aead.Suite
+}{
+	{
+		"XChaCha20-Poly1305",
+		aead.XChaCha20Poly1305(),
+	},
+	{
+		"AES-256-GCM",
+		aead.AES256GCM(),
+	},
+	{
+		"AES-256-GCM-SIV",
+		aead.AES256GCMSIV(),
+	},
+}
+
+func TestRatchetAEAD(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range ratchetAEADTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			sender, recipient := newTestRatchets(t, WithAEAD(test.suite))
+
+			first := encryptTestMessage(t, &sender, []byte("first"))
+			second := encryptTestMessage(t, &sender, []byte("second"))
+
+			if len(second.encryptedData) != len(second.data)+test.suite.Overhead() {
+				t.Fatalf("Encrypt(): expected %s overhead", test.name)
+			}
+
+			decryptTestMessage(t, &recipient, second)
+			decryptTestMessage(t, &recipient, first)
+			decryptTestMessage(t, &sender, encryptTestMessage(t, &recipient, []byte("reply")))
+
+			data := []byte("append")
+
+			encryptedHeader, encryptedData, err := sender.EncryptAppend(nil, nil, data, nil)
+			if err != nil {
+				t.Fatalf("EncryptAppend(): expected no error but got %v", err)
+			}
+
+			decryptTestMessage(t, &recipient, testMessage{encryptedHeader, encryptedData, data})
+
+			data = newTestStreamData(t, streamChunkSize+1)
+			decryptTestStream(t, &recipient, encryptTestStream(t, &sender, data), data)
+		})
+	}
+}
+
+func TestRatchetAEADMismatch(t *testing.T) {
+	t.Parallel()
+
+	sender, recipient := newTestRatchets(t, WithAEAD(aead.AES256GCM()))
+
+	recipientBytes, err := recipient.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary(): expected no error but got %v", err)
+	}
+
+	_, err = Unmarshal(recipientBytes, WithAEAD(aead.AES256GCMSIV()))
+	if !errors.Is(err, ErrSuiteMismatch) {
+		t.Fatalf("Unmarshal(): expected error %v but got %v", ErrSuiteMismatch, err)
+	}
+
+	recipient, err = Unmarshal(recipientBytes, WithAEAD(aead.AES256GCM()))
+	if err != nil {
+		t.Fatalf("Unmarshal(): expected no error but got %v", err)
+	}
+
+	decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("data")))
+}
+
+type countingSendingChainCrypto struct {
+	sendingchain.Crypto
+
+	encryptions int
+}
+
+func (c *countingSendingChainCrypto) EncryptMessage(
+	key keys.Message,
+	message []byte,
+	auth []byte,
+) ([]byte, error) {
+	c.encryptions++
+
+	return c.Crypto.EncryptMessage(key, message, auth)
+}
+
+var ratchetChainCryptoTests = []struct {
+	name    string
+	suite   aead.Suite
+	options []Option
+}{
+	{
+		"default suite",
+		aead.XChaCha20Poly1305(),
+		nil,
+	},
+	{
+		"AEAD",
+		aead.AES256GCM(),
+		[]Option{WithAEAD(aead.AES256GCM())},
+	},
+}
+
+func TestRatchetChainCrypto(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range ratchetChainCryptoTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			crypto := &countingSendingChainCrypto{Crypto: sendingchain.NewDefaultCrypto(test.suite)}
+			options := append(
+				slices.Clone(test.options),
+				WithSendingChainOptions(sendingchain.WithCrypto(crypto)),
+			)
+
+			sender, recipient := newTestRatchets(t, options...)
+
+			decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("first")))
+			decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("second")))
+
+			if crypto.encryptions != 2 {
+				t.Fatalf(
+					"Encrypt(): expected 2 encryptions with passed crypto but got %d",
+					crypto.encryptions,
+				)
+			}
+		})
+	}
+}
+
+type countingSkippedKeysStorage struct {
+	keys   map[string]keys.Message
+	clones int
+}
+
+func newCountingSkippedKeysStorage() *countingSkippedKeysStorage {
+	return &countingSkippedKeysStorage{
+		keys: make(map[string]keys.Message),
+	}
+}
+
+func (s *countingSkippedKeysStorage) Add(
+	headerKey keys.Header,
+	messageNumber uint64,
+	messageKey keys.Message,
+) error {
+	s.keys[fmt.Sprintf("%x/%d", headerKey.Bytes, messageNumber)] = messageKey
+
+	return nil
+}
+
+func (s *countingSkippedKeysStorage) Clone() receivingchain.SkippedKeysStorage {
+	s.clones++
+
+	return s
+}
+
+func (s *countingSkippedKeysStorage) Delete(headerKey keys.Header, messageNumber uint64) error {
+	delete(s.keys, fmt.Sprintf("%x/%d", headerKey.Bytes, messageNumber))
+
+	return nil
+}
+
+func (s *countingSkippedKeysStorage) GetIter() (receivingchain.SkippedKeysIter, error) {
+	iter := func(yield receivingchain.SkippedKeysYield) {
+		for id, messageKey := range s.keys {
+			var (
+				headerKey     []byte
+				messageNumber uint64
+			)
+
+			_, err := fmt.Sscanf(id, "%x/%d", &headerKey, &messageNumber)
+			if err != nil {
+				return
+			}
+
+			messageNumberKeysIter := func(yield receivingchain.SkippedMessageNumberKeysYield) {
+				yield(messageNumber, messageKey)
+			}
+
+			if !yield(keys.Header{Bytes: headerKey}, messageNumberKeysIter) {
+				return
+			}
+		}
+	}
+
+	return iter, nil
+}
+
+func TestRatchetDoesNotCloneSkippedKeysStorage(t *testing.T) {
+	t.Parallel()
+
+	storage := newCountingSkippedKeysStorage()
+	sender, recipient := newTestRatchets(
+		t,
+		WithReceivingChainOptions(receivingchain.WithSkippedKeysStorage(storage)),
+	)
+
+	first := encryptTestMessage(t, &sender, []byte("first"))
+	second := encryptTestMessage(t, &sender, []byte("second"))
+
+	decryptTestMessage(t, &recipient, second)
+
+	reply := encryptTestMessage(t, &recipient, []byte("reply"))
+	decryptTestMessage(t, &sender, reply)
+	decryptTestMessage(t, &recipient, first)
+
+	if storage.clones != 0 {
+		t.Fatalf("Encrypt()/Decrypt(): expected no storage clones but got %d", storage.clones)
+	}
+}
+
+func TestRatchetDecryptErrorDoesNotAddSkippedKeys(t *testing.T) {
+	t.Parallel()
+
+	storage := newCountingSkippedKeysStorage()
+	sender, recipient := newTestRatchets(
+		t,
+		WithReceivingChainOptions(receivingchain.WithSkippedKeysStorage(storage)),
+	)
+
+	encryptTestMessage(t, &sender, []byte("skipped"))
+	message := encryptTestMessage(t, &sender, []byte("message"))
+
+	corruptedData := slices.Clone(message.encryptedData)
+	corruptedData[0] ^= 1
+
+	_, err := recipient.Decrypt(message.encryptedHeader, corruptedData, nil)
+	if err == nil {
+		t.Fatal("Decrypt(): expected error for corrupted data")
+	}
+
+	if len(storage.keys) != 0 {
+		t.Fatalf("Decrypt(): expected no skipped keys but got %d", len(storage.keys))
+	}
+
+	decryptTestMessage(t, &recipient, message)
+
+	if len(storage.keys) != 1 {
+		t.Fatalf("Decrypt(): expected 1 skipped key but got %d", len(storage.keys))
+	}
+}
+
+// unknownHeaderMessage is the index of the message with the header of an unknown key.
+const unknownHeaderMessage = -1
+
+var ratchetDecryptErrorTests = []struct {
+	name                  string
+	decrypted             []int
+	ratchetStep           bool
+	message               int
+	tamper                bool
+	expectedStage         receivingchain.DecryptStage
+	expectedErr           error
+	expectedMessageNumber int
+}{
+	{
+		"tampered message",
+		[]int{0},
+		false,
+		1,
+		true,
+		receivingchain.DecryptStageMessage,
+		receivingchain.ErrDecryptMessage,
+		1,
+	},
+	{
+		"tampered skipped message",
+		[]int{1},
+		false,
+		0,
+		true,
+		receivingchain.DecryptStageMessage,
+		receivingchain.ErrDecryptMessage,
+		0,
+	},
+	{
+		"duplicate message",
+		[]int{0},
+		false,
+		0,
+		false,
+		receivingchain.DecryptStageMessage,
+		receivingchain.ErrDuplicateMessage,
+		0,
+	},
+	{
+		"duplicate message of previous epoch",
+		[]int{0, 2},
+		true,
+		0,
+		false,
+		receivingchain.DecryptStageMessage,
+		receivingchain.ErrDuplicateMessage,
+		0,
+	},
+	{
+		"duplicate message of previous epoch without skipped keys",
+		[]int{0, 1, 2},
+		true,
+		1,
+		false,
+		receivingchain.DecryptStageMessage,
+		receivingchain.ErrDuplicateMessage,
+		1,
+	},
+	{
+		"unknown epoch",
+		[]int{0},
+		false,
+		unknownHeaderMessage,
+		false,
+		receivingchain.DecryptStageHeader,
+		receivingchain.ErrUnknownEpoch,
+		unknownHeaderMessage,
+	},
+}
+
+func TestRatchetDecryptError(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range ratchetDecryptErrorTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			sender, recipient := newTestRatchets(t)
+
+			messages := make([]testMessage, 3)
+			for i := range messages {
+				messages[i] = encryptTestMessage(t, &sender, []byte("message"))
+			}
+
+			for _, i := range test.decrypted {
+				decryptTestMessage(t, &recipient, messages[i])
+			}
+
+			if test.ratchetStep {
+				decryptTestMessage(t, &sender, encryptTestMessage(t, &recipient, []byte("reply")))
+				decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("next")))
+			}
+
+			message := testMessage{
+				encryptedHeader: newTestKey(t),
+				encryptedData:   newTestKey(t),
+			}
+			if test.message != unknownHeaderMessage {
+				message = messages[test.message]
+			}
+
+			encryptedData := slices.Clone(message.encryptedData)
+			if test.tamper {
+				encryptedData[0] ^= 1
+			}
+
+			_, err := recipient.Decrypt(message.encryptedHeader, encryptedData, nil)
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("Decrypt(): expected error %v but got %v", test.expectedErr, err)
+			}
+
+			isDuplicate := errors.Is(err, receivingchain.ErrDuplicateMessage)
+			if isDuplicate != errors.Is(test.expectedErr, receivingchain.ErrDuplicateMessage) {
+				t.Fatalf("Decrypt(): unexpected duplicate message error %v", err)
+			}
+
+			var decryptErr *receivingchain.DecryptError
+			if !errors.As(err, &decryptErr) {
+				t.Fatalf("Decrypt(): expected decrypt error but got %T", err)
+			}
+
+			if decryptErr.Stage != test.expectedStage {
+				t.Fatalf(
+					"Decrypt(): expected stage %v but got %v",
+					test.expectedStage,
+					decryptErr.Stage,
+				)
+			}
+
+			if test.expectedMessageNumber == unknownHeaderMessage {
+				if decryptErr.Header != nil {
+					t.Fatalf("Decrypt(): expected no header but got %+v", decryptErr.Header)
+				}
+
+				return
+			}
+
+			if decryptErr.Header == nil ||
+				decryptErr.Header.MessageNumber != uint64(test.expectedMessageNumber) {
+				t.Fatalf(
+					"Decrypt(): expected header of message %d but got %+v",
+					test.expectedMessageNumber,
+					decryptErr.Header,
+				)
+			}
+		})
+	}
+}
+
+type countingKEM struct {
+	defaultKEM
+
+	encapsulations int
+	decapsulations int
+}
+
+func (k *countingKEM) Decapsulate(privateKey keys.Private, ciphertext []byte) (keys.Shared, error) {
+	k.decapsulations++
+
+	return k.defaultKEM.Decapsulate(privateKey, ciphertext)
+}
+
+func (k *countingKEM) Encapsulate(publicKey keys.Public) (keys.Shared, []byte, error) {
+	k.encapsulations++
+
+	return k.defaultKEM.Encapsulate(publicKey)
+}
+
+var kemRatchetTests = []struct {
+	name                   string
+	interval               uint64
+	expectedEncapsulations int
+}{
+	{
+		"disabled",
+		0,
+		0,
+	},
+	{
+		"every sending chain",
+		1,
+		5,
+	},
+	{
+		"every third sending chain",
+		3,
+		2,
+	},
+}
+
+func TestRatchetKEMRatchet(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range kemRatchetTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var kem countingKEM
+
+			// Note that the pong is decrypted before the delayed message, so both of them
+			// must carry the KEM data.
+			sender, recipient := newTestRatchets(
+				t,
+				WithKEM(&kem),
+				WithKEMHeaderMessages(2),
+				WithKEMRatchetInterval(test.interval),
+			)
+
+			for range 3 {
+				decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("ping")))
+
+				delayed := encryptTestMessage(t, &recipient, []byte("delayed"))
+				decryptTestMessage(t, &sender, encryptTestMessage(t, &recipient, []byte("pong")))
+				decryptTestMessage(t, &sender, delayed)
+			}
+
+			if kem.encapsulations != test.expectedEncapsulations {
+				t.Fatalf(
+					"expected %d encapsulations but got %d",
+					test.expectedEncapsulations,
+					kem.encapsulations,
+				)
+			}
+
+			if kem.decapsulations != kem.encapsulations {
+				t.Fatalf(
+					"expected %d decapsulations but got %d",
+					kem.encapsulations,
+					kem.decapsulations,
+				)
+			}
+		})
+	}
+}
+
+func TestRatchetKEMHeaderMessages(t *testing.T) {
+	t.Parallel()
+
+	sender, recipient := newTestRatchets(t, WithKEMRatchetInterval(1))
+	plainSender, _ := newTestRatchets(t)
+
+	decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("ping")))
+
+	first := encryptTestMessage(t, &recipient, []byte("first"))
+	second := encryptTestMessage(t, &recipient, []byte("second"))
+	plain := encryptTestMessage(t, &plainSender, []byte("plain"))
+
+	if len(first.encryptedHeader) <= len(plain.encryptedHeader) {
+		t.Fatalf(
+			"Encrypt(): expected first header longer than %d bytes but got %d",
+			len(plain.encryptedHeader),
+			len(first.encryptedHeader),
+		)
+	}
+
+	if len(second.encryptedHeader) != len(plain.encryptedHeader) {
+		t.Fatalf(
+			"Encrypt(): expected second header of %d bytes but got %d",
+			len(plain.encryptedHeader),
+			len(second.encryptedHeader),
+		)
+	}
+
+	// The keys of the chain depend on the KEM shared key, so the second message is decrypted
+	// only after the first one.
+	_, err := sender.Decrypt(second.encryptedHeader, second.encryptedData, nil)
+	if err == nil {
+		t.Fatal("Decrypt(): expected error before the first message but got nil")
+	}
+
+	decryptTestMessage(t, &sender, first)
+	decryptTestMessage(t, &sender, second)
+}
+
+func TestRatchetWipesReplacedKeys(t *testing.T) {
+	t.Parallel()
+
+	sender, recipient := newTestRatchets(t)
+	decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("first")))
+
+	recipientPrivateKey := recipient.localPrivateKey.Bytes
+	decryptTestMessage(t, &sender, encryptTestMessage(t, &recipient, []byte("reply")))
+
+	if !bytes.Equal(recipientPrivateKey, make([]byte, len(recipientPrivateKey))) {
+		t.Fatalf(
+			"Encrypt(): expected replaced private key to be wiped but got %v",
+			recipientPrivateKey,
+		)
+	}
+}
+
+func TestRatchetDestroy(t *testing.T) {
+	t.Parallel()
+
+	sender, recipient := newTestRatchets(t)
+	encryptTestMessage(t, &sender, []byte("skipped"))
+	decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("second")))
+
+	privateKey := recipient.localPrivateKey.Bytes
+
+	recipient.Destroy()
+
+	if !bytes.Equal(privateKey, make([]byte, len(privateKey))) {
+		t.Fatalf("Destroy(): expected private key to be wiped but got %v", privateKey)
+	}
+
+	_, _, err := recipient.Encrypt([]byte("data"), nil)
+	if err == nil {
+		t.Fatal("Encrypt(): expected error after Destroy() but got nil")
+	}
+}
@@ -1,24 +1,71 @@
 package receivingchain
 
 import (
+	"bytes"
+	"crypto/subtle"
 	"errors"
 
 	"github.com/platform-source/aegis/header"
 	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/padding"
+	"github.com/platform-source/tools/convert"
 	"github.com/platform-source/tools/slices"
 )
 
 // Chain is the ratchet receiving chain.
 //
-// Please note that this structure may corrupt its state in case of errors.
-// Therefore, clone the data at the top level and replace the current data
-// with it if there are no errors.
+// Please note that decryption methods do not corrupt the state in case of errors:
+// keys are modified in a cheap copy of the chain and skipped keys are staged, so
+// the skipped keys storage is updated only when decryption succeeds.
 type Chain struct {
 	masterKey         *keys.Master
 	headerKey         *keys.Header
 	nextHeaderKey     keys.Header
 	nextMessageNumber uint64
+	stagedSkippedKeys []skippedKey
+	replays           *replayWindow
 	cfg               config
+	scratch           *scratch
+}
+
+// scratch is the memory reused by DecryptNextAppend. The master keys are double-buffered,
+// so the current master key is kept intact until the new one is committed.
+type scratch struct {
+	masterKeys    [2]keys.Master
+	nextMasterKey int
+	messageKey    []byte
+	header        []byte
+	auth          []byte
+}
+
+// scratchMasterKeySize is the size of the master keys derived by the default crypto, which
+// is reserved for the scratch master keys in the memory of the allocator.
+const scratchMasterKeySize = 64
+
+func newScratch(allocator keys.Allocator) *scratch {
+	sc := &scratch{}
+
+	if allocator != nil {
+		for i := range sc.masterKeys {
+			sc.masterKeys[i] = keys.Master{
+				Bytes: make([]byte, scratchMasterKeySize),
+			}.CloneTo(allocator)
+		}
+	}
+
+	return sc
+}
+
+// wipeMasterKey wipes passed master key. The memory of the scratch master keys is only
+// zeroed, so it is reused.
+func (sc *scratch) wipeMasterKey(masterKey *keys.Master) {
+	if masterKey == &sc.masterKeys[0] || masterKey == &sc.masterKeys[1] {
+		clear(masterKey.Bytes)
+
+		return
+	}
+
+	masterKey.Wipe()
 }
 
 // New creates a new receiving chain.
@@ -30,7 +77,6 @@ func New(
 	options ...Option,
 ) (Chain, error) {
 	chain := Chain{
-		masterKey:         masterKey,
 		headerKey:         headerKey,
 		nextHeaderKey:     nextHeaderKey,
 		nextMessageNumber: nextMessageNumber,
@@ -43,64 +89,425 @@ func New(
 		return Chain{}, errors.Join(ErrNewConfig, err)
 	}
 
+	if masterKey != nil {
+		chain.masterKey = chain.protectMasterKey(*masterKey)
+	}
+
+	chain.replays = newReplayWindow(chain.cfg.replayWindowSize)
+
 	return chain, nil
 }
 
 // Clone clones receiving chain.
 func (ch Chain) Clone() Chain {
-	ch.masterKey = ch.masterKey.ClonePtr()
-	ch.headerKey = ch.headerKey.ClonePtr()
-	ch.nextHeaderKey = ch.nextHeaderKey.Clone()
+	ch = ch.cloneKeys()
+	ch.replays = ch.replays.clone()
 	ch.cfg = ch.cfg.clone()
+	ch.scratch = nil
 
 	return ch
 }
 
 // Decrypt decrypts passed encrypted header and encrypted data and authenticates
-// them with auth. Also calls ratchet callback if ratchet is needed.
+// them with auth. Also calls ratchet callback if ratchet is needed. The errors are
+// returned as *DecryptError.
+//
+// Please note that the callback upgrades the chain itself, so the chain stays upgraded
+// if the message fails to decrypt after the ratchet. Use DecryptWithSkippedKeys and
+// DecryptWithChainKeys to get the KEM data of the header or to keep the chain unchanged.
 func (ch *Chain) Decrypt(
 	encryptedHeader []byte,
 	encryptedData []byte,
 	auth []byte,
 	ratchet RatchetCallback,
 ) ([]byte, error) {
-	decryptedData, skippedKeysErr := ch.decryptWithSkippedKeys(encryptedHeader, encryptedData, auth)
+	decryptedData, skippedKeysErr := ch.DecryptWithSkippedKeys(encryptedHeader, encryptedData, auth)
 	if skippedKeysErr == nil {
 		return decryptedData, nil
 	}
 
-	skippedKeysErr = errors.Join(ErrDecryptWithSkippedKeys, skippedKeysErr)
+	// Note that the message of the found skipped key is not decrypted with chain keys.
+	if errors.Is(skippedKeysErr, ErrOpenMessage) {
+		decryptErr := NewDecryptError(skippedKeysErr)
+		ch.cfg.observer.decryptFailed(decryptErr)
 
-	err := ch.handleEncryptedHeader(encryptedHeader, ratchet)
+		return nil, decryptErr
+	}
+
+	decryptedData, err := ch.DecryptWithChainKeys(
+		encryptedHeader,
+		encryptedData,
+		auth,
+		ch.newHeaderRatchetCallback(ratchet),
+	)
 	if err != nil {
-		return nil, errors.Join(skippedKeysErr, ErrHandleEncryptedHeader, err)
+		decryptErr := NewDecryptError(errors.Join(skippedKeysErr, err))
+		ch.cfg.observer.decryptFailed(decryptErr)
+
+		return nil, decryptErr
 	}
 
-	messageKey, err := ch.advance()
+	// Note that here it is ok to ignore an error when decrypting with skipped keys
+	// if decryption with the next message key succeeds.
+	return decryptedData, nil
+}
+
+// DecryptNextAppend decrypts passed encrypted header and encrypted data, authenticates them
+// with auth and appends the decrypted data to dst. Only the next message of the current
+// chain is decrypted: ErrNotNextMessage is returned without any changes for other messages,
+// which must be decrypted with Decrypt. The chain state is changed only if there are no
+// errors.
+//
+// If the crypto implements AppendCrypto, the chain reuses its own memory for the keys and
+// the header, so it does not allocate when dst has enough capacity.
+func (ch *Chain) DecryptNextAppend(
+	dst []byte,
+	encryptedHeader []byte,
+	encryptedData []byte,
+	auth []byte,
+) ([]byte, error) {
+	crypto, ok := ch.cfg.crypto.(AppendCrypto)
+	if !ok || ch.headerKey == nil || ch.masterKey == nil {
+		return nil, ErrNotNextMessage
+	}
+
+	if ch.scratch == nil {
+		ch.scratch = newScratch(ch.cfg.allocator)
+	}
+
+	decryptedHeader, err := ch.decryptHeaderWithCurrentKeyAppend(crypto, encryptedHeader)
 	if err != nil {
-		return nil, errors.Join(skippedKeysErr, ErrAdvanceChain, err)
+		return nil, errors.Join(ErrNotNextMessage, err)
 	}
 
-	auth = slices.ConcatBytes(encryptedHeader, auth)
+	if decryptedHeader.MessageNumber != ch.nextMessageNumber {
+		return nil, ErrNotNextMessage
+	}
+
+	nextMasterKey := &ch.scratch.masterKeys[ch.scratch.nextMasterKey]
 
-	decryptedData, err = ch.cfg.crypto.DecryptMessage(messageKey, encryptedData, auth)
+	newMasterKey, messageKey, err := crypto.AdvanceChainAppend(
+		nextMasterKey.Bytes[:0],
+		ch.scratch.messageKey[:0],
+		*ch.masterKey,
+	)
 	if err != nil {
-		return nil, errors.Join(skippedKeysErr, ErrDecryptMessage, err)
+		return nil, errors.Join(ErrAdvanceChain, ErrCryptoAdvanceChain, err)
+	}
+
+	ch.scratch.messageKey = messageKey.Bytes
+	ch.scratch.auth = append(append(ch.scratch.auth[:0], encryptedHeader...), auth...)
+
+	decryptedData, err := crypto.DecryptMessageAppend(
+		dst,
+		messageKey,
+		encryptedData,
+		ch.scratch.auth,
+	)
+	clear(ch.scratch.messageKey)
+
+	if err != nil {
+		clear(newMasterKey.Bytes)
+
+		return nil, ch.newAuthenticatedHeaderError(
+			cloneHeader(decryptedHeader),
+			errors.Join(ErrDecryptMessage, err),
+		)
+	}
+
+	if ch.cfg.padding {
+		unpaddedData, err := padding.Unpad(decryptedData[len(dst):])
+		if err != nil {
+			clear(newMasterKey.Bytes)
+			clear(decryptedData[len(dst):])
+
+			return nil, ch.newAuthenticatedHeaderError(
+				cloneHeader(decryptedHeader),
+				errors.Join(ErrUnpad, err),
+			)
+		}
+
+		decryptedData = decryptedData[:len(dst)+len(unpaddedData)]
+	}
+
+	ch.wipeMasterKey()
+	nextMasterKey.Bytes = newMasterKey.Bytes
+	ch.masterKey = nextMasterKey
+	ch.scratch.nextMasterKey ^= 1
+	ch.nextMessageNumber++
+	ch.replays.add(encryptedHeader, decryptedHeader)
+	ch.cfg.observer.headerDecrypted(decryptedHeader.MessageNumber, false)
+
+	return decryptedData, nil
+}
+
+// DecryptWithChainKeys decrypts passed encrypted header and encrypted data with
+// the current or next header key and the next message key, and authenticates them
+// with auth. Also calls ratchet callback if ratchet is needed. Skipped keys are
+// not used, but the keys skipped by the message are added to the storage.
+func (ch *Chain) DecryptWithChainKeys(
+	encryptedHeader []byte,
+	encryptedData []byte,
+	auth []byte,
+	ratchet HeaderRatchetCallback,
+) ([]byte, error) {
+	return ch.OpenWithChainKeys(
+		encryptedHeader,
+		ch.newDecryptMessageCallback(encryptedHeader, encryptedData, auth),
+		ratchet,
+	)
+}
+
+// DecryptWithSkippedKeys decrypts passed encrypted header and encrypted data with
+// skipped keys only and authenticates them with auth. The used key is deleted from
+// the storage, and nothing else in the chain is modified.
+func (ch *Chain) DecryptWithSkippedKeys(
+	encryptedHeader []byte,
+	encryptedData []byte,
+	auth []byte,
+) ([]byte, error) {
+	decryptedData, err := ch.OpenWithSkippedKeys(
+		encryptedHeader,
+		ch.newDecryptMessageCallback(encryptedHeader, encryptedData, auth),
+	)
+	if err != nil {
+		return nil, errors.Join(ErrDecryptWithSkippedKeys, err)
 	}
 
-	// Note that here it is ok to ignore an error when decrypting with skipped keys
-	// if decryption with the next message key succeeds.
 	return decryptedData, nil
 }
 
-// Upgrade upgrades receiving chain with new starting values.
+// OpenWithChainKeys is the same as DecryptWithChainKeys, but passes the message key to
+// the open callback instead of decrypting the data. The chain is modified only if the
+// callback succeeds.
+func (ch *Chain) OpenWithChainKeys(
+	encryptedHeader []byte,
+	open OpenCallback,
+	ratchet HeaderRatchetCallback,
+) ([]byte, error) {
+	dirty := ch.cloneKeys()
+
+	openedData, err := dirty.openWithChainKeys(encryptedHeader, open, ratchet)
+	if err != nil {
+		dirty.wipeKeys()
+
+		return nil, err
+	}
+
+	ch.wipeKeys()
+	*ch = dirty
+
+	return openedData, nil
+}
+
+// OpenWithSkippedKeys is the same as DecryptWithSkippedKeys, but passes the message key
+// to the open callback instead of decrypting the data. The key is deleted from the storage
+// only if the callback succeeds.
+//
+// If the header is decrypted with the header key of a previous epoch, but there is no
+// skipped key for its message number, ErrDuplicateMessage is returned for the message in
+// the replay window and ErrMessageKeyEvicted otherwise.
+func (ch *Chain) OpenWithSkippedKeys(encryptedHeader []byte, open OpenCallback) ([]byte, error) {
+	if ch.cfg.plaintextHeaders {
+		return ch.openWithSkippedKeysByHeader(encryptedHeader, open)
+	}
+
+	iter, err := ch.cfg.skippedKeysStorage.GetIter()
+	if err != nil {
+		return nil, errors.Join(ErrGetSkippedKeysStorageIter, err)
+	}
+
+	var previousEpochHeader *header.Header
+
+	for headerKey, messageNumberKeys := range iter {
+		decryptedHeader, err := ch.cfg.crypto.DecryptHeader(headerKey, encryptedHeader)
+		if err != nil {
+			continue
+		}
+
+		// Note that the keys of the current epoch may be skipped by the messages, which
+		// are not received yet, so only previous epochs are complete.
+		if !ch.isCurrentHeaderKey(headerKey) {
+			previousEpochHeader = &decryptedHeader
+		}
+
+		for messageNumber, messageKey := range messageNumberKeys {
+			if messageNumber != decryptedHeader.MessageNumber {
+				continue
+			}
+
+			openedData, err := open(messageKey)
+			if err != nil {
+				return nil, &authenticatedHeaderError{
+					header: decryptedHeader,
+					err:    errors.Join(ErrOpenMessage, err),
+				}
+			}
+
+			err = ch.cfg.skippedKeysStorage.Delete(headerKey, messageNumber)
+			if err != nil {
+				return nil, errors.Join(ErrDeleteSkippedKeys, err)
+			}
+
+			ch.replays.add(encryptedHeader, decryptedHeader)
+			ch.cfg.observer.skippedKey(SkippedKeyConsumed, messageNumber)
+
+			return openedData, nil
+		}
+	}
+
+	if previousEpochHeader != nil {
+		return nil, &authenticatedHeaderError{
+			header: *previousEpochHeader,
+			err:    errors.Join(ErrSkippedKeysNotFound, ch.missingKeyError(encryptedHeader)),
+		}
+	}
+
+	return nil, ErrSkippedKeysNotFound
+}
+
+// Upgrade upgrades receiving chain with new starting values. The old keys are wiped.
 func (ch *Chain) Upgrade(masterKey keys.Master, nextHeaderKey keys.Header) {
-	ch.masterKey = &masterKey
-	ch.headerKey = &ch.nextHeaderKey
+	ch.wipeMasterKey()
+	ch.masterKey = ch.protectMasterKey(masterKey)
+	ch.headerKey.Wipe()
+	ch.headerKey = convert.ToPtr(ch.nextHeaderKey)
 	ch.nextHeaderKey = nextHeaderKey
 	ch.nextMessageNumber = 0
 }
 
+// openWithChainKeys handles passed encrypted header, advances the chain and opens the
+// message with the next message key. The message key is wiped after the callback returns.
+func (ch *Chain) openWithChainKeys(
+	encryptedHeader []byte,
+	open OpenCallback,
+	ratchet HeaderRatchetCallback,
+) ([]byte, error) {
+	decryptedHeader, err := ch.handleEncryptedHeader(encryptedHeader, ratchet)
+	if err != nil {
+		return nil, errors.Join(ErrHandleEncryptedHeader, err)
+	}
+
+	messageKey, err := ch.advance()
+	if err != nil {
+		return nil, ch.newAuthenticatedHeaderError(
+			decryptedHeader,
+			errors.Join(ErrAdvanceChain, err),
+		)
+	}
+
+	openedData, err := open(messageKey)
+	messageKey.Wipe()
+
+	if err != nil {
+		return nil, ch.newAuthenticatedHeaderError(
+			decryptedHeader,
+			errors.Join(ErrOpenMessage, err),
+		)
+	}
+
+	err = ch.commitSkippedKeys()
+	if err != nil {
+		return nil, ch.newAuthenticatedHeaderError(
+			decryptedHeader,
+			errors.Join(ErrCommitSkippedKeys, err),
+		)
+	}
+
+	ch.replays.add(encryptedHeader, decryptedHeader)
+
+	return openedData, nil
+}
+
+// Wipe wipes all keys of the chain and the skipped keys storage, if the storage has the Wipe
+// method. The chain must not be used after the call.
+func (ch *Chain) Wipe() {
+	ch.wipeKeys()
+
+	if ch.scratch != nil {
+		for i := range ch.scratch.masterKeys {
+			ch.scratch.masterKeys[i].Wipe()
+		}
+
+		clear(ch.scratch.messageKey)
+		clear(ch.scratch.header)
+		ch.scratch = nil
+	}
+
+	ch.replays.wipe()
+
+	if storage, ok := ch.cfg.skippedKeysStorage.(interface{ Wipe() }); ok {
+		storage.Wipe()
+	}
+}
+
+// cloneKeys clones the keys of the chain. The skipped keys storage is shared with
+// the clone, and the staged skipped keys are dropped.
+func (ch Chain) cloneKeys() Chain {
+	ch.masterKey = ch.masterKey.ClonePtr()
+	ch.headerKey = ch.headerKey.ClonePtr()
+	ch.nextHeaderKey = ch.nextHeaderKey.Clone()
+	ch.stagedSkippedKeys = nil
+
+	return ch
+}
+
+// wipeKeys wipes the keys of the chain and the staged skipped keys.
+func (ch *Chain) wipeKeys() {
+	ch.wipeMasterKey()
+	ch.headerKey.Wipe()
+	ch.nextHeaderKey.Wipe()
+
+	for i := range ch.stagedSkippedKeys {
+		ch.stagedSkippedKeys[i].headerKey.Wipe()
+		ch.stagedSkippedKeys[i].messageKey.Wipe()
+	}
+
+	ch.stagedSkippedKeys = nil
+}
+
+// wipeMasterKey wipes the current master key, but keeps the memory of the scratch for reuse.
+func (ch *Chain) wipeMasterKey() {
+	if ch.scratch == nil {
+		ch.masterKey.Wipe()
+
+		return
+	}
+
+	ch.scratch.wipeMasterKey(ch.masterKey)
+}
+
+// protectMasterKey moves passed master key into the memory of the allocator if it is set.
+func (ch *Chain) protectMasterKey(masterKey keys.Master) *keys.Master {
+	if ch.cfg.allocator == nil {
+		return &masterKey
+	}
+
+	protectedMasterKey := masterKey.CloneTo(ch.cfg.allocator)
+	masterKey.Wipe()
+
+	return &protectedMasterKey
+}
+
+// commitSkippedKeys adds staged skipped keys to the storage.
+//
+// Note that the storage may contain a part of the staged keys in case of errors. This
+// is harmless because the same keys will be derived and added again later.
+func (ch *Chain) commitSkippedKeys() error {
+	for _, key := range ch.stagedSkippedKeys {
+		err := ch.cfg.skippedKeysStorage.Add(key.headerKey, key.messageNumber, key.messageKey)
+		if err != nil {
+			return errors.Join(ErrAddSkippedKey, err)
+		}
+
+		ch.cfg.observer.skippedKey(SkippedKeyStored, key.messageNumber)
+	}
+
+	ch.stagedSkippedKeys = nil
+
+	return nil
+}
+
 func (ch *Chain) advance() (keys.Message, error) {
 	if ch.masterKey == nil {
 		return keys.Message{}, ErrMasterKeyIsNil
@@ -111,19 +518,68 @@ func (ch *Chain) advance() (keys.Message, error) {
 		return keys.Message{}, errors.Join(ErrCryptoAdvanceChain, err)
 	}
 
-	ch.masterKey = &newMasterKey
+	ch.wipeMasterKey()
+	ch.masterKey = ch.protectMasterKey(newMasterKey)
 	ch.nextMessageNumber++
 
 	return messageKey, nil
 }
 
+// decryptHeaderWithCurrentKeyAppend decrypts passed encrypted header with the current
+// header key into the scratch memory. In the plaintext header mode the header is decoded
+// and its public key must be the current one.
+func (ch *Chain) decryptHeaderWithCurrentKeyAppend(
+	crypto AppendCrypto,
+	encryptedHeader []byte,
+) (header.Header, error) {
+	if ch.cfg.plaintextHeaders {
+		decodedHeader, err := header.Decode(encryptedHeader)
+		if err != nil {
+			return header.Header{}, errors.Join(ErrDecodeHeader, err)
+		}
+
+		if !bytes.Equal(decodedHeader.PublicKey.Bytes, ch.headerKey.Bytes) {
+			return header.Header{}, ErrNotNextMessage
+		}
+
+		return decodedHeader, nil
+	}
+
+	decryptedHeader, headerBytes, err := crypto.DecryptHeaderAppend(
+		ch.scratch.header[:0],
+		*ch.headerKey,
+		encryptedHeader,
+	)
+	if err != nil {
+		return header.Header{}, err
+	}
+
+	ch.scratch.header = headerBytes
+
+	return decryptedHeader, nil
+}
+
 // decryptHeaderWithCurrentOrNextKeys must decrypt passed encrypted header with
 // current or next header key.
 //
-// Note that ratchet is needed if header decrypted with next header key.
+// Note that ratchet is needed if header decrypted with next header key. In the plaintext
+// header mode the header is decoded, and ratchet is needed if its public key differs from
+// the public key of the current epoch.
 func (ch *Chain) decryptHeaderWithCurrentOrNextKey(
 	encryptedHeader []byte,
 ) (decryptedHeader header.Header, needRatchet bool, err error) {
+	if ch.cfg.plaintextHeaders {
+		decryptedHeader, err = header.Decode(encryptedHeader)
+		if err != nil {
+			return header.Header{}, false, errors.Join(ErrDecodeHeader, err)
+		}
+
+		needRatchet = ch.headerKey == nil ||
+			!bytes.Equal(decryptedHeader.PublicKey.Bytes, ch.headerKey.Bytes)
+
+		return decryptedHeader, needRatchet, nil
+	}
+
 	var currentKeyErr error
 
 	if ch.headerKey != nil {
@@ -145,46 +601,160 @@ func (ch *Chain) decryptHeaderWithCurrentOrNextKey(
 	return decryptedHeader, true, nil
 }
 
-func (ch *Chain) decryptWithSkippedKeys(
-	encryptedHeader, encryptedData, auth []byte,
+// newDecryptMessageCallback returns the open callback, which decrypts passed encrypted data
+// and authenticates it with passed encrypted header and auth. The padding is removed if it
+// is enabled.
+func (ch *Chain) newDecryptMessageCallback(
+	encryptedHeader []byte,
+	encryptedData []byte,
+	auth []byte,
+) OpenCallback {
+	auth = slices.ConcatBytes(encryptedHeader, auth)
+
+	return func(messageKey keys.Message) ([]byte, error) {
+		decryptedData, err := ch.cfg.crypto.DecryptMessage(messageKey, encryptedData, auth)
+		if err != nil {
+			return nil, errors.Join(ErrDecryptMessage, err)
+		}
+
+		if !ch.cfg.padding {
+			return decryptedData, nil
+		}
+
+		unpaddedData, err := padding.Unpad(decryptedData)
+		if err != nil {
+			clear(decryptedData)
+
+			return nil, errors.Join(ErrUnpad, err)
+		}
+
+		return unpaddedData, nil
+	}
+}
+
+// openWithSkippedKeysByHeader opens the message with the skipped key found by the public key
+// and the message number of the plaintext header. The storage is looked up directly if it
+// implements SkippedKeysGetter, otherwise the keys are iterated without trial decryption.
+func (ch *Chain) openWithSkippedKeysByHeader(
+	encodedHeader []byte,
+	open OpenCallback,
 ) ([]byte, error) {
+	decodedHeader, err := header.Decode(encodedHeader)
+	if err != nil {
+		return nil, errors.Join(ErrDecodeHeader, err)
+	}
+
+	epochKey := keys.Header{Bytes: decodedHeader.PublicKey.Bytes}
+
+	messageKey, exists, err := ch.getSkippedKey(epochKey, decodedHeader.MessageNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, ErrSkippedKeysNotFound
+	}
+
+	openedData, err := open(messageKey)
+	if err != nil {
+		return nil, errors.Join(ErrOpenMessage, err)
+	}
+
+	err = ch.cfg.skippedKeysStorage.Delete(epochKey, decodedHeader.MessageNumber)
+	if err != nil {
+		return nil, errors.Join(ErrDeleteSkippedKeys, err)
+	}
+
+	ch.replays.add(encodedHeader, decodedHeader)
+
+	ch.cfg.observer.skippedKey(SkippedKeyConsumed, decodedHeader.MessageNumber)
+
+	return openedData, nil
+}
+
+// getSkippedKey returns the skipped key by the epoch key and the message number.
+func (ch *Chain) getSkippedKey(
+	epochKey keys.Header,
+	messageNumber uint64,
+) (keys.Message, bool, error) {
+	if getter, ok := ch.cfg.skippedKeysStorage.(SkippedKeysGetter); ok {
+		messageKey, exists, err := getter.Get(epochKey, messageNumber)
+		if err != nil {
+			return keys.Message{}, false, errors.Join(ErrGetSkippedKey, err)
+		}
+
+		return messageKey, exists, nil
+	}
+
 	iter, err := ch.cfg.skippedKeysStorage.GetIter()
 	if err != nil {
-		return nil, errors.Join(ErrGetSkippedKeysStorageIter, err)
+		return keys.Message{}, false, errors.Join(ErrGetSkippedKeysStorageIter, err)
 	}
 
 	for headerKey, messageNumberKeys := range iter {
-		decryptedHeader, err := ch.cfg.crypto.DecryptHeader(headerKey, encryptedHeader)
-		if err != nil {
+		if !bytes.Equal(headerKey.Bytes, epochKey.Bytes) {
 			continue
 		}
 
-		for messageNumber, messageKey := range messageNumberKeys {
-			if messageNumber != decryptedHeader.MessageNumber {
-				continue
+		for number, messageKey := range messageNumberKeys {
+			if number == messageNumber {
+				return messageKey, true, nil
 			}
+		}
+	}
 
-			decryptedData, err := ch.cfg.crypto.DecryptMessage(messageKey, encryptedData, auth)
-			if err != nil {
-				return nil, errors.Join(ErrDecryptMessage, err)
-			}
+	return keys.Message{}, false, nil
+}
 
-			err = ch.cfg.skippedKeysStorage.Delete(headerKey, messageNumber)
-			if err != nil {
-				return nil, errors.Join(ErrDeleteSkippedKeys, err)
+// handleEncryptedHeader decrypts passed encrypted header, skips the keys of the messages
+// before it and ratchets the chain if needed. The errors after the header decryption carry
+// the header.
+func (ch *Chain) handleEncryptedHeader(
+	encryptedHeader []byte,
+	ratchet HeaderRatchetCallback,
+) (header.Header, error) {
+	decryptedHeader, needRatchet, err := ch.decryptHeaderWithCurrentOrNextKey(encryptedHeader)
+	if err != nil {
+		// Note that the header keys of the previous epochs are kept only by the skipped keys
+		// storage, so the replays of their messages are found by the encrypted header.
+		replayedHeader, replayed := ch.replays.find(encryptedHeader)
+		if replayed {
+			return header.Header{}, &authenticatedHeaderError{
+				header: replayedHeader,
+				err:    ErrDuplicateMessage,
 			}
-
-			return decryptedData, nil
 		}
+
+		return header.Header{}, errors.Join(ErrDecryptHeaderWithCurrentOrNextKey, err)
 	}
 
-	return nil, ErrSkippedKeysNotFound
+	ch.cfg.observer.headerDecrypted(decryptedHeader.MessageNumber, needRatchet)
+
+	err = ch.checkReplay(encryptedHeader, decryptedHeader, needRatchet)
+	if err != nil {
+		return header.Header{}, ch.newAuthenticatedHeaderError(decryptedHeader, err)
+	}
+
+	err = ch.advanceToHeader(decryptedHeader, needRatchet, ratchet)
+	if err != nil {
+		return header.Header{}, ch.newAuthenticatedHeaderError(decryptedHeader, err)
+	}
+
+	return decryptedHeader, nil
 }
 
-func (ch *Chain) handleEncryptedHeader(encryptedHeader []byte, ratchet RatchetCallback) error {
-	decryptedHeader, needRatchet, err := ch.decryptHeaderWithCurrentOrNextKey(encryptedHeader)
+// advanceToHeader skips the keys of the messages before passed decrypted header and
+// ratchets the chain if needed.
+func (ch *Chain) advanceToHeader(
+	decryptedHeader header.Header,
+	needRatchet bool,
+	ratchet HeaderRatchetCallback,
+) error {
+	// Note that all gaps are checked before any key derivation, so a peer can not force
+	// a lot of work with a huge message number.
+	err := ch.checkSkippedMessagesCount(decryptedHeader, needRatchet)
 	if err != nil {
-		return errors.Join(ErrDecryptHeaderWithCurrentOrNextKey, err)
+		return err
 	}
 
 	if needRatchet {
@@ -193,10 +763,17 @@ func (ch *Chain) handleEncryptedHeader(encryptedHeader []byte, ratchet RatchetCa
 			return errors.Join(ErrSkipPreviousChainKeys, err)
 		}
 
-		err = ratchet(decryptedHeader.PublicKey)
+		masterKey, nextHeaderKey, err := ratchet(decryptedHeader)
 		if err != nil {
 			return errors.Join(ErrRatchet, err)
 		}
+
+		ch.Upgrade(masterKey, nextHeaderKey)
+
+		if ch.cfg.plaintextHeaders {
+			ch.headerKey.Wipe()
+			ch.headerKey = &keys.Header{Bytes: bytes.Clone(decryptedHeader.PublicKey.Bytes)}
+		}
 	}
 
 	err = ch.skipKeys(decryptedHeader.MessageNumber)
@@ -207,6 +784,66 @@ func (ch *Chain) handleEncryptedHeader(encryptedHeader []byte, ratchet RatchetCa
 	return nil
 }
 
+// checkReplay checks whether the message of passed header is consumed earlier. The key of an
+// earlier message of the current epoch is not found in the storage, so the message is either
+// in the replay window or its key is evicted. In the plaintext header mode the replays of the
+// previous epochs are found in the replay window before the ratchet is attempted.
+func (ch *Chain) checkReplay(encryptedHeader []byte, head header.Header, needRatchet bool) error {
+	if !needRatchet && head.MessageNumber < ch.nextMessageNumber {
+		return ch.missingKeyError(encryptedHeader)
+	}
+
+	if needRatchet && ch.cfg.plaintextHeaders {
+		if _, replayed := ch.replays.find(encryptedHeader); replayed {
+			return ErrDuplicateMessage
+		}
+	}
+
+	return nil
+}
+
+// missingKeyError returns the error of the message, whose key is not found. The message is
+// a duplicate only if it is in the replay window, otherwise its key is evicted or expired,
+// or the message is consumed before the window.
+func (ch *Chain) missingKeyError(encryptedHeader []byte) error {
+	if _, replayed := ch.replays.find(encryptedHeader); replayed {
+		return ErrDuplicateMessage
+	}
+
+	return ErrMessageKeyEvicted
+}
+
+func (ch *Chain) checkSkippedMessagesCount(head header.Header, needRatchet bool) error {
+	nextMessageNumber := ch.nextMessageNumber
+
+	if needRatchet {
+		err := ch.checkSkippedMessagesGap(nextMessageNumber, head.PreviousSendingChainMessagesCount)
+		if err != nil {
+			return err
+		}
+
+		nextMessageNumber = 0
+	}
+
+	return ch.checkSkippedMessagesGap(nextMessageNumber, head.MessageNumber)
+}
+
+func (ch *Chain) checkSkippedMessagesGap(nextMessageNumber, untilMessageNumber uint64) error {
+	if untilMessageNumber <= nextMessageNumber {
+		return nil
+	}
+
+	gap := untilMessageNumber - nextMessageNumber
+	if gap > ch.cfg.maxSkip {
+		return &TooManySkippedMessagesError{
+			Gap:     gap,
+			MaxSkip: ch.cfg.maxSkip,
+		}
+	}
+
+	return nil
+}
+
 func (ch *Chain) skipKeys(untilMessageNumber uint64) error {
 	for messageNumber := ch.nextMessageNumber; messageNumber < untilMessageNumber; messageNumber++ {
 		messageKey, err := ch.advance()
@@ -218,14 +855,79 @@ func (ch *Chain) skipKeys(untilMessageNumber uint64) error {
 			return ErrHeaderKeyIsNil
 		}
 
-		err = ch.cfg.skippedKeysStorage.Add(*ch.headerKey, messageNumber, messageKey)
-		if err != nil {
-			return errors.Join(ErrAddSkippedKey, err)
-		}
+		ch.stagedSkippedKeys = append(ch.stagedSkippedKeys, skippedKey{
+			headerKey:     ch.headerKey.Clone(),
+			messageNumber: messageNumber,
+			messageKey:    messageKey,
+		})
 	}
 
 	return nil
 }
 
+// isCurrentHeaderKey reports whether passed header key is the header key of the current
+// epoch.
+func (ch *Chain) isCurrentHeaderKey(headerKey keys.Header) bool {
+	return ch.headerKey != nil &&
+		subtle.ConstantTimeCompare(headerKey.Bytes, ch.headerKey.Bytes) == 1
+}
+
+// newAuthenticatedHeaderError wraps passed error with passed header, which is authenticated
+// with a header key. The plaintext headers are not authenticated, so the error is returned
+// as is.
+func (ch *Chain) newAuthenticatedHeaderError(head header.Header, err error) error {
+	if ch.cfg.plaintextHeaders {
+		return err
+	}
+
+	return &authenticatedHeaderError{header: head, err: err}
+}
+
+// cloneHeader clones passed header, which may share memory with the scratch.
+func cloneHeader(head header.Header) header.Header {
+	head.PublicKey = head.PublicKey.Clone()
+	head.KEMPublicKey = head.KEMPublicKey.Clone()
+	head.KEMCiphertext = slices.CloneBytes(head.KEMCiphertext)
+
+	return head
+}
+
+// newHeaderRatchetCallback adapts passed ratchet callback, which upgrades the chain itself,
+// to the chain keys decryption, which works on a copy of the chain. The upgraded keys are
+// copied from the chain after the callback.
+func (ch *Chain) newHeaderRatchetCallback(ratchet RatchetCallback) HeaderRatchetCallback {
+	if ratchet == nil {
+		return nil
+	}
+
+	return func(head header.Header) (keys.Master, keys.Header, error) {
+		err := ratchet(head.PublicKey)
+		if err != nil {
+			return keys.Master{}, keys.Header{}, err
+		}
+
+		if ch.masterKey == nil {
+			return keys.Master{}, keys.Header{}, ErrMasterKeyIsNil
+		}
+
+		return ch.masterKey.Clone(), ch.nextHeaderKey.Clone(), nil
+	}
+}
+
 // RatchetCallback must perform ratchet and upgrade receiving chain.
 type RatchetCallback func(remotePublicKey keys.Public) error
+
+// HeaderRatchetCallback must perform ratchet and return new master key and next header key
+// to upgrade the receiving chain with. Passed header is the decrypted header of the first
+// message of the new remote sending chain, so the callback gets its KEM data too.
+type HeaderRatchetCallback func(head header.Header) (keys.Master, keys.Header, error)
+
+// OpenCallback must open the message with passed message key. The key is consumed only if
+// the callback succeeds.
+type OpenCallback func(messageKey keys.Message) ([]byte, error)
+
+type skippedKey struct {
+	headerKey     keys.Header
+	messageNumber uint64
+	messageKey    keys.Message
+}
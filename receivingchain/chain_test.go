@@ -0,0 +1,499 @@
+package receivingchain
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/platform-source/aegis/header"
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/sendingchain"
+)
+
+type countingCrypto struct {
+	testCrypto
+
+	head         header.Header
+	advanceCalls uint64
+}
+
+func (c *countingCrypto) AdvanceChain(_ keys.Master) (keys.Master, keys.Message, error) {
+	c.advanceCalls++
+
+	return keys.Master{}, keys.Message{}, nil
+}
+
+func (c *countingCrypto) DecryptHeader(_ keys.Header, _ []byte) (header.Header, error) {
+	return c.head, nil
+}
+
+var chainDecryptMaxSkipTests = []struct {
+	name                 string
+	messageNumber        uint64
+	maxSkip              uint64
+	expectedAdvanceCalls uint64
+	expectedGap          uint64
+}{
+	{
+		"no gap",
+		0,
+		0,
+		1,
+		0,
+	},
+	{
+		"gap within limit",
+		10,
+		10,
+		11,
+		0,
+	},
+	{
+		"gap over limit",
+		1_000_000,
+		10,
+		0,
+		1_000_000,
+	},
+}
+
+func TestChainDecryptMaxSkip(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range chainDecryptMaxSkipTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			crypto := &countingCrypto{
+				head: header.Header{MessageNumber: test.messageNumber},
+			}
+
+			chain, err := New(
+				&keys.Master{},
+				&keys.Header{},
+				keys.Header{},
+				0,
+				WithCrypto(crypto),
+				WithMaxSkip(test.maxSkip),
+			)
+			if err != nil {
+				t.Fatalf("New(): expected no error but got %v", err)
+			}
+
+			_, err = chain.Decrypt(nil, nil, nil, nil)
+
+			var tooManyErr *TooManySkippedMessagesError
+
+			if test.expectedGap == 0 && err != nil {
+				t.Fatalf("Decrypt(): expected no error but got %v", err)
+			}
+
+			if test.expectedGap != 0 &&
+				(!errors.Is(err, ErrTooManySkippedMessages) || !errors.As(err, &tooManyErr)) {
+				t.Fatalf("Decrypt(): expected too many skipped messages error but got %v", err)
+			}
+
+			if tooManyErr != nil && tooManyErr.Gap != test.expectedGap {
+				t.Fatalf("Decrypt(): expected gap %d but got %d", test.expectedGap, tooManyErr.Gap)
+			}
+
+			if crypto.advanceCalls != test.expectedAdvanceCalls {
+				t.Fatalf(
+					"Decrypt(): expected %d chain advances but got %d",
+					test.expectedAdvanceCalls,
+					crypto.advanceCalls,
+				)
+			}
+		})
+	}
+}
+
+var chainDecryptNextAppendNotNextTests = []struct {
+	name    string
+	options []Option
+}{
+	{
+		"crypto without append methods",
+		[]Option{WithCrypto(&countingCrypto{})},
+	},
+	{
+		"header of other key",
+		nil,
+	},
+}
+
+func TestChainDecryptNextAppendNotNext(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range chainDecryptNextAppendNotNextTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			chain, err := New(&keys.Master{}, &keys.Header{}, keys.Header{}, 0, test.options...)
+			if err != nil {
+				t.Fatalf("New(): expected no error but got %v", err)
+			}
+
+			_, err = chain.DecryptNextAppend(nil, make([]byte, 64), nil, nil)
+			if !errors.Is(err, ErrNotNextMessage) {
+				t.Fatalf("DecryptNextAppend(): expected not next message error but got %v", err)
+			}
+
+			if chain.nextMessageNumber != 0 {
+				t.Fatalf(
+					"DecryptNextAppend(): expected unchanged chain but got next message number %d",
+					chain.nextMessageNumber,
+				)
+			}
+		})
+	}
+}
+
+var errTestRatchet = errors.New("test ratchet")
+
+// iterSkippedKeysStorage hides the Get method of the wrapped storage.
+type iterSkippedKeysStorage struct {
+	SkippedKeysStorage
+}
+
+var chainPlaintextHeadersTests = []struct {
+	name    string
+	storage func(t *testing.T) SkippedKeysStorage
+}{
+	{
+		"storage with getter",
+		func(t *testing.T) SkippedKeysStorage {
+			t.Helper()
+
+			return newTestDefaultSkippedKeysStorage(t, DefaultSkippedKeysLimits())
+		},
+	},
+	{
+		"storage without getter",
+		func(t *testing.T) SkippedKeysStorage {
+			t.Helper()
+
+			return iterSkippedKeysStorage{
+				newTestDefaultSkippedKeysStorage(t, DefaultSkippedKeysLimits()),
+			}
+		},
+	},
+}
+
+func TestChainPlaintextHeaders(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range chainPlaintextHeadersTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			publicKey := keys.Public{Bytes: []byte{1, 2, 3}}
+			newPublicKey := keys.Public{Bytes: []byte{4, 5, 6}}
+			crypto := &countingCrypto{}
+			ratchetCalls := 0
+
+			// Note that the ratchet is attempted for any unknown public key, but not for the
+			// replayed messages of the previous epochs.
+			var chain Chain
+
+			ratchet := func(remotePublicKey keys.Public) error {
+				ratchetCalls++
+
+				if !reflect.DeepEqual(remotePublicKey, newPublicKey) {
+					return errTestRatchet
+				}
+
+				chain.Upgrade(keys.Master{}, keys.Header{Bytes: []byte{7}})
+
+				return nil
+			}
+
+			chain, err := New(
+				&keys.Master{},
+				&keys.Header{Bytes: bytes.Clone(publicKey.Bytes)},
+				keys.Header{},
+				0,
+				WithCrypto(crypto),
+				WithPlaintextHeaders(),
+				WithSkippedKeysStorage(test.storage(t)),
+			)
+			if err != nil {
+				t.Fatalf("New(): expected no error but got %v", err)
+			}
+
+			messages := []struct {
+				head          header.Header
+				expectedCalls int
+				expectedErr   error
+			}{
+				{header.Header{PublicKey: publicKey, MessageNumber: 2}, 0, nil},
+				{header.Header{PublicKey: publicKey, MessageNumber: 0}, 0, nil},
+				{header.Header{PublicKey: publicKey, MessageNumber: 0}, 0, ErrDuplicateMessage},
+				{
+					header.Header{PublicKey: newPublicKey, PreviousSendingChainMessagesCount: 3},
+					1,
+					nil,
+				},
+				{header.Header{PublicKey: publicKey, MessageNumber: 1}, 1, nil},
+				{header.Header{PublicKey: publicKey, MessageNumber: 1}, 1, ErrDuplicateMessage},
+				{header.Header{PublicKey: publicKey, MessageNumber: 3}, 2, errTestRatchet},
+				{header.Header{PublicKey: newPublicKey, MessageNumber: 1}, 2, nil},
+			}
+
+			for i, message := range messages {
+				encodedHeader, err := message.head.Encode()
+				if err != nil {
+					t.Fatalf("Encode(%d): expected no error but got %v", i, err)
+				}
+
+				_, err = chain.Decrypt(encodedHeader, nil, nil, ratchet)
+				if !errors.Is(err, message.expectedErr) {
+					t.Fatalf(
+						"Decrypt(%d): expected error %v but got %v",
+						i,
+						message.expectedErr,
+						err,
+					)
+				}
+
+				if ratchetCalls != message.expectedCalls {
+					t.Fatalf(
+						"Decrypt(%d): expected %d ratchets but got %d",
+						i,
+						message.expectedCalls,
+						ratchetCalls,
+					)
+				}
+			}
+		})
+	}
+}
+
+var errTestGetIter = errors.New("test get iter")
+
+// failingIterSkippedKeysStorage fails to iterate over the wrapped storage.
+type failingIterSkippedKeysStorage struct {
+	SkippedKeysStorage
+}
+
+func (failingIterSkippedKeysStorage) GetIter() (SkippedKeysIter, error) {
+	return nil, errTestGetIter
+}
+
+var chainStatsTests = []struct {
+	name          string
+	storage       func(t *testing.T) SkippedKeysStorage
+	expectedStats Stats
+}{
+	{
+		"default storage",
+		func(t *testing.T) SkippedKeysStorage {
+			t.Helper()
+
+			return newTestDefaultSkippedKeysStorage(t, DefaultSkippedKeysLimits())
+		},
+		Stats{
+			Active:              true,
+			NextMessageNumber:   4,
+			SkippedKeys:         3,
+			SkippedKeysPerEpoch: []uint64{3},
+		},
+	},
+	{
+		"failing storage",
+		func(t *testing.T) SkippedKeysStorage {
+			t.Helper()
+
+			return failingIterSkippedKeysStorage{
+				newTestDefaultSkippedKeysStorage(t, DefaultSkippedKeysLimits()),
+			}
+		},
+		Stats{
+			Active:            true,
+			NextMessageNumber: 4,
+			SkippedKeysErr:    errTestGetIter,
+		},
+	},
+}
+
+func TestChainStats(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range chainStatsTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			chain, err := New(
+				&keys.Master{},
+				&keys.Header{Bytes: []byte{1}},
+				keys.Header{},
+				0,
+				WithCrypto(&countingCrypto{head: header.Header{MessageNumber: 3}}),
+				WithSkippedKeysStorage(test.storage(t)),
+			)
+			if err != nil {
+				t.Fatalf("New(): expected no error but got %v", err)
+			}
+
+			_, err = chain.Decrypt(nil, nil, nil, nil)
+			if err != nil {
+				t.Fatalf("Decrypt(): expected no error but got %v", err)
+			}
+
+			stats := chain.Stats()
+			if !reflect.DeepEqual(stats, test.expectedStats) {
+				t.Fatalf("Stats(): expected %+v but got %+v", test.expectedStats, stats)
+			}
+		})
+	}
+}
+
+func TestChainDecryptWithSkippedKeys(t *testing.T) {
+	t.Parallel()
+
+	masterKey := keys.Master{Bytes: bytes.Repeat([]byte{1}, 32)}
+	headerKey := keys.Header{Bytes: bytes.Repeat([]byte{2}, 32)}
+
+	sending, err := sendingchain.New(
+		masterKey.ClonePtr(),
+		headerKey.ClonePtr(),
+		keys.Header{},
+		0,
+		0,
+	)
+	if err != nil {
+		t.Fatalf("sendingchain.New(): expected no error but got %v", err)
+	}
+
+	receiving, err := New(masterKey.ClonePtr(), headerKey.ClonePtr(), keys.Header{}, 0)
+	if err != nil {
+		t.Fatalf("New(): expected no error but got %v", err)
+	}
+
+	messages := make([][2][]byte, 2)
+	for i := range messages {
+		head := sending.PrepareHeader(keys.Public{Bytes: []byte{3}})
+
+		messages[i][0], messages[i][1], err = sending.Encrypt(head, []byte("data"), []byte("auth"))
+		if err != nil {
+			t.Fatalf("Encrypt(): expected no error but got %v", err)
+		}
+	}
+
+	// Note that the first message is decrypted with the skipped key, which must authenticate
+	// the header like the next message key does.
+	for _, i := range []int{1, 0} {
+		data, err := receiving.Decrypt(messages[i][0], messages[i][1], []byte("auth"), nil)
+		if err != nil {
+			t.Fatalf("Decrypt(%d): expected no error but got %v", i, err)
+		}
+
+		if !bytes.Equal(data, []byte("data")) {
+			t.Fatalf("Decrypt(%d): expected %q but got %q", i, "data", data)
+		}
+	}
+}
+
+func TestChainDecryptEvictedMessage(t *testing.T) {
+	t.Parallel()
+
+	masterKey := keys.Master{Bytes: bytes.Repeat([]byte{1}, 32)}
+	headerKey := keys.Header{Bytes: bytes.Repeat([]byte{2}, 32)}
+
+	sending, err := sendingchain.New(
+		masterKey.ClonePtr(),
+		headerKey.ClonePtr(),
+		keys.Header{},
+		0,
+		0,
+	)
+	if err != nil {
+		t.Fatalf("sendingchain.New(): expected no error but got %v", err)
+	}
+
+	limits := DefaultSkippedKeysLimits()
+	limits.MaxKeys = 1
+
+	receiving, err := New(
+		masterKey.ClonePtr(),
+		headerKey.ClonePtr(),
+		keys.Header{},
+		0,
+		WithSkippedKeysStorage(newTestDefaultSkippedKeysStorage(t, limits)),
+	)
+	if err != nil {
+		t.Fatalf("New(): expected no error but got %v", err)
+	}
+
+	messages := make([][2][]byte, 3)
+	for i := range messages {
+		head := sending.PrepareHeader(keys.Public{Bytes: []byte{3}})
+
+		messages[i][0], messages[i][1], err = sending.Encrypt(head, []byte("data"), nil)
+		if err != nil {
+			t.Fatalf("Encrypt(): expected no error but got %v", err)
+		}
+	}
+
+	// Note that the key of the first message is evicted by the key of the second one, so
+	// the first message is delivered after its key is gone, but it is not a duplicate.
+	tests := []struct {
+		message       int
+		expectedErr   error
+		unexpectedErr error
+	}{
+		{2, nil, nil},
+		{0, ErrMessageKeyEvicted, ErrDuplicateMessage},
+		{1, nil, nil},
+		{1, ErrDuplicateMessage, ErrMessageKeyEvicted},
+		{2, ErrDuplicateMessage, ErrMessageKeyEvicted},
+	}
+
+	for _, test := range tests {
+		message := messages[test.message]
+
+		_, err = receiving.Decrypt(message[0], message[1], nil, nil)
+		if !errors.Is(err, test.expectedErr) ||
+			(test.unexpectedErr != nil && errors.Is(err, test.unexpectedErr)) {
+			t.Fatalf(
+				"Decrypt(%d): expected error %v but got %v",
+				test.message,
+				test.expectedErr,
+				err,
+			)
+		}
+	}
+}
+
+func TestChainUpgrade(t *testing.T) {
+	t.Parallel()
+
+	chain, err := New(
+		&keys.Master{Bytes: []byte{1}},
+		&keys.Header{Bytes: []byte{2}},
+		keys.Header{Bytes: []byte{3}},
+		5,
+	)
+	if err != nil {
+		t.Fatalf("New(): expected no error but got %v", err)
+	}
+
+	chain.Upgrade(keys.Master{Bytes: []byte{4}}, keys.Header{Bytes: []byte{5}})
+
+	if !bytes.Equal(chain.headerKey.Bytes, []byte{3}) {
+		t.Fatalf("Upgrade(): expected header key %v but got %v", []byte{3}, chain.headerKey.Bytes)
+	}
+
+	if !bytes.Equal(chain.nextHeaderKey.Bytes, []byte{5}) {
+		t.Fatalf(
+			"Upgrade(): expected next header key %v but got %v",
+			[]byte{5},
+			chain.nextHeaderKey.Bytes,
+		)
+	}
+
+	if chain.nextMessageNumber != 0 {
+		t.Fatalf("Upgrade(): expected next message number 0 but got %d", chain.nextMessageNumber)
+	}
+}
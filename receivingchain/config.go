@@ -2,19 +2,38 @@ package receivingchain
 
 import (
 	"errors"
+	"time"
 
+	"github.com/platform-source/aegis/aead"
+	"github.com/platform-source/aegis/keys"
 	"github.com/platform-source/tools/check"
 )
 
+const defaultMaxSkip = defaultSkippedKeysStorageMaxKeysPerEpoch
+
 type config struct {
+	allocator          keys.Allocator
 	crypto             Crypto
+	cryptoSet          bool
+	suiteCrypto        Crypto
+	maxSkip            uint64
 	skippedKeysStorage SkippedKeysStorage
+	skippedKeysLimits  SkippedKeysLimits
+	onSkippedKeysEvict SkippedKeysEvictionCallback
+	skippedKeysClock   Clock
+	skippedKeysMaxAge  time.Duration
+	plaintextHeaders   bool
+	padding            bool
+	observer           Observer
+	replayWindowSize   uint64
 }
 
 func newConfig(options ...Option) (config, error) {
 	cfg := config{
-		crypto:             newDefaultCrypto(),
-		skippedKeysStorage: newDefaultSkippedKeysStorage(),
+		crypto:            newDefaultCrypto(aead.XChaCha20Poly1305()),
+		maxSkip:           defaultMaxSkip,
+		skippedKeysLimits: DefaultSkippedKeysLimits(),
+		replayWindowSize:  defaultReplayWindowSize,
 	}
 
 	err := cfg.applyOptions(options...)
@@ -22,6 +41,21 @@ func newConfig(options ...Option) (config, error) {
 		return config{}, errors.Join(ErrApplyOptions, err)
 	}
 
+	if cfg.suiteCrypto != nil {
+		if cfg.cryptoSet {
+			return config{}, errors.Join(ErrApplyOptions, ErrMixedCrypto)
+		}
+
+		cfg.crypto = cfg.suiteCrypto
+	}
+
+	if cfg.skippedKeysStorage == nil {
+		cfg.skippedKeysStorage, err = cfg.newDefaultSkippedKeysStorage()
+		if err != nil {
+			return config{}, errors.Join(ErrNewDefaultSkippedKeysStorage, err)
+		}
+	}
+
 	return cfg, nil
 }
 
@@ -36,6 +70,42 @@ func (cfg *config) applyOptions(options ...Option) error {
 	return nil
 }
 
+func (cfg config) newDefaultSkippedKeysStorage() (*DefaultSkippedKeysStorage, error) {
+	var options []DefaultSkippedKeysStorageOption
+
+	if onEvict := cfg.newEvictionCallback(); onEvict != nil {
+		options = append(options, WithEvictionCallback(onEvict))
+	}
+
+	if cfg.skippedKeysClock != nil {
+		options = append(options, WithClock(cfg.skippedKeysClock))
+	}
+
+	if cfg.skippedKeysMaxAge != 0 {
+		options = append(options, WithMaxAge(cfg.skippedKeysMaxAge))
+	}
+
+	return NewDefaultSkippedKeysStorage(cfg.skippedKeysLimits, options...)
+}
+
+// newEvictionCallback returns the eviction callback of the default storage, which reports
+// the evictions to both the eviction callback and the observer.
+func (cfg config) newEvictionCallback() SkippedKeysEvictionCallback {
+	switch {
+	case cfg.observer.OnSkippedKey == nil:
+		return cfg.onSkippedKeysEvict
+	case cfg.onSkippedKeysEvict == nil:
+		return func(_ keys.Header, messageNumber uint64) {
+			cfg.observer.skippedKey(SkippedKeyEvicted, messageNumber)
+		}
+	default:
+		return func(headerKey keys.Header, messageNumber uint64) {
+			cfg.onSkippedKeysEvict(headerKey, messageNumber)
+			cfg.observer.skippedKey(SkippedKeyEvicted, messageNumber)
+		}
+	}
+}
+
 func (cfg config) clone() config {
 	cfg.skippedKeysStorage = cfg.skippedKeysStorage.Clone()
 
@@ -45,6 +115,33 @@ func (cfg config) clone() config {
 // Option is the way to modify config default values.
 type Option func(cfg *config) error
 
+// WithAEAD is an option to set the default crypto with passed AEAD suite.
+func WithAEAD(suite aead.Suite) Option {
+	return func(cfg *config) error {
+		if !suite.Valid() {
+			return ErrInvalidAEAD
+		}
+
+		cfg.crypto = newDefaultCrypto(suite)
+		cfg.cryptoSet = true
+
+		return nil
+	}
+}
+
+// WithAllocator sets passed allocator, which holds the secret keys of the chain.
+func WithAllocator(allocator keys.Allocator) Option {
+	return func(cfg *config) error {
+		if check.IsNil(allocator) {
+			return ErrAllocatorIsNil
+		}
+
+		cfg.allocator = allocator
+
+		return nil
+	}
+}
+
 // WithCrypto sets passed crypto to the config.
 func WithCrypto(crypto Crypto) Option {
 	return func(cfg *config) error {
@@ -53,6 +150,151 @@ func WithCrypto(crypto Crypto) Option {
 		}
 
 		cfg.crypto = crypto
+		cfg.cryptoSet = true
+
+		return nil
+	}
+}
+
+// WithMaxSkip sets the maximum count of messages, which may be skipped by one received
+// message in one chain. The count is checked before any key derivation.
+func WithMaxSkip(maxSkip uint64) Option {
+	return func(cfg *config) error {
+		cfg.maxSkip = maxSkip
+
+		return nil
+	}
+}
+
+// WithMaxSkippedEpochs sets the maximum count of epochs retained by the default skipped
+// keys storage. The option has no effect if the storage is passed with WithSkippedKeysStorage.
+func WithMaxSkippedEpochs(count uint64) Option {
+	return func(cfg *config) error {
+		if count == 0 {
+			return ErrSkippedKeysLimitIsZero
+		}
+
+		cfg.skippedKeysLimits.MaxEpochs = count
+
+		return nil
+	}
+}
+
+// WithMaxSkippedKeys sets the maximum count of keys in all epochs of the default skipped
+// keys storage. The option has no effect if the storage is passed with WithSkippedKeysStorage.
+func WithMaxSkippedKeys(count uint64) Option {
+	return func(cfg *config) error {
+		if count == 0 {
+			return ErrSkippedKeysLimitIsZero
+		}
+
+		cfg.skippedKeysLimits.MaxKeys = count
+
+		return nil
+	}
+}
+
+// WithMaxSkippedKeysPerEpoch sets the maximum count of keys in one epoch of the default
+// skipped keys storage. The option has no effect if the storage is passed with
+// WithSkippedKeysStorage.
+func WithMaxSkippedKeysPerEpoch(count uint64) Option {
+	return func(cfg *config) error {
+		if count == 0 {
+			return ErrSkippedKeysLimitIsZero
+		}
+
+		cfg.skippedKeysLimits.MaxKeysPerEpoch = count
+
+		return nil
+	}
+}
+
+// WithObserver sets passed observer of the chain events. Evictions are reported only if the
+// default skipped keys storage is used.
+func WithObserver(observer Observer) Option {
+	return func(cfg *config) error {
+		cfg.observer = observer
+
+		return nil
+	}
+}
+
+// WithPadding enables the removal of the padding from the decrypted messages. The padding
+// of all schemes of the padding package is removed, so the scheme is not needed.
+func WithPadding() Option {
+	return func(cfg *config) error {
+		cfg.padding = true
+
+		return nil
+	}
+}
+
+// WithPlaintextHeaders enables the plaintext header mode. The headers are sent in clear
+// and authenticated with the message only, so they are not trial-decrypted. The epoch of
+// the skipped keys is the public key of the remote sending chain instead of its header key,
+// so the skipped keys are found by the public key and the message number of the header.
+func WithPlaintextHeaders() Option {
+	return func(cfg *config) error {
+		cfg.plaintextHeaders = true
+
+		return nil
+	}
+}
+
+// WithReplayWindowSize sets the count of the recently consumed messages, whose replays are
+// reported with ErrDuplicateMessage after their keys are deleted. Other messages without
+// keys are reported with ErrMessageKeyEvicted. Zero disables the window.
+//
+// The window keeps the SHA-256 digest of the encrypted header, the public key, the message
+// number and the previous messages count of each message, but no keys. They are encoded by
+// MarshalBinary.
+func WithReplayWindowSize(size uint64) Option {
+	return func(cfg *config) error {
+		cfg.replayWindowSize = size
+
+		return nil
+	}
+}
+
+// WithSkippedKeysClock sets passed clock to the default skipped keys storage. The option
+// has no effect if the storage is passed with WithSkippedKeysStorage.
+func WithSkippedKeysClock(clock Clock) Option {
+	return func(cfg *config) error {
+		if check.IsNil(clock) {
+			return ErrClockIsNil
+		}
+
+		cfg.skippedKeysClock = clock
+
+		return nil
+	}
+}
+
+// WithSkippedKeysMaxAge enables expiry of the keys of the default skipped keys storage,
+// which are older than passed age. The option has no effect if the storage is passed with
+// WithSkippedKeysStorage.
+func WithSkippedKeysMaxAge(maxAge time.Duration) Option {
+	return func(cfg *config) error {
+		if maxAge <= 0 {
+			return ErrInvalidMaxAge
+		}
+
+		cfg.skippedKeysMaxAge = maxAge
+
+		return nil
+	}
+}
+
+// WithSkippedKeysEvictionCallback sets passed callback, which is called when the default
+// skipped keys storage evicts keys. The option has no effect if the storage is passed with
+// WithSkippedKeysStorage.
+func WithSkippedKeysEvictionCallback(callback SkippedKeysEvictionCallback) Option {
+	return func(cfg *config) error {
+		if check.IsNil(callback) {
+			return ErrEvictionCallbackIsNil
+		}
+
+		cfg.onSkippedKeysEvict = callback
 
 		return nil
 	}
@@ -70,3 +312,17 @@ func WithSkippedKeysStorage(storage SkippedKeysStorage) Option {
 		return err
 	}
 }
+
+// WithSuiteCrypto sets passed crypto of the ratchet suite. Unlike WithCrypto, it can not be
+// combined with WithCrypto and WithAEAD, so the chain never mixes crypto of different suites.
+func WithSuiteCrypto(crypto Crypto) Option {
+	return func(cfg *config) error {
+		if check.IsNil(crypto) {
+			return ErrCryptoIsNil
+		}
+
+		cfg.suiteCrypto = crypto
+
+		return nil
+	}
+}
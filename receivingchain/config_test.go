@@ -4,7 +4,9 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/platform-source/aegis/aead"
 	"github.com/platform-source/aegis/header"
 	"github.com/platform-source/aegis/keys"
 )
@@ -51,13 +53,54 @@ var newConfigTests = []struct {
 	errCategories              []error
 	expectedCrypto             Crypto
 	expectedSkippedKeysStorage SkippedKeysStorage
+	expectedSkippedKeysLimits  SkippedKeysLimits
 }{
 	{
 		"default",
 		nil,
 		nil,
 		defaultCrypto{},
-		defaultSkippedKeysStorage{},
+		&DefaultSkippedKeysStorage{},
+		DefaultSkippedKeysLimits(),
+	},
+	{
+		"AEAD option success",
+		[]Option{
+			WithAEAD(aead.AES256GCM()),
+		},
+		nil,
+		defaultCrypto{},
+		&DefaultSkippedKeysStorage{},
+		DefaultSkippedKeysLimits(),
+	},
+	{
+		"invalid AEAD",
+		[]Option{
+			WithAEAD(aead.Suite{}),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrInvalidAEAD,
+		},
+		nil,
+		nil,
+		SkippedKeysLimits{},
+	},
+	{
+		"skipped keys limits options success",
+		[]Option{
+			WithMaxSkippedEpochs(1),
+			WithMaxSkippedKeysPerEpoch(2),
+			WithMaxSkippedKeys(3),
+		},
+		nil,
+		defaultCrypto{},
+		&DefaultSkippedKeysStorage{},
+		SkippedKeysLimits{
+			MaxEpochs:       1,
+			MaxKeysPerEpoch: 2,
+			MaxKeys:         3,
+		},
 	},
 	{
 		"crypto and skipped keys storage options success",
@@ -68,6 +111,7 @@ var newConfigTests = []struct {
 		nil,
 		testCrypto{},
 		&testSkippedKeysStorage{},
+		DefaultSkippedKeysLimits(),
 	},
 	{
 		"nil crypto",
@@ -80,6 +124,7 @@ var newConfigTests = []struct {
 		},
 		nil,
 		nil,
+		SkippedKeysLimits{},
 	},
 	{
 		"nil skipped keys storage",
@@ -92,6 +137,96 @@ var newConfigTests = []struct {
 		},
 		nil,
 		nil,
+		SkippedKeysLimits{},
+	},
+	{
+		"nil skipped keys eviction callback",
+		[]Option{
+			WithSkippedKeysEvictionCallback(nil),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrEvictionCallbackIsNil,
+		},
+		nil,
+		nil,
+		SkippedKeysLimits{},
+	},
+	{
+		"nil skipped keys clock",
+		[]Option{
+			WithSkippedKeysClock(nil),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrClockIsNil,
+		},
+		nil,
+		nil,
+		SkippedKeysLimits{},
+	},
+	{
+		"invalid skipped keys max age",
+		[]Option{
+			WithSkippedKeysMaxAge(-time.Second),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrInvalidMaxAge,
+		},
+		nil,
+		nil,
+		SkippedKeysLimits{},
+	},
+	{
+		"zero skipped keys limit",
+		[]Option{
+			WithMaxSkippedEpochs(0),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrSkippedKeysLimitIsZero,
+		},
+		nil,
+		nil,
+		SkippedKeysLimits{},
+	},
+	{
+		"suite crypto option success",
+		[]Option{
+			WithSuiteCrypto(testCrypto{}),
+		},
+		nil,
+		testCrypto{},
+		&DefaultSkippedKeysStorage{},
+		DefaultSkippedKeysLimits(),
+	},
+	{
+		"mixed crypto",
+		[]Option{
+			WithAEAD(aead.AES256GCM()),
+			WithSuiteCrypto(testCrypto{}),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrMixedCrypto,
+		},
+		nil,
+		nil,
+		SkippedKeysLimits{},
+	},
+	{
+		"nil suite crypto",
+		[]Option{
+			WithSuiteCrypto(nil),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrCryptoIsNil,
+		},
+		nil,
+		nil,
+		SkippedKeysLimits{},
 	},
 }
 
@@ -128,6 +263,23 @@ func TestNewConfig(t *testing.T) {
 			) {
 				t.Fatal("WithSkippedKeysStorage() option did not set passed skipped keys storage")
 			}
+
+			if cfg.skippedKeysLimits != test.expectedSkippedKeysLimits {
+				t.Fatalf(
+					"newConfig(): expected limits %+v but got %+v",
+					test.expectedSkippedKeysLimits,
+					cfg.skippedKeysLimits,
+				)
+			}
+
+			storage, ok := cfg.skippedKeysStorage.(*DefaultSkippedKeysStorage)
+			if ok && storage.Limits() != test.expectedSkippedKeysLimits {
+				t.Fatalf(
+					"newConfig(): expected storage limits %+v but got %+v",
+					test.expectedSkippedKeysLimits,
+					storage.Limits(),
+				)
+			}
 		})
 	}
 }
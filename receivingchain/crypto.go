@@ -11,3 +11,26 @@ type Crypto interface {
 	DecryptHeader(key keys.Header, encryptedHeader []byte) (header.Header, error)
 	DecryptMessage(key keys.Message, encryptedMessage, auth []byte) ([]byte, error)
 }
+
+// AppendCrypto is the optional interface of the receiving chain crypto, which appends its
+// outputs to passed buffers. The chain uses it to decrypt without allocations.
+//
+// The header returned by DecryptHeaderAppend may refer to the returned buffer.
+type AppendCrypto interface {
+	AdvanceChainAppend(
+		dstMasterKey []byte,
+		dstMessageKey []byte,
+		masterKey keys.Master,
+	) (keys.Master, keys.Message, error)
+	DecryptHeaderAppend(
+		dst []byte,
+		key keys.Header,
+		encryptedHeader []byte,
+	) (header.Header, []byte, error)
+	DecryptMessageAppend(
+		dst []byte,
+		key keys.Message,
+		encryptedMessage []byte,
+		auth []byte,
+	) ([]byte, error)
+}
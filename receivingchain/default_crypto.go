@@ -1,63 +1,45 @@
 package receivingchain
 
 import (
-	"crypto/hmac"
 	"errors"
-	"hash"
 
+	"github.com/platform-source/aegis/aead"
 	"github.com/platform-source/aegis/chainscommon"
 	"github.com/platform-source/aegis/header"
 	"github.com/platform-source/aegis/keys"
-	"golang.org/x/crypto/blake2b"
-	cipher "golang.org/x/crypto/chacha20poly1305"
 )
 
-type defaultCrypto struct{}
-
-func newDefaultCrypto() defaultCrypto {
-	crypto := defaultCrypto{}
-
-	return crypto
+type defaultCrypto struct {
+	aead aead.Suite
 }
 
-func (defaultCrypto) AdvanceChain(masterKey keys.Master) (keys.Master, keys.Message, error) {
-	var newHasherErr error
-
-	getHasher := func() hash.Hash {
-		hasher, err := blake2b.New512(nil)
-		newHasherErr = err
-
-		return hasher
-	}
-
-	mac := hmac.New(getHasher, masterKey.Bytes)
-
-	const masterKeyByte = 0x02
-
-	_, err := mac.Write([]byte{masterKeyByte})
-	if err != nil {
-		return keys.Master{}, keys.Message{}, errors.Join(ErrWriteMasterKeyByteToMAC, err)
-	}
+// NewDefaultCrypto returns the default crypto of the chain with passed AEAD suite.
+func NewDefaultCrypto(suite aead.Suite) Crypto {
+	return newDefaultCrypto(suite)
+}
 
-	newMasterKey := keys.Master{
-		Bytes: mac.Sum(nil),
+func newDefaultCrypto(suite aead.Suite) defaultCrypto {
+	crypto := defaultCrypto{
+		aead: suite,
 	}
-	mac.Reset()
-
-	const messageKeyByte = 0x01
 
-	_, err = mac.Write([]byte{messageKeyByte})
-	if err != nil {
-		return keys.Master{}, keys.Message{}, errors.Join(ErrWriteMessageKeyByteToMAC, err)
-	}
+	return crypto
+}
 
-	messageKey := keys.Message{
-		Bytes: mac.Sum(nil),
-	}
+func (c defaultCrypto) AdvanceChain(masterKey keys.Master) (keys.Master, keys.Message, error) {
+	return c.AdvanceChainAppend(nil, nil, masterKey)
+}
 
-	if newHasherErr != nil {
-		return keys.Master{}, keys.Message{}, errors.Join(ErrNewHasher, newHasherErr)
-	}
+func (defaultCrypto) AdvanceChainAppend(
+	dstMasterKey []byte,
+	dstMessageKey []byte,
+	masterKey keys.Master,
+) (keys.Master, keys.Message, error) {
+	newMasterKey, messageKey := chainscommon.AdvanceChainAppend(
+		dstMasterKey,
+		dstMessageKey,
+		masterKey,
+	)
 
 	return newMasterKey, messageKey, nil
 }
@@ -66,26 +48,40 @@ func (c defaultCrypto) DecryptHeader(
 	key keys.Header,
 	encryptedHeader []byte,
 ) (header.Header, error) {
-	if len(encryptedHeader) <= cipher.NonceSizeX {
-		return header.Header{}, ErrNotEnoughEncryptedHeaderBytes
+	decryptedHeader, _, err := c.DecryptHeaderAppend(nil, key, encryptedHeader)
+
+	return decryptedHeader, err
+}
+
+func (c defaultCrypto) DecryptHeaderAppend(
+	dst []byte,
+	key keys.Header,
+	encryptedHeader []byte,
+) (header.Header, []byte, error) {
+	nonceSize := c.aead.NonceSize()
+	if len(encryptedHeader) <= nonceSize {
+		return header.Header{}, nil, ErrNotEnoughEncryptedHeaderBytes
 	}
 
-	decryptedHeaderBytes, err := c.decrypt(
+	headerStart := len(dst)
+
+	dst, err := c.decrypt(
+		dst,
 		key.Bytes,
-		encryptedHeader[:cipher.NonceSizeX],
-		encryptedHeader[cipher.NonceSizeX:],
+		encryptedHeader[:nonceSize],
+		encryptedHeader[nonceSize:],
 		nil,
 	)
 	if err != nil {
-		return header.Header{}, err
+		return header.Header{}, nil, err
 	}
 
-	decryptedHeader, err := header.Decode(decryptedHeaderBytes)
+	decryptedHeader, err := header.Decode(dst[headerStart:])
 	if err != nil {
-		return header.Header{}, errors.Join(ErrDecodeHeader, err)
+		return header.Header{}, nil, errors.Join(ErrDecodeHeader, err)
 	}
 
-	return decryptedHeader, nil
+	return decryptedHeader, dst, nil
 }
 
 func (c defaultCrypto) DecryptMessage(
@@ -93,26 +89,35 @@ func (c defaultCrypto) DecryptMessage(
 	encryptedMessage []byte,
 	auth []byte,
 ) ([]byte, error) {
-	cipherKey, nonce, err := chainscommon.DeriveMessageCipherKeyAndNonce(key)
-	if err != nil {
-		return nil, errors.Join(ErrDeriveMessageCipherKeyAndNonce, err)
-	}
+	return c.DecryptMessageAppend(nil, key, encryptedMessage, auth)
+}
 
-	decryptedMessage, err := c.decrypt(cipherKey, nonce, encryptedMessage, auth)
+func (c defaultCrypto) DecryptMessageAppend(
+	dst []byte,
+	key keys.Message,
+	encryptedMessage []byte,
+	auth []byte,
+) ([]byte, error) {
+	var kdfOutput [chainscommon.MaxMessageCipherKeyAndNonceSize]byte
+	defer clear(kdfOutput[:])
+
+	cipherKeyAndNonce := chainscommon.AppendMessageCipherKeyAndNonce(kdfOutput[:0], c.aead, key)
+	cipherKey, nonce := cipherKeyAndNonce[:c.aead.KeySize()], cipherKeyAndNonce[c.aead.KeySize():]
+
+	dst, err := c.decrypt(dst, cipherKey, nonce, encryptedMessage, auth)
 	if err != nil {
 		return nil, errors.Join(ErrDecrypt, err)
 	}
 
-	return decryptedMessage, nil
+	return dst, nil
 }
 
-func (defaultCrypto) decrypt(key, nonce, encryptedData, auth []byte) ([]byte, error) {
-	cipherX, err := cipher.NewX(key)
-	if err != nil {
+func (c defaultCrypto) decrypt(dst, key, nonce, encryptedData, auth []byte) ([]byte, error) {
+	decryptedData, err := c.aead.Open(dst, key, nonce, encryptedData, auth)
+	if errors.Is(err, aead.ErrNewCipher) {
 		return nil, errors.Join(ErrNewCipher, err)
 	}
 
-	decryptedData, err := cipherX.Open(nil, nonce, encryptedData, auth)
 	if err != nil {
 		return nil, errors.Join(ErrOpenCipher, err)
 	}
@@ -1,81 +1,279 @@
 package receivingchain
 
 import (
+	"container/list"
+	"errors"
+	"slices"
+	"time"
+
 	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/tools/check"
 )
 
 const (
-	defaultSkippedKeysStorageHeaderKeysCountToClear = 4
-	defaultSkippedKeysStorageMessageKeysCountLimit  = 1024
+	defaultSkippedKeysStorageMaxEpochs       = 4
+	defaultSkippedKeysStorageMaxKeysPerEpoch = 1024
+	defaultSkippedKeysStorageMaxKeys         = defaultSkippedKeysStorageMaxEpochs *
+		defaultSkippedKeysStorageMaxKeysPerEpoch
 )
 
-type defaultSkippedKeysStorage struct {
-	mapping map[string]map[uint64]keys.Message
+// SkippedKeysLimits are the limits of the default skipped keys storage. Epoch is the
+// set of skipped keys sharing the same header key.
+type SkippedKeysLimits struct {
+	// MaxEpochs is the maximum count of epochs retained.
+	MaxEpochs uint64
+
+	// MaxKeysPerEpoch is the maximum count of keys in one epoch.
+	MaxKeysPerEpoch uint64
+
+	// MaxKeys is the maximum count of keys in all epochs.
+	MaxKeys uint64
+}
+
+// DefaultSkippedKeysLimits returns the limits used by the default skipped keys storage.
+func DefaultSkippedKeysLimits() SkippedKeysLimits {
+	return SkippedKeysLimits{
+		MaxEpochs:       defaultSkippedKeysStorageMaxEpochs,
+		MaxKeysPerEpoch: defaultSkippedKeysStorageMaxKeysPerEpoch,
+		MaxKeys:         defaultSkippedKeysStorageMaxKeys,
+	}
+}
+
+func (limits SkippedKeysLimits) validate() error {
+	if limits.MaxEpochs == 0 || limits.MaxKeysPerEpoch == 0 || limits.MaxKeys == 0 {
+		return ErrSkippedKeysLimitIsZero
+	}
+
+	return nil
+}
+
+// SkippedKeysEvictionCallback is called for every skipped key evicted from the storage.
+// The message with passed header key and number can not be decrypted anymore.
+type SkippedKeysEvictionCallback func(headerKey keys.Header, messageNumber uint64)
+
+// Clock returns the current time.
+type Clock func() time.Time
+
+// DefaultSkippedKeysStorageOption is the way to modify default skipped keys storage.
+type DefaultSkippedKeysStorageOption func(st *DefaultSkippedKeysStorage) error
+
+// WithEvictionCallback sets passed callback, which is called on skipped keys eviction.
+func WithEvictionCallback(callback SkippedKeysEvictionCallback) DefaultSkippedKeysStorageOption {
+	return func(st *DefaultSkippedKeysStorage) error {
+		if check.IsNil(callback) {
+			return ErrEvictionCallbackIsNil
+		}
+
+		st.onEvict = callback
+
+		return nil
+	}
+}
+
+// WithClock sets passed clock, which is used to record creation time of keys.
+func WithClock(clock Clock) DefaultSkippedKeysStorageOption {
+	return func(st *DefaultSkippedKeysStorage) error {
+		if check.IsNil(clock) {
+			return ErrClockIsNil
+		}
+
+		st.clock = clock
+
+		return nil
+	}
+}
+
+// WithMaxAge enables expiry of keys older than passed age. Expired keys are purged in
+// Add, GetIter and Prune calls and reported to the eviction callback.
+func WithMaxAge(maxAge time.Duration) DefaultSkippedKeysStorageOption {
+	return func(st *DefaultSkippedKeysStorage) error {
+		if maxAge <= 0 {
+			return ErrInvalidMaxAge
+		}
+
+		st.maxAge = maxAge
+
+		return nil
+	}
 }
 
-func newDefaultSkippedKeysStorage() defaultSkippedKeysStorage {
-	storage := defaultSkippedKeysStorage{
-		mapping: make(map[string]map[uint64]keys.Message),
+// DefaultSkippedKeysStorage is the in-memory skipped keys storage, which is used by
+// the receiving chain if no other storage is passed.
+//
+// Keys are kept in insertion order. When the limits are reached, keys are evicted
+// starting from the oldest epoch. Creation time of each key is recorded, so keys
+// may also expire if the max age is set. The epoch is removed with its last key.
+type DefaultSkippedKeysStorage struct {
+	epochs    []*skippedKeysEpoch
+	mapping   map[string]*skippedKeysEpoch
+	keysCount uint64
+	limits    SkippedKeysLimits
+	onEvict   SkippedKeysEvictionCallback
+	clock     Clock
+	maxAge    time.Duration
+}
+
+// NewDefaultSkippedKeysStorage creates a new default skipped keys storage with passed limits.
+func NewDefaultSkippedKeysStorage(
+	limits SkippedKeysLimits,
+	options ...DefaultSkippedKeysStorageOption,
+) (*DefaultSkippedKeysStorage, error) {
+	err := limits.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	storage := &DefaultSkippedKeysStorage{
+		mapping: make(map[string]*skippedKeysEpoch),
+		limits:  limits,
 	}
 
-	return storage
+	for _, option := range options {
+		err = option(storage)
+		if err != nil {
+			return nil, errors.Join(ErrApplyOptions, err)
+		}
+	}
+
+	return storage, nil
 }
 
-func (st defaultSkippedKeysStorage) Add(
+// Add adds new skipped key to the storage. Keys of the oldest epochs are evicted if
+// the limits are reached.
+func (st *DefaultSkippedKeysStorage) Add(
 	headerKey keys.Header,
 	messageNumber uint64,
 	messageKey keys.Message,
 ) error {
-	if st.getHeaderKeysCount() >= defaultSkippedKeysStorageHeaderKeysCountToClear {
-		st.clear()
+	now := st.now()
+	st.Prune(now)
+
+	return st.add(headerKey, messageNumber, messageKey, now)
+}
+
+// AddCreatedAt adds new skipped key, which was created at passed time, to the storage.
+// This is useful to restore keys with their original creation time.
+func (st *DefaultSkippedKeysStorage) AddCreatedAt(
+	headerKey keys.Header,
+	messageNumber uint64,
+	messageKey keys.Message,
+	createdAt time.Time,
+) error {
+	now := st.now()
+	st.Prune(now)
+
+	if st.isExpired(createdAt, now) {
+		st.reportEviction(headerKey, messageNumber)
+
+		return nil
 	}
 
-	if st.getMessageKeysCount(headerKey) >= defaultSkippedKeysStorageMessageKeysCountLimit {
-		return ErrTooManySkippedMessageKeys
+	return st.add(headerKey, messageNumber, messageKey, createdAt)
+}
+
+// Clone deep clones the storage.
+func (st *DefaultSkippedKeysStorage) Clone() SkippedKeysStorage {
+	stClone := &DefaultSkippedKeysStorage{
+		epochs:    make([]*skippedKeysEpoch, 0, len(st.epochs)),
+		mapping:   make(map[string]*skippedKeysEpoch, len(st.mapping)),
+		keysCount: st.keysCount,
+		limits:    st.limits,
+		onEvict:   st.onEvict,
+		clock:     st.clock,
+		maxAge:    st.maxAge,
 	}
 
-	st.addUnsafe(headerKey, messageNumber, messageKey)
+	for _, epoch := range st.epochs {
+		epochClone := epoch.clone()
+		stClone.epochs = append(stClone.epochs, epochClone)
+		stClone.mapping[st.serializeHeaderKey(epoch.headerKey)] = epochClone
+	}
 
-	return nil
+	return stClone
 }
 
-func (st defaultSkippedKeysStorage) Clone() SkippedKeysStorage {
-	stClone := defaultSkippedKeysStorage{
-		mapping: make(map[string]map[uint64]keys.Message, st.getHeaderKeysCount()),
+// CreatedAt returns creation time of the skipped key by header key and message number.
+func (st *DefaultSkippedKeysStorage) CreatedAt(
+	headerKey keys.Header,
+	messageNumber uint64,
+) (time.Time, bool) {
+	epoch, exists := st.mapping[st.serializeHeaderKey(headerKey)]
+	if !exists {
+		return time.Time{}, false
 	}
 
-	for serHeaderKey, messageNumberKeys := range st.mapping {
-		stClone.mapping[serHeaderKey] = make(map[uint64]keys.Message, len(messageNumberKeys))
-
-		for messageNumber, messageKey := range messageNumberKeys {
-			stClone.mapping[serHeaderKey][messageNumber] = messageKey.Clone()
-		}
+	entry, exists := epoch.entries[messageNumber]
+	if !exists {
+		return time.Time{}, false
 	}
 
-	return stClone
+	return entry.createdAt, true
 }
 
-func (st defaultSkippedKeysStorage) Delete(headerKey keys.Header, messageNumber uint64) error {
-	serHeaderKey := st.serializeHeaderKey(headerKey)
-	delete(st.mapping[serHeaderKey], messageNumber)
+// Delete deletes skipped key by header key and message number. The epoch is removed if
+// the key is the last one.
+func (st *DefaultSkippedKeysStorage) Delete(headerKey keys.Header, messageNumber uint64) error {
+	epoch, exists := st.mapping[st.serializeHeaderKey(headerKey)]
+	if !exists {
+		return nil
+	}
+
+	if _, exists = epoch.entries[messageNumber]; !exists {
+		return nil
+	}
+
+	epoch.deleteEntry(messageNumber)
+	st.keysCount--
+
+	if len(epoch.entries) == 0 {
+		st.removeEpoch(epoch)
+	}
 
 	return nil
 }
 
-func (st defaultSkippedKeysStorage) GetIter() (SkippedKeysIter, error) {
-	iter := func(yield SkippedKeysYield) {
-		for serHeaderKey, messageNumberKeys := range st.mapping {
-			headerKey := st.deserializeHeaderKey(serHeaderKey)
+// Get returns the skipped key by header key and message number. Expired keys are purged
+// before.
+func (st *DefaultSkippedKeysStorage) Get(
+	headerKey keys.Header,
+	messageNumber uint64,
+) (keys.Message, bool, error) {
+	st.Prune(st.now())
+
+	epoch, exists := st.mapping[st.serializeHeaderKey(headerKey)]
+	if !exists {
+		return keys.Message{}, false, nil
+	}
 
+	entry, exists := epoch.entries[messageNumber]
+	if !exists {
+		return keys.Message{}, false, nil
+	}
+
+	return entry.messageKey, true, nil
+}
+
+// GetIter returns function, which iterates over all skipped keys in insertion order.
+// Expired keys are purged before.
+func (st *DefaultSkippedKeysStorage) GetIter() (SkippedKeysIter, error) {
+	st.Prune(st.now())
+
+	iter := func(yield SkippedKeysYield) {
+		for _, epoch := range st.epochs {
 			messageNumberKeysIter := func(yield SkippedMessageNumberKeysYield) {
-				for messageNumber, messageKey := range messageNumberKeys {
-					if !yield(messageNumber, messageKey) {
+				// Note that the next element is taken before the yield, which may delete
+				// the current one.
+				for element := epoch.order.Front(); element != nil; {
+					messageNumber := element.Value.(uint64)
+					element = element.Next()
+
+					if !yield(messageNumber, epoch.entries[messageNumber].messageKey) {
 						return
 					}
 				}
 			}
 
-			if !yield(headerKey, messageNumberKeysIter) {
+			if !yield(epoch.headerKey, messageNumberKeysIter) {
 				return
 			}
 		}
@@ -84,47 +282,247 @@ func (st defaultSkippedKeysStorage) GetIter() (SkippedKeysIter, error) {
 	return iter, nil
 }
 
-func (st defaultSkippedKeysStorage) addUnsafe(
+// Limits returns the limits of the storage.
+func (st *DefaultSkippedKeysStorage) Limits() SkippedKeysLimits {
+	return st.limits
+}
+
+// Prune purges keys expired at passed time and reports them to the eviction callback.
+// It does nothing if the max age is not set.
+func (st *DefaultSkippedKeysStorage) Prune(now time.Time) {
+	if st.maxAge == 0 {
+		return
+	}
+
+	for _, epoch := range st.epochs {
+		// Note that keys of the epoch are added in creation order, so only the
+		// oldest keys have to be checked.
+		for len(epoch.entries) > 0 {
+			messageNumber := epoch.oldestMessageNumber()
+			if !st.isExpired(epoch.entries[messageNumber].createdAt, now) {
+				break
+			}
+
+			epoch.deleteEntry(messageNumber)
+			st.keysCount--
+			st.reportEviction(epoch.headerKey, messageNumber)
+		}
+
+		if len(epoch.entries) == 0 {
+			st.removeEpoch(epoch)
+		}
+	}
+}
+
+// Wipe wipes all keys and empties the storage.
+func (st *DefaultSkippedKeysStorage) Wipe() {
+	for _, epoch := range st.epochs {
+		epoch.wipe()
+	}
+
+	st.epochs = nil
+	st.mapping = make(map[string]*skippedKeysEpoch)
+	st.keysCount = 0
+}
+
+func (st *DefaultSkippedKeysStorage) add(
 	headerKey keys.Header,
 	messageNumber uint64,
 	messageKey keys.Message,
-) {
-	serHeaderKey := st.serializeHeaderKey(headerKey)
+	createdAt time.Time,
+) error {
+	epoch, exists := st.mapping[st.serializeHeaderKey(headerKey)]
+	if !exists {
+		for st.getHeaderKeysCount() >= st.limits.MaxEpochs {
+			st.evictEpoch()
+		}
+
+		epoch = st.addEpoch(headerKey)
+	}
 
-	if _, ok := st.mapping[serHeaderKey]; !ok {
-		st.mapping[serHeaderKey] = make(map[uint64]keys.Message)
+	entry := skippedKeyEntry{
+		messageKey: messageKey,
+		createdAt:  createdAt,
 	}
 
-	st.mapping[serHeaderKey][messageNumber] = messageKey
+	if oldEntry, exists := epoch.entries[messageNumber]; exists {
+		oldEntry.messageKey.Wipe()
+		entry.element = oldEntry.element
+		epoch.entries[messageNumber] = entry
+
+		return nil
+	}
+
+	if uint64(len(epoch.entries)) >= st.limits.MaxKeysPerEpoch {
+		return ErrTooManySkippedMessageKeys
+	}
+
+	for st.keysCount >= st.limits.MaxKeys {
+		st.evictKey()
+	}
+
+	// Note that the epoch is removed if the eviction deletes its last key.
+	if _, exists = st.mapping[st.serializeHeaderKey(headerKey)]; !exists {
+		epoch = st.addEpoch(headerKey)
+	}
+
+	epoch.addEntry(messageNumber, entry)
+	st.keysCount++
+
+	return nil
 }
 
-func (st defaultSkippedKeysStorage) clear() {
-	clear(st.mapping)
+func (st *DefaultSkippedKeysStorage) addEpoch(headerKey keys.Header) *skippedKeysEpoch {
+	epoch := &skippedKeysEpoch{
+		headerKey: headerKey.Clone(),
+		order:     list.New(),
+		entries:   make(map[uint64]skippedKeyEntry),
+	}
+
+	st.epochs = append(st.epochs, epoch)
+	st.mapping[st.serializeHeaderKey(headerKey)] = epoch
+
+	return epoch
 }
 
-func (defaultSkippedKeysStorage) serializeHeaderKey(key keys.Header) string {
-	ser := string(key.Bytes)
+// evictEpoch evicts all keys of the oldest epoch.
+func (st *DefaultSkippedKeysStorage) evictEpoch() {
+	epoch := st.epochs[0]
 
-	return ser
+	for element := epoch.order.Front(); element != nil; element = element.Next() {
+		st.reportEviction(epoch.headerKey, element.Value.(uint64))
+	}
+
+	st.keysCount -= uint64(len(epoch.entries))
+	st.removeEpoch(epoch)
+}
+
+// evictKey evicts the oldest key of the oldest epoch. The epoch is removed if the key is
+// the last one.
+func (st *DefaultSkippedKeysStorage) evictKey() {
+	epoch := st.epochs[0]
+	messageNumber := epoch.oldestMessageNumber()
+
+	epoch.deleteEntry(messageNumber)
+	st.keysCount--
+	st.reportEviction(epoch.headerKey, messageNumber)
+
+	if len(epoch.entries) == 0 {
+		st.removeEpoch(epoch)
+	}
+}
+
+// removeEpoch removes passed epoch and wipes its keys. The epochs are copied to a new slice,
+// so the iteration over the old one is not affected.
+func (st *DefaultSkippedKeysStorage) removeEpoch(epoch *skippedKeysEpoch) {
+	index := slices.Index(st.epochs, epoch)
+	st.epochs = slices.Concat(st.epochs[:index], st.epochs[index+1:])
+
+	delete(st.mapping, st.serializeHeaderKey(epoch.headerKey))
+	epoch.wipe()
+}
+
+func (st *DefaultSkippedKeysStorage) isExpired(createdAt time.Time, now time.Time) bool {
+	return st.maxAge != 0 && now.Sub(createdAt) >= st.maxAge
+}
+
+// now returns the current time without monotonic clock reading, so it can be compared
+// with restored times.
+func (st *DefaultSkippedKeysStorage) now() time.Time {
+	if st.clock == nil {
+		return time.Now().Round(0)
+	}
+
+	return st.clock().Round(0)
 }
 
-func (defaultSkippedKeysStorage) deserializeHeaderKey(value string) keys.Header {
-	headerKey := keys.Header{
-		Bytes: []byte(value),
+func (st *DefaultSkippedKeysStorage) reportEviction(headerKey keys.Header, messageNumber uint64) {
+	if st.onEvict != nil {
+		st.onEvict(headerKey.Clone(), messageNumber)
 	}
+}
+
+func (*DefaultSkippedKeysStorage) serializeHeaderKey(key keys.Header) string {
+	ser := string(key.Bytes)
 
-	return headerKey
+	return ser
 }
 
-func (st defaultSkippedKeysStorage) getHeaderKeysCount() int {
-	count := len(st.mapping)
+func (st *DefaultSkippedKeysStorage) getHeaderKeysCount() uint64 {
+	count := uint64(len(st.epochs))
 
 	return count
 }
 
-func (st defaultSkippedKeysStorage) getMessageKeysCount(headerKey keys.Header) int {
-	serHeaderKey := st.serializeHeaderKey(headerKey)
-	count := len(st.mapping[serHeaderKey])
+func (st *DefaultSkippedKeysStorage) getMessageKeysCount(headerKey keys.Header) uint64 {
+	epoch, exists := st.mapping[st.serializeHeaderKey(headerKey)]
+	if !exists {
+		return 0
+	}
+
+	count := uint64(len(epoch.entries))
 
 	return count
 }
+
+// skippedKeysEpoch is the set of skipped keys sharing the same header key. The entries are
+// indexed by the message number, and the order list keeps the message numbers in insertion
+// order, so a key is deleted in constant time.
+type skippedKeysEpoch struct {
+	headerKey keys.Header
+	order     *list.List
+	entries   map[uint64]skippedKeyEntry
+}
+
+func (epoch *skippedKeysEpoch) clone() *skippedKeysEpoch {
+	epochClone := &skippedKeysEpoch{
+		headerKey: epoch.headerKey.Clone(),
+		order:     list.New(),
+		entries:   make(map[uint64]skippedKeyEntry, len(epoch.entries)),
+	}
+
+	for element := epoch.order.Front(); element != nil; element = element.Next() {
+		messageNumber := element.Value.(uint64)
+
+		entry := epoch.entries[messageNumber]
+		entry.messageKey = entry.messageKey.Clone()
+		epochClone.addEntry(messageNumber, entry)
+	}
+
+	return epochClone
+}
+
+// addEntry adds passed entry as the newest one.
+func (epoch *skippedKeysEpoch) addEntry(messageNumber uint64, entry skippedKeyEntry) {
+	entry.element = epoch.order.PushBack(messageNumber)
+	epoch.entries[messageNumber] = entry
+}
+
+// deleteEntry wipes the message key of the entry and deletes it.
+func (epoch *skippedKeysEpoch) deleteEntry(messageNumber uint64) {
+	entry := epoch.entries[messageNumber]
+	entry.messageKey.Wipe()
+	epoch.order.Remove(entry.element)
+	delete(epoch.entries, messageNumber)
+}
+
+// oldestMessageNumber returns the message number of the oldest entry. The epoch must not be
+// empty.
+func (epoch *skippedKeysEpoch) oldestMessageNumber() uint64 {
+	return epoch.order.Front().Value.(uint64)
+}
+
+// wipe wipes the header key and all message keys of the epoch.
+func (epoch *skippedKeysEpoch) wipe() {
+	epoch.headerKey.Wipe()
+
+	for messageNumber := range epoch.entries {
+		epoch.deleteEntry(messageNumber)
+	}
+}
+
+type skippedKeyEntry struct {
+	messageKey keys.Message
+	createdAt  time.Time
+	element    *list.Element
+}
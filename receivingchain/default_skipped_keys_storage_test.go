@@ -2,22 +2,83 @@ package receivingchain
 
 import (
 	"errors"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/platform-source/aegis/keys"
 )
 
-func TestDefaultSkippedKeysStorageAddClear(t *testing.T) {
+func newTestDefaultSkippedKeysStorage(
+	t *testing.T,
+	limits SkippedKeysLimits,
+) *DefaultSkippedKeysStorage {
+	t.Helper()
+
+	storage, err := NewDefaultSkippedKeysStorage(limits)
+	if err != nil {
+		t.Fatalf("NewDefaultSkippedKeysStorage(%+v): expected no error but got %v", limits, err)
+	}
+
+	return storage
+}
+
+func TestNewDefaultSkippedKeysStorageZeroLimit(t *testing.T) {
+	t.Parallel()
+
+	limits := DefaultSkippedKeysLimits()
+	limits.MaxKeys = 0
+
+	_, err := NewDefaultSkippedKeysStorage(limits)
+	if !errors.Is(err, ErrSkippedKeysLimitIsZero) {
+		t.Fatalf(
+			"NewDefaultSkippedKeysStorage(%+v): expected zero limit error but got %v",
+			limits,
+			err,
+		)
+	}
+}
+
+type evictedSkippedKey struct {
+	headerKey     string
+	messageNumber uint64
+}
+
+func newTestEvictingSkippedKeysStorage(
+	t *testing.T,
+	limits SkippedKeysLimits,
+	options ...DefaultSkippedKeysStorageOption,
+) (*DefaultSkippedKeysStorage, *[]evictedSkippedKey) {
+	t.Helper()
+
+	var evicted []evictedSkippedKey
+
+	options = append(
+		options,
+		WithEvictionCallback(func(headerKey keys.Header, messageNumber uint64) {
+			evicted = append(evicted, evictedSkippedKey{string(headerKey.Bytes), messageNumber})
+		}),
+	)
+
+	storage, err := NewDefaultSkippedKeysStorage(limits, options...)
+	if err != nil {
+		t.Fatalf("NewDefaultSkippedKeysStorage(%+v): expected no error but got %v", limits, err)
+	}
+
+	return storage, &evicted
+}
+
+func TestDefaultSkippedKeysStorageAddEvictOldestEpoch(t *testing.T) {
 	t.Parallel()
 
-	storage := newDefaultSkippedKeysStorage()
+	storage, evicted := newTestEvictingSkippedKeysStorage(t, DefaultSkippedKeysLimits())
 
-	for headerNumber := range defaultSkippedKeysStorageHeaderKeysCountToClear {
+	for headerNumber := range defaultSkippedKeysStorageMaxEpochs {
 		err := storage.Add(
 			keys.Header{
 				Bytes: make([]byte, headerNumber),
 			},
-			0,
+			uint64(headerNumber),
 			keys.Message{},
 		)
 		if err != nil {
@@ -25,8 +86,8 @@ func TestDefaultSkippedKeysStorageAddClear(t *testing.T) {
 		}
 	}
 
-	if storage.getHeaderKeysCount() != defaultSkippedKeysStorageHeaderKeysCountToClear {
-		t.Fatal("Add(): early clear")
+	if storage.getHeaderKeysCount() != defaultSkippedKeysStorageMaxEpochs || len(*evicted) != 0 {
+		t.Fatal("Add(): early eviction")
 	}
 
 	err := storage.Add(
@@ -40,17 +101,93 @@ func TestDefaultSkippedKeysStorageAddClear(t *testing.T) {
 		t.Fatalf("Add(1, 2, 3): expected no error but got %+v", err)
 	}
 
-	if storage.getHeaderKeysCount() != 1 {
-		t.Fatalf("Add(): expected clear but length is %d", storage.getHeaderKeysCount())
+	if storage.getHeaderKeysCount() != defaultSkippedKeysStorageMaxEpochs {
+		t.Fatalf("Add(): expected eviction but length is %d", storage.getHeaderKeysCount())
+	}
+
+	if storage.getMessageKeysCount(keys.Header{}) != 0 ||
+		storage.getMessageKeysCount(keys.Header{Bytes: make([]byte, 1)}) != 1 {
+		t.Fatal("Add(): expected eviction of the oldest epoch only")
+	}
+
+	expectedEvicted := []evictedSkippedKey{{"", 0}}
+	if !reflect.DeepEqual(*evicted, expectedEvicted) {
+		t.Fatalf("Add(): expected evicted keys %+v but got %+v", expectedEvicted, *evicted)
+	}
+}
+
+func TestDefaultSkippedKeysStorageAddEvictOldestKey(t *testing.T) {
+	t.Parallel()
+
+	storage, evicted := newTestEvictingSkippedKeysStorage(t, SkippedKeysLimits{
+		MaxEpochs:       2,
+		MaxKeysPerEpoch: 3,
+		MaxKeys:         4,
+	})
+
+	for _, messageNumber := range []uint64{5, 3, 4} {
+		err := storage.Add(keys.Header{}, messageNumber, keys.Message{})
+		if err != nil {
+			t.Fatalf("Add(%d): expected no error but got %+v", messageNumber, err)
+		}
+	}
+
+	for messageNumber := range uint64(2) {
+		err := storage.Add(keys.Header{Bytes: []byte{1}}, messageNumber, keys.Message{})
+		if err != nil {
+			t.Fatalf("Add(1, %d): expected no error but got %+v", messageNumber, err)
+		}
+	}
+
+	err := storage.Delete(keys.Header{}, 3)
+	if err != nil {
+		t.Fatalf("Delete(3): expected no error but got %+v", err)
+	}
+
+	err = storage.Add(keys.Header{Bytes: []byte{1}}, 2, keys.Message{})
+	if err != nil {
+		t.Fatalf("Add(1, 2): expected no error but got %+v", err)
+	}
+
+	err = storage.Add(keys.Header{Bytes: []byte{1}}, 3, keys.Message{})
+	if !errors.Is(err, ErrTooManySkippedMessageKeys) {
+		t.Fatalf("Add(1, 3): expected too many message keys error but got %+v", err)
+	}
+
+	expectedEvicted := []evictedSkippedKey{{"", 5}}
+	if !reflect.DeepEqual(*evicted, expectedEvicted) {
+		t.Fatalf("Add(): expected evicted keys %+v but got %+v", expectedEvicted, *evicted)
+	}
+
+	var messageNumbers []uint64
+
+	iter, err := storage.GetIter()
+	if err != nil {
+		t.Fatalf("GetIter(): expected no error but got %+v", err)
+	}
+
+	for _, messageNumberKeys := range iter {
+		for messageNumber := range messageNumberKeys {
+			messageNumbers = append(messageNumbers, messageNumber)
+		}
+	}
+
+	expectedMessageNumbers := []uint64{4, 0, 1, 2}
+	if !reflect.DeepEqual(messageNumbers, expectedMessageNumbers) {
+		t.Fatalf(
+			"GetIter(): expected insertion order %v but got %v",
+			expectedMessageNumbers,
+			messageNumbers,
+		)
 	}
 }
 
 func TestDefaultSkippedKeysStorageAddTooManyMessageKeys(t *testing.T) {
 	t.Parallel()
 
-	storage := newDefaultSkippedKeysStorage()
+	storage := newTestDefaultSkippedKeysStorage(t, DefaultSkippedKeysLimits())
 
-	for messageNumber := range defaultSkippedKeysStorageMessageKeysCountLimit {
+	for messageNumber := range defaultSkippedKeysStorageMaxKeysPerEpoch {
 		err := storage.Add(keys.Header{}, uint64(messageNumber), keys.Message{})
 		if err != nil {
 			t.Fatalf("Add(%d): expected no error but got %+v", messageNumber, err)
@@ -59,13 +196,13 @@ func TestDefaultSkippedKeysStorageAddTooManyMessageKeys(t *testing.T) {
 
 	err := storage.Add(
 		keys.Header{},
-		defaultSkippedKeysStorageMessageKeysCountLimit,
+		defaultSkippedKeysStorageMaxKeysPerEpoch,
 		keys.Message{},
 	)
 	if !errors.Is(err, ErrTooManySkippedMessageKeys) {
 		t.Fatalf(
 			"Add(%d): expected error too many message keys error but got %+v",
-			defaultSkippedKeysStorageMessageKeysCountLimit,
+			defaultSkippedKeysStorageMaxKeysPerEpoch,
 			err,
 		)
 	}
@@ -77,7 +214,7 @@ func TestDefaultSkippedKeysStorageDelete(t *testing.T) {
 	headerKey := keys.Header{Bytes: []byte{1, 2, 3}}
 	messageNumber := uint64(456)
 
-	storage := newDefaultSkippedKeysStorage()
+	storage := newTestDefaultSkippedKeysStorage(t, DefaultSkippedKeysLimits())
 
 	err := storage.Add(headerKey, messageNumber, keys.Message{})
 	if err != nil {
@@ -116,26 +253,168 @@ func TestDefaultSkippedKeysStorageDelete(t *testing.T) {
 		t.Fatalf("Delete(): expected no error but got %+v", err)
 	}
 
-	if storage.getHeaderKeysCount() != 1 || storage.getMessageKeysCount(headerKey) != 0 {
+	// Note that the epoch is removed with its last key.
+	if storage.getHeaderKeysCount() != 0 || storage.getMessageKeysCount(headerKey) != 0 {
 		t.Fatalf(
-			"Delete(): expected delete but len is %d:%d",
+			"Delete(): expected delete of the epoch but len is %d:%d",
 			storage.getHeaderKeysCount(),
 			storage.getMessageKeysCount(headerKey),
 		)
 	}
 }
 
+func TestDefaultSkippedKeysStorageDeleteLastKeyOfEpoch(t *testing.T) {
+	t.Parallel()
+
+	storage, evicted := newTestEvictingSkippedKeysStorage(t, SkippedKeysLimits{
+		MaxEpochs:       2,
+		MaxKeysPerEpoch: 2,
+		MaxKeys:         4,
+	})
+
+	for headerKeyByte := range byte(2) {
+		err := storage.Add(keys.Header{Bytes: []byte{headerKeyByte}}, 0, keys.Message{})
+		if err != nil {
+			t.Fatalf("Add(%d, 0): expected no error but got %+v", headerKeyByte, err)
+		}
+	}
+
+	err := storage.Delete(keys.Header{Bytes: []byte{0}}, 0)
+	if err != nil {
+		t.Fatalf("Delete(0, 0): expected no error but got %+v", err)
+	}
+
+	// Note that the consumed epoch is removed, so it does not count toward the max epochs.
+	err = storage.Add(keys.Header{Bytes: []byte{2}}, 0, keys.Message{})
+	if err != nil {
+		t.Fatalf("Add(2, 0): expected no error but got %+v", err)
+	}
+
+	if len(*evicted) != 0 || storage.getHeaderKeysCount() != 2 {
+		t.Fatalf(
+			"Add(): expected 2 epochs without evictions but got %d epochs and evicted %+v",
+			storage.getHeaderKeysCount(),
+			*evicted,
+		)
+	}
+}
+
+func TestDefaultSkippedKeysStorageAddEvictLastKeyOfSameEpoch(t *testing.T) {
+	t.Parallel()
+
+	storage, evicted := newTestEvictingSkippedKeysStorage(t, SkippedKeysLimits{
+		MaxEpochs:       1,
+		MaxKeysPerEpoch: 2,
+		MaxKeys:         1,
+	})
+
+	for messageNumber := range uint64(2) {
+		err := storage.Add(keys.Header{}, messageNumber, keys.Message{Bytes: []byte{1}})
+		if err != nil {
+			t.Fatalf("Add(%d): expected no error but got %+v", messageNumber, err)
+		}
+	}
+
+	expectedEvicted := []evictedSkippedKey{{"", 0}}
+	if !reflect.DeepEqual(*evicted, expectedEvicted) {
+		t.Fatalf("Add(): expected evicted keys %+v but got %+v", expectedEvicted, *evicted)
+	}
+
+	_, exists, err := storage.Get(keys.Header{}, 1)
+	if err != nil || !exists {
+		t.Fatalf("Get(1): expected stored key but got %t and %+v", exists, err)
+	}
+}
+
+func TestDefaultSkippedKeysStorageGet(t *testing.T) {
+	t.Parallel()
+
+	headerKey := keys.Header{Bytes: []byte{1, 2, 3}}
+	messageKey := keys.Message{Bytes: []byte{4, 5, 6}}
+
+	storage := newTestDefaultSkippedKeysStorage(t, DefaultSkippedKeysLimits())
+
+	err := storage.Add(headerKey, 7, messageKey)
+	if err != nil {
+		t.Fatalf("Add(): expected no error but got %+v", err)
+	}
+
+	tests := []struct {
+		name          string
+		headerKey     keys.Header
+		messageNumber uint64
+		expectedKey   []byte
+	}{
+		{"found", headerKey, 7, messageKey.Bytes},
+		{"other message number", headerKey, 8, nil},
+		{"other header key", keys.Header{Bytes: []byte{1, 2}}, 7, nil},
+	}
+
+	for _, test := range tests {
+		gotKey, exists, err := storage.Get(test.headerKey, test.messageNumber)
+		if err != nil {
+			t.Fatalf("Get(%s): expected no error but got %+v", test.name, err)
+		}
+
+		if exists != (test.expectedKey != nil) ||
+			!reflect.DeepEqual(gotKey.Bytes, test.expectedKey) {
+			t.Fatalf(
+				"Get(%s): expected key %v but got %v",
+				test.name,
+				test.expectedKey,
+				gotKey.Bytes,
+			)
+		}
+	}
+}
+
+func TestDefaultSkippedKeysStorageWipe(t *testing.T) {
+	t.Parallel()
+
+	headerKey := keys.Header{Bytes: []byte{1, 2, 3}}
+	deletedKey := keys.Message{Bytes: []byte{4, 5, 6}}
+	storedKey := keys.Message{Bytes: []byte{7, 8, 9}}
+
+	storage := newTestDefaultSkippedKeysStorage(t, DefaultSkippedKeysLimits())
+
+	for messageNumber, messageKey := range []keys.Message{deletedKey, storedKey} {
+		err := storage.Add(headerKey, uint64(messageNumber), messageKey)
+		if err != nil {
+			t.Fatalf("Add(): expected no error but got %+v", err)
+		}
+	}
+
+	err := storage.Delete(headerKey, 0)
+	if err != nil {
+		t.Fatalf("Delete(): expected no error but got %+v", err)
+	}
+
+	if !reflect.DeepEqual(deletedKey.Bytes, []byte{0, 0, 0}) {
+		t.Fatalf("Delete(): expected deleted key to be wiped but got %v", deletedKey.Bytes)
+	}
+
+	storage.Wipe()
+
+	if !reflect.DeepEqual(storedKey.Bytes, []byte{0, 0, 0}) {
+		t.Fatalf("Wipe(): expected stored key to be wiped but got %v", storedKey.Bytes)
+	}
+
+	if storage.getHeaderKeysCount() != 0 || storage.keysCount != 0 {
+		t.Fatalf("Wipe(): expected empty storage but got %d keys", storage.keysCount)
+	}
+}
+
 func TestDefaultSkippedKeysStorageGetIter(t *testing.T) {
 	t.Parallel()
 
-	storage := newDefaultSkippedKeysStorage()
-	iters := make(map[byte]map[uint64]byte, defaultSkippedKeysStorageHeaderKeysCountToClear)
+	storage := newTestDefaultSkippedKeysStorage(t, DefaultSkippedKeysLimits())
+	iters := make(map[byte]map[uint64]byte, defaultSkippedKeysStorageMaxEpochs)
 
-	for headerKeyByteInt := range defaultSkippedKeysStorageHeaderKeysCountToClear / 2 {
+	for headerKeyByteInt := range defaultSkippedKeysStorageMaxEpochs / 2 {
 		headerKeyByte := byte(headerKeyByteInt)
 		headerKey := keys.Header{Bytes: []byte{headerKeyByte}}
 
-		for messageNumber := range defaultSkippedKeysStorageMessageKeysCountLimit {
+		for messageNumber := range defaultSkippedKeysStorageMaxKeysPerEpoch {
 			if messageNumber%2 == 1 {
 				continue
 			}
@@ -156,7 +435,7 @@ func TestDefaultSkippedKeysStorageGetIter(t *testing.T) {
 			if _, exists := iters[headerKeyByte]; !exists {
 				iters[headerKeyByte] = make(
 					map[uint64]byte,
-					defaultSkippedKeysStorageMessageKeysCountLimit/2,
+					defaultSkippedKeysStorageMaxKeysPerEpoch/2,
 				)
 			}
 
@@ -223,3 +502,72 @@ func TestDefaultSkippedKeysStorageGetIter(t *testing.T) {
 		)
 	}
 }
+
+func TestDefaultSkippedKeysStorageMaxAge(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+
+	storage, evicted := newTestEvictingSkippedKeysStorage(
+		t,
+		DefaultSkippedKeysLimits(),
+		WithClock(func() time.Time { return now }),
+		WithMaxAge(time.Hour),
+	)
+
+	addTestKey := func(headerKeyByte byte, messageNumber uint64) {
+		headerKey := keys.Header{Bytes: []byte{headerKeyByte}}
+
+		err := storage.Add(headerKey, messageNumber, keys.Message{})
+		if err != nil {
+			t.Fatalf("Add(%d, %d): expected no error but got %+v", headerKey, messageNumber, err)
+		}
+	}
+
+	addTestKey(1, 0)
+	now = now.Add(30 * time.Minute)
+	addTestKey(1, 1)
+	addTestKey(2, 0)
+
+	now = now.Add(40 * time.Minute)
+	addTestKey(3, 0)
+
+	expectedEvicted := []evictedSkippedKey{{"\x01", 0}}
+	if !reflect.DeepEqual(*evicted, expectedEvicted) {
+		t.Fatalf("Add(): expected evicted keys %+v but got %+v", expectedEvicted, *evicted)
+	}
+
+	now = now.Add(30 * time.Minute)
+
+	_, err := storage.GetIter()
+	if err != nil {
+		t.Fatalf("GetIter(): expected no error but got %+v", err)
+	}
+
+	expectedEvicted = append(
+		expectedEvicted,
+		evictedSkippedKey{"\x01", 1},
+		evictedSkippedKey{"\x02", 0},
+	)
+	if !reflect.DeepEqual(*evicted, expectedEvicted) {
+		t.Fatalf("GetIter(): expected evicted keys %+v but got %+v", expectedEvicted, *evicted)
+	}
+
+	if storage.getHeaderKeysCount() != 1 || storage.keysCount != 1 {
+		t.Fatalf(
+			"GetIter(): expected 1 epoch and 1 key but got %d and %d",
+			storage.getHeaderKeysCount(),
+			storage.keysCount,
+		)
+	}
+
+	storage.Prune(now.Add(time.Hour))
+
+	if storage.getHeaderKeysCount() != 0 || storage.keysCount != 0 {
+		t.Fatalf(
+			"Prune(): expected no keys but got %d epochs and %d keys",
+			storage.getHeaderKeysCount(),
+			storage.keysCount,
+		)
+	}
+}
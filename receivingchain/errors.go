@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/platform-source/aegis/header"
 	cipher "golang.org/x/crypto/chacha20poly1305"
 )
 
@@ -14,9 +15,21 @@ var (
 	// ErrAdvanceChain is chain advance error.
 	ErrAdvanceChain = errors.New("advance chain")
 
+	// ErrAllocatorIsNil is the nil allocator error.
+	ErrAllocatorIsNil = errors.New("allocator is nil")
+
 	// ErrApplyOptions is the config options apply error.
 	ErrApplyOptions = errors.New("apply options")
 
+	// ErrBuildBytes is the bytes building error.
+	ErrBuildBytes = errors.New("build bytes")
+
+	// ErrClockIsNil is the nil clock error.
+	ErrClockIsNil = errors.New("clock is nil")
+
+	// ErrCommitSkippedKeys is the staged skipped keys commit error.
+	ErrCommitSkippedKeys = errors.New("commit skipped keys")
+
 	// ErrCryptoAdvanceChain is chain advance error from crypto provider.
 	ErrCryptoAdvanceChain = errors.New("crypto advance chain")
 
@@ -50,6 +63,16 @@ var (
 	// ErrDeriveMessageCipherKeyAndNonce is the message cipher key and nonce derivation error.
 	ErrDeriveMessageCipherKeyAndNonce = errors.New("derive message cipher key and nonce")
 
+	// ErrDuplicateMessage is an error when the message is already consumed and found in
+	// the replay window.
+	ErrDuplicateMessage = errors.New("duplicate message")
+
+	// ErrEvictionCallbackIsNil is the nil eviction callback error.
+	ErrEvictionCallbackIsNil = errors.New("eviction callback is nil")
+
+	// ErrGetSkippedKey is the skipped key lookup error.
+	ErrGetSkippedKey = errors.New("get skipped key")
+
 	// ErrGetSkippedKeysStorageIter is the skipped keys storage iterator obtaining error.
 	ErrGetSkippedKeysStorageIter = errors.New("get skipped keys storage iter")
 
@@ -59,9 +82,32 @@ var (
 	// ErrHeaderKeyIsNil is the nil header key error.
 	ErrHeaderKeyIsNil = errors.New("header key is nil")
 
+	// ErrInvalidAEAD is an error when the AEAD suite is not valid.
+	ErrInvalidAEAD = errors.New("invalid AEAD")
+
+	// ErrInvalidEncoding is an error when encoded state is malformed.
+	ErrInvalidEncoding = errors.New("invalid encoding")
+
+	// ErrInvalidMaxAge is the non-positive max age error.
+	ErrInvalidMaxAge = errors.New("invalid max age")
+
 	// ErrMasterKeyIsNil is the nil master key error.
 	ErrMasterKeyIsNil = errors.New("master key is nil")
 
+	// ErrMessageKeyEvicted is an error when the key of an earlier message is not found and
+	// the message is not in the replay window. The key is evicted or expired, or the message
+	// is consumed before the window.
+	ErrMessageKeyEvicted = errors.New("message key evicted")
+
+	// ErrMixedCrypto is an error when the suite crypto is combined with other crypto options.
+	ErrMixedCrypto = errors.New("mixed crypto")
+
+	// ErrNewChain is the chain initialization error.
+	ErrNewChain = errors.New("new chain")
+
+	// ErrNewDefaultSkippedKeysStorage is the default skipped keys storage creation error.
+	ErrNewDefaultSkippedKeysStorage = errors.New("new default skipped keys storage")
+
 	// ErrNotEnoughEncryptedHeaderBytes is the not enough encrypted header bytes.
 	ErrNotEnoughEncryptedHeaderBytes = fmt.Errorf(
 		"encrypted header too shot, expected at least %d bytes",
@@ -77,12 +123,21 @@ var (
 	// ErrNewHasher is the hasher initialization error.
 	ErrNewHasher = errors.New("new hasher")
 
+	// ErrNotNextMessage is an error when the message is not the next message of the current chain.
+	ErrNotNextMessage = errors.New("not next message")
+
 	// ErrOpenCipher is the cipher opening error.
 	ErrOpenCipher = errors.New("open cipher")
 
+	// ErrOpenMessage is the message opening error.
+	ErrOpenMessage = errors.New("open message")
+
 	// ErrRatchet is the ratchet callback error.
 	ErrRatchet = errors.New("ratchet")
 
+	// ErrSkippedKeysLimitIsZero is the zero skipped keys limit error.
+	ErrSkippedKeysLimitIsZero = errors.New("skipped keys limit is zero")
+
 	// ErrSkippedKeysNotFound is an error when skipped keys not found.
 	ErrSkippedKeysNotFound = errors.New("skipped keys not found")
 
@@ -98,9 +153,112 @@ var (
 	// ErrTooManySkippedMessageKeys is an error when there are too many skipped message keys.
 	ErrTooManySkippedMessageKeys = errors.New("too many skipped message keys")
 
+	// ErrTooManySkippedMessages is the error of too many messages skipped by one received message.
+	ErrTooManySkippedMessages = errors.New("too many skipped messages")
+
+	// ErrUnknownEpoch is an error when the header is not decrypted with any known header key.
+	ErrUnknownEpoch = errors.New("unknown epoch")
+
+	// ErrUnpad is the padding removal error.
+	ErrUnpad = errors.New("unpad")
+
+	// ErrUnsupportedVersion is an error when encoded state has unknown version.
+	ErrUnsupportedVersion = errors.New("unsupported version")
+
 	// ErrWriteMasterKeyByteToMAC is the master key byte write error.
 	ErrWriteMasterKeyByteToMAC = errors.New("write master key byte to MAC")
 
 	// ErrWriteMessageKeyByteToMAC is the message key byte write error.
 	ErrWriteMessageKeyByteToMAC = errors.New("write message key byte to MAC")
 )
+
+// TooManySkippedMessagesError is the error of a received message, which skips more
+// messages than allowed. It matches ErrTooManySkippedMessages.
+type TooManySkippedMessagesError struct {
+	// Gap is the requested count of skipped messages.
+	Gap uint64
+
+	// MaxSkip is the maximum allowed count of skipped messages.
+	MaxSkip uint64
+}
+
+// Error implements error interface.
+func (err *TooManySkippedMessagesError) Error() string {
+	return fmt.Sprintf("%v: gap %d exceeds %d", ErrTooManySkippedMessages, err.Gap, err.MaxSkip)
+}
+
+// Is reports whether the error matches target.
+func (*TooManySkippedMessagesError) Is(target error) bool {
+	return target == ErrTooManySkippedMessages
+}
+
+// DecryptError is the error of the failed decryption. It wraps the errors of the decryption
+// with skipped keys and with chain keys, so errors.Is works with the errors of the chain.
+type DecryptError struct {
+	// Stage is the furthest stage reached by the decryption.
+	Stage DecryptStage
+
+	// Header is the decrypted header if it is authenticated with a header key. It is nil
+	// otherwise, including the plaintext header mode, where only the message authenticates
+	// the header.
+	Header *header.Header
+
+	// Err is the decryption error.
+	Err error
+}
+
+// NewDecryptError wraps passed error of Decrypt, or the joined errors of the decryption
+// with skipped keys and with chain keys. ErrUnknownEpoch is added if no header key decrypts
+// the header.
+func NewDecryptError(err error) *DecryptError {
+	var decryptErr *DecryptError
+	if errors.As(err, &decryptErr) {
+		return decryptErr
+	}
+
+	decryptErr = &DecryptError{
+		Stage: DecryptStageOf(err),
+		Err:   err,
+	}
+
+	var headerErr *authenticatedHeaderError
+	if errors.As(err, &headerErr) {
+		decryptErr.Header = &headerErr.header
+	}
+
+	if decryptErr.Stage == DecryptStageHeader &&
+		decryptErr.Header == nil &&
+		!errors.Is(err, ErrDecodeHeader) &&
+		!errors.Is(err, ErrGetSkippedKeysStorageIter) {
+		decryptErr.Err = errors.Join(ErrUnknownEpoch, err)
+	}
+
+	return decryptErr
+}
+
+// Error implements error interface.
+func (err *DecryptError) Error() string {
+	return fmt.Sprintf("%v at %v stage: %v", ErrDecrypt, err.Stage, err.Err)
+}
+
+// Unwrap returns the decryption error.
+func (err *DecryptError) Unwrap() error {
+	return err.Err
+}
+
+// authenticatedHeaderError is the error of the decryption after the header is authenticated
+// with a header key. It carries the header for DecryptError.
+type authenticatedHeaderError struct {
+	header header.Header
+	err    error
+}
+
+// Error implements error interface.
+func (err *authenticatedHeaderError) Error() string {
+	return err.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (err *authenticatedHeaderError) Unwrap() error {
+	return err.err
+}
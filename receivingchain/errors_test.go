@@ -0,0 +1,88 @@
+package receivingchain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/platform-source/aegis/header"
+	"github.com/platform-source/tools/convert"
+)
+
+func TestNewDecryptError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                  string
+		err                   error
+		expectedStage         DecryptStage
+		expectedUnknownEpoch  bool
+		expectedMessageNumber *uint64
+	}{
+		{
+			"unknown epoch",
+			errors.Join(ErrSkippedKeysNotFound, ErrDecryptHeaderWithCurrentOrNextKey),
+			DecryptStageHeader,
+			true,
+			nil,
+		},
+		{
+			"malformed plaintext header",
+			errors.Join(ErrDecodeHeader, ErrDecryptHeaderWithCurrentOrNextKey),
+			DecryptStageHeader,
+			false,
+			nil,
+		},
+		{
+			"authenticated header",
+			errors.Join(
+				ErrDecryptHeaderWithCurrentOrNextKey,
+				&authenticatedHeaderError{
+					header: header.Header{MessageNumber: 5},
+					err:    ErrDuplicateMessage,
+				},
+			),
+			DecryptStageMessage,
+			false,
+			convert.ToPtr[uint64](5),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := NewDecryptError(test.err)
+			if err.Stage != test.expectedStage {
+				t.Fatalf(
+					"NewDecryptError(): expected stage %v but got %v",
+					test.expectedStage,
+					err.Stage,
+				)
+			}
+
+			if errors.Is(err, ErrUnknownEpoch) != test.expectedUnknownEpoch {
+				t.Fatalf("NewDecryptError(): unexpected unknown epoch error %v", err)
+			}
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("NewDecryptError(): expected to wrap %v but got %v", test.err, err)
+			}
+
+			if test.expectedMessageNumber == nil {
+				if err.Header != nil {
+					t.Fatalf("NewDecryptError(): expected no header but got %+v", err.Header)
+				}
+
+				return
+			}
+
+			if err.Header == nil || err.Header.MessageNumber != *test.expectedMessageNumber {
+				t.Fatalf(
+					"NewDecryptError(): expected message number %d but got header %+v",
+					*test.expectedMessageNumber,
+					err.Header,
+				)
+			}
+		})
+	}
+}
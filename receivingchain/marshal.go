@@ -0,0 +1,363 @@
+package receivingchain
+
+import (
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/tools/convert"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	marshalVersion = 1
+
+	marshalListEnd  = 0
+	marshalListItem = 1
+)
+
+// timedSkippedKeysStorage is the skipped keys storage, which records creation time
+// of the keys. The creation time is preserved by MarshalBinary and Unmarshal.
+type timedSkippedKeysStorage interface {
+	AddCreatedAt(
+		headerKey keys.Header,
+		messageNumber uint64,
+		messageKey keys.Message,
+		createdAt time.Time,
+	) error
+	CreatedAt(headerKey keys.Header, messageNumber uint64) (time.Time, bool)
+}
+
+// MarshalBinary encodes the receiving chain state including all skipped keys to bytes.
+//
+// Note that the config is not encoded, so the same options must be passed to Unmarshal.
+func (ch Chain) MarshalBinary() ([]byte, error) {
+	iter, err := ch.cfg.skippedKeysStorage.GetIter()
+	if err != nil {
+		return nil, errors.Join(ErrGetSkippedKeysStorageIter, err)
+	}
+
+	timedStorage, _ := ch.cfg.skippedKeysStorage.(timedSkippedKeysStorage)
+
+	builder := cryptobyte.NewBuilder(nil)
+	builder.AddUint8(marshalVersion)
+
+	if ch.masterKey == nil {
+		builder.AddUint8(0)
+	} else {
+		builder.AddUint8(1)
+		builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+			builder.AddBytes(ch.masterKey.Bytes)
+		})
+	}
+
+	if ch.headerKey == nil {
+		builder.AddUint8(0)
+	} else {
+		builder.AddUint8(1)
+		builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+			builder.AddBytes(ch.headerKey.Bytes)
+		})
+	}
+
+	builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(ch.nextHeaderKey.Bytes)
+	})
+	builder.AddUint64(ch.nextMessageNumber)
+
+	for headerKey, messageNumberKeys := range iter {
+		builder.AddUint8(marshalListItem)
+		builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+			builder.AddBytes(headerKey.Bytes)
+		})
+
+		for messageNumber, messageKey := range messageNumberKeys {
+			builder.AddUint8(marshalListItem)
+			builder.AddUint64(messageNumber)
+			builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+				builder.AddBytes(messageKey.Bytes)
+			})
+			builder.AddUint64(marshalCreatedAt(timedStorage, headerKey, messageNumber))
+		}
+
+		builder.AddUint8(marshalListEnd)
+	}
+
+	builder.AddUint8(marshalListEnd)
+	ch.marshalReplayWindow(builder)
+
+	data, err := builder.Bytes()
+	if err != nil {
+		return nil, errors.Join(ErrBuildBytes, err)
+	}
+
+	return data, nil
+}
+
+// Unmarshal decodes the receiving chain state from bytes produced by MarshalBinary and
+// applies passed options to it. Skipped keys are added to the configured storage.
+func Unmarshal(data []byte, options ...Option) (Chain, error) {
+	input := cryptobyte.String(data)
+
+	var version uint8
+	if !input.ReadUint8(&version) {
+		return Chain{}, ErrInvalidEncoding
+	}
+
+	if version != marshalVersion {
+		return Chain{}, ErrUnsupportedVersion
+	}
+
+	var (
+		masterKey          *keys.Master
+		headerKey          *keys.Header
+		masterKeyPresent   uint8
+		headerKeyPresent   uint8
+		keyBytes           cryptobyte.String
+		nextHeaderKeyBytes cryptobyte.String
+		nextMessageNumber  uint64
+	)
+
+	if !input.ReadUint8(&masterKeyPresent) {
+		return Chain{}, ErrInvalidEncoding
+	}
+
+	if masterKeyPresent != 0 {
+		if !input.ReadUint16LengthPrefixed(&keyBytes) {
+			return Chain{}, ErrInvalidEncoding
+		}
+
+		masterKey = convert.ToPtr(keys.Master{Bytes: keyBytes}.Clone())
+	}
+
+	if !input.ReadUint8(&headerKeyPresent) {
+		return Chain{}, ErrInvalidEncoding
+	}
+
+	if headerKeyPresent != 0 {
+		if !input.ReadUint16LengthPrefixed(&keyBytes) {
+			return Chain{}, ErrInvalidEncoding
+		}
+
+		headerKey = convert.ToPtr(keys.Header{Bytes: keyBytes}.Clone())
+	}
+
+	if !input.ReadUint16LengthPrefixed(&nextHeaderKeyBytes) ||
+		!input.ReadUint64(&nextMessageNumber) {
+		return Chain{}, ErrInvalidEncoding
+	}
+
+	chain, err := New(
+		masterKey,
+		headerKey,
+		keys.Header{Bytes: nextHeaderKeyBytes}.Clone(),
+		nextMessageNumber,
+		options...,
+	)
+	if err != nil {
+		return Chain{}, errors.Join(ErrNewChain, err)
+	}
+
+	err = chain.unmarshalSkippedKeys(&input)
+	if err != nil {
+		return Chain{}, err
+	}
+
+	err = chain.unmarshalReplayWindow(&input)
+	if err != nil {
+		return Chain{}, err
+	}
+
+	if !input.Empty() {
+		return Chain{}, ErrInvalidEncoding
+	}
+
+	return chain, nil
+}
+
+func (ch *Chain) unmarshalSkippedKeys(input *cryptobyte.String) error {
+	for {
+		var (
+			marker         uint8
+			headerKeyBytes cryptobyte.String
+		)
+
+		if !input.ReadUint8(&marker) {
+			return ErrInvalidEncoding
+		}
+
+		if marker == marshalListEnd {
+			return nil
+		}
+
+		if !input.ReadUint16LengthPrefixed(&headerKeyBytes) {
+			return ErrInvalidEncoding
+		}
+
+		headerKey := keys.Header{Bytes: headerKeyBytes}.Clone()
+
+		for {
+			var (
+				messageNumber   uint64
+				messageKeyBytes cryptobyte.String
+				createdAt       uint64
+			)
+
+			if !input.ReadUint8(&marker) {
+				return ErrInvalidEncoding
+			}
+
+			if marker == marshalListEnd {
+				break
+			}
+
+			if !input.ReadUint64(&messageNumber) ||
+				!input.ReadUint16LengthPrefixed(&messageKeyBytes) ||
+				!input.ReadUint64(&createdAt) {
+				return ErrInvalidEncoding
+			}
+
+			messageKey := keys.Message{Bytes: messageKeyBytes}.Clone()
+
+			err := ch.addSkippedKey(headerKey, messageNumber, messageKey, createdAt)
+			if err != nil {
+				return errors.Join(ErrAddSkippedKey, err)
+			}
+		}
+	}
+}
+
+// unmarshalReplayWindow decodes the public keys and the consumed messages of the replay
+// window. The messages are added to the window of the chain, so its size is applied to them.
+func (ch *Chain) unmarshalReplayWindow(input *cryptobyte.String) error {
+	var publicKeys []keys.Public
+
+	for {
+		var (
+			marker         uint8
+			publicKeyBytes cryptobyte.String
+		)
+
+		if !input.ReadUint8(&marker) {
+			return ErrInvalidEncoding
+		}
+
+		if marker == marshalListEnd {
+			break
+		}
+
+		if !input.ReadUint16LengthPrefixed(&publicKeyBytes) {
+			return ErrInvalidEncoding
+		}
+
+		publicKeys = append(publicKeys, keys.Public{Bytes: publicKeyBytes})
+	}
+
+	for {
+		var (
+			marker                uint8
+			epochIndex            uint32
+			digest                [sha256.Size]byte
+			messageNumber         uint64
+			previousMessagesCount uint64
+		)
+
+		if !input.ReadUint8(&marker) {
+			return ErrInvalidEncoding
+		}
+
+		if marker == marshalListEnd {
+			return nil
+		}
+
+		if !input.ReadUint32(&epochIndex) ||
+			!input.CopyBytes(digest[:]) ||
+			!input.ReadUint64(&messageNumber) ||
+			!input.ReadUint64(&previousMessagesCount) ||
+			epochIndex >= uint32(len(publicKeys)) {
+			return ErrInvalidEncoding
+		}
+
+		if ch.replays != nil {
+			ch.replays.addDigest(
+				digest,
+				publicKeys[epochIndex],
+				messageNumber,
+				previousMessagesCount,
+			)
+		}
+	}
+}
+
+// addSkippedKey adds passed key to the storage. The creation time is passed to the storage
+// if it is known and the storage records it.
+func (ch *Chain) addSkippedKey(
+	headerKey keys.Header,
+	messageNumber uint64,
+	messageKey keys.Message,
+	createdAt uint64,
+) error {
+	timedStorage, ok := ch.cfg.skippedKeysStorage.(timedSkippedKeysStorage)
+	if !ok || createdAt == 0 {
+		return ch.cfg.skippedKeysStorage.Add(headerKey, messageNumber, messageKey)
+	}
+
+	return timedStorage.AddCreatedAt(
+		headerKey,
+		messageNumber,
+		messageKey,
+		time.Unix(0, int64(createdAt)),
+	)
+}
+
+// marshalReplayWindow encodes the public keys and the consumed messages of the replay window
+// from the oldest message. The messages refer to the public keys by their indexes.
+func (ch Chain) marshalReplayWindow(builder *cryptobyte.Builder) {
+	messages := ch.replays.ordered()
+	epochIndexes := make(map[*replayEpoch]uint32)
+
+	for _, message := range messages {
+		if _, ok := epochIndexes[message.epoch]; ok {
+			continue
+		}
+
+		epochIndexes[message.epoch] = uint32(len(epochIndexes))
+
+		builder.AddUint8(marshalListItem)
+		builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+			builder.AddBytes(message.epoch.publicKey.Bytes)
+		})
+	}
+
+	builder.AddUint8(marshalListEnd)
+
+	for _, message := range messages {
+		builder.AddUint8(marshalListItem)
+		builder.AddUint32(epochIndexes[message.epoch])
+		builder.AddBytes(message.digest[:])
+		builder.AddUint64(message.messageNumber)
+		builder.AddUint64(message.previousMessagesCount)
+	}
+
+	builder.AddUint8(marshalListEnd)
+}
+
+// marshalCreatedAt returns the creation time of the key in Unix nanoseconds or 0 if
+// it is unknown.
+func marshalCreatedAt(
+	timedStorage timedSkippedKeysStorage,
+	headerKey keys.Header,
+	messageNumber uint64,
+) uint64 {
+	if timedStorage == nil {
+		return 0
+	}
+
+	createdAt, ok := timedStorage.CreatedAt(headerKey, messageNumber)
+	if !ok || createdAt.UnixNano() <= 0 {
+		return 0
+	}
+
+	return uint64(createdAt.UnixNano())
+}
@@ -0,0 +1,231 @@
+package receivingchain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/platform-source/aegis/header"
+	"github.com/platform-source/aegis/keys"
+)
+
+func TestChainMarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	chain, err := New(
+		&keys.Master{Bytes: []byte{1, 2, 3}},
+		&keys.Header{Bytes: []byte{4, 5, 6}},
+		keys.Header{Bytes: []byte{7, 8, 9}},
+		12,
+	)
+	if err != nil {
+		t.Fatalf("New(): expected no error but got %v", err)
+	}
+
+	skippedKeys := map[string]map[uint64]keys.Message{
+		string([]byte{4, 5, 6}): {
+			3: keys.Message{Bytes: []byte{10}},
+			5: keys.Message{Bytes: []byte{11}},
+		},
+		string([]byte{1, 1, 1}): {
+			0: keys.Message{Bytes: []byte{12}},
+		},
+	}
+
+	for headerKey, messageNumberKeys := range skippedKeys {
+		for messageNumber, messageKey := range messageNumberKeys {
+			err = chain.cfg.skippedKeysStorage.Add(
+				keys.Header{Bytes: []byte(headerKey)},
+				messageNumber,
+				messageKey,
+			)
+			if err != nil {
+				t.Fatalf("Add(): expected no error but got %v", err)
+			}
+		}
+	}
+
+	chain.replays.add(
+		[]byte{13},
+		header.Header{PublicKey: keys.Public{Bytes: []byte{1, 1, 1}}, MessageNumber: 1},
+	)
+	chain.replays.add(
+		[]byte{14},
+		header.Header{
+			PublicKey:                         keys.Public{Bytes: []byte{4, 5, 6}},
+			PreviousSendingChainMessagesCount: 3,
+			MessageNumber:                     4,
+		},
+	)
+	chain.replays.add(
+		[]byte{15},
+		header.Header{PublicKey: keys.Public{Bytes: []byte{1, 1, 1}}, MessageNumber: 2},
+	)
+
+	data, err := chain.MarshalBinary()
+	if err != nil {
+		t.Fatalf("%+v.MarshalBinary(): expected no error but got %v", chain, err)
+	}
+
+	restored, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal(%v): expected no error but got %v", data, err)
+	}
+
+	if !reflect.DeepEqual(restored, chain) {
+		t.Fatalf("Unmarshal(%v): expected %+v but got %+v", data, chain, restored)
+	}
+}
+
+var unmarshalTests = []struct {
+	name          string
+	data          []byte
+	options       []Option
+	errCategories []error
+}{
+	{
+		"nil data",
+		nil,
+		nil,
+		[]error{
+			ErrInvalidEncoding,
+		},
+	},
+	{
+		"unsupported version",
+		[]byte{0xFF},
+		nil,
+		[]error{
+			ErrUnsupportedVersion,
+		},
+	},
+	{
+		"missing skipped keys",
+		[]byte{
+			marshalVersion, 0x00, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		},
+		nil,
+		[]error{
+			ErrInvalidEncoding,
+		},
+	},
+	{
+		"missing replay window",
+		[]byte{
+			marshalVersion, 0x00, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			marshalListEnd,
+		},
+		nil,
+		[]error{
+			ErrInvalidEncoding,
+		},
+	},
+	{
+		"unknown replay window epoch",
+		[]byte{
+			marshalVersion, 0x00, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			marshalListEnd,
+			marshalListEnd,
+			marshalListItem, 0x00, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			marshalListEnd,
+		},
+		nil,
+		[]error{
+			ErrInvalidEncoding,
+		},
+	},
+	{
+		"nil skipped keys storage",
+		[]byte{
+			marshalVersion, 0x00, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			marshalListEnd,
+		},
+		[]Option{
+			WithSkippedKeysStorage(nil),
+		},
+		[]error{
+			ErrNewChain,
+			ErrSkippedKeysStorageIsNil,
+		},
+	},
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range unmarshalTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Unmarshal(test.data, test.options...)
+
+			for _, errCategory := range test.errCategories {
+				if !errors.Is(err, errCategory) {
+					t.Fatalf(
+						"Unmarshal(%v): expected error %v but got %v",
+						test.data,
+						errCategory,
+						err,
+					)
+				}
+			}
+		})
+	}
+}
+
+func TestChainMarshalBinaryKeepsCreatedAt(t *testing.T) {
+	t.Parallel()
+
+	createdAt := time.Unix(1000, 0)
+	now := createdAt.Add(time.Hour)
+	options := []Option{
+		WithSkippedKeysClock(func() time.Time { return now }),
+		WithSkippedKeysMaxAge(2 * time.Hour),
+	}
+
+	chain, err := New(nil, nil, keys.Header{}, 0, options...)
+	if err != nil {
+		t.Fatalf("New(): expected no error but got %v", err)
+	}
+
+	storage, ok := chain.cfg.skippedKeysStorage.(*DefaultSkippedKeysStorage)
+	if !ok {
+		t.Fatalf("New(): expected default skipped keys storage but got %T", storage)
+	}
+
+	err = storage.AddCreatedAt(keys.Header{Bytes: []byte{1}}, 2, keys.Message{}, createdAt)
+	if err != nil {
+		t.Fatalf("AddCreatedAt(): expected no error but got %v", err)
+	}
+
+	data, err := chain.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary(): expected no error but got %v", err)
+	}
+
+	restored, err := Unmarshal(data, options...)
+	if err != nil {
+		t.Fatalf("Unmarshal(%v): expected no error but got %v", data, err)
+	}
+
+	restoredStorage, ok := restored.cfg.skippedKeysStorage.(*DefaultSkippedKeysStorage)
+	if !ok {
+		t.Fatalf("Unmarshal(): expected default skipped keys storage but got %T", restoredStorage)
+	}
+
+	restoredCreatedAt, ok := restoredStorage.CreatedAt(keys.Header{Bytes: []byte{1}}, 2)
+	if !ok || !restoredCreatedAt.Equal(createdAt) {
+		t.Fatalf("Unmarshal(): expected creation time %v but got %v", createdAt, restoredCreatedAt)
+	}
+}
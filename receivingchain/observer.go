@@ -0,0 +1,189 @@
+package receivingchain
+
+import (
+	"errors"
+)
+
+// Observer receives the events of the chain, e.g. to log them or to collect metrics. Nil
+// callbacks are skipped. The callbacks are called synchronously, so they must be fast and
+// must not call the chain.
+//
+// Please note that the events carry no keys, so they are safe to log.
+type Observer struct {
+	// OnDecryptFailed is called when Decrypt fails.
+	OnDecryptFailed func(event DecryptFailedEvent)
+
+	// OnHeaderDecrypted is called when the header is decrypted with the current or the next
+	// header key. The message itself may fail to decrypt after that.
+	OnHeaderDecrypted func(event HeaderDecryptedEvent)
+
+	// OnSkippedKey is called when a skipped key is stored, consumed or evicted. Evictions
+	// are reported only by the default storage.
+	OnSkippedKey func(event SkippedKeyEvent)
+}
+
+// DecryptFailedEvent is the event of the failed decryption.
+type DecryptFailedEvent struct {
+	// Stage is the furthest stage reached by the decryption.
+	Stage DecryptStage
+
+	// Err is the decryption error.
+	Err error
+}
+
+// HeaderDecryptedEvent is the event of the decrypted header.
+type HeaderDecryptedEvent struct {
+	// MessageNumber is the message number of the header.
+	MessageNumber uint64
+
+	// NextHeaderKey reports whether the header is decrypted with the next header key, so
+	// the message starts a new epoch. In the plaintext header mode it reports whether the
+	// public key of the header is new.
+	NextHeaderKey bool
+}
+
+// SkippedKeyEvent is the event of the skipped key.
+type SkippedKeyEvent struct {
+	// Kind is the kind of the event.
+	Kind SkippedKeyEventKind
+
+	// MessageNumber is the message number of the skipped key.
+	MessageNumber uint64
+}
+
+// SkippedKeyEventKind is the kind of the skipped key event.
+type SkippedKeyEventKind uint8
+
+const (
+	// SkippedKeyStored is the kind of the event of the key added to the storage.
+	SkippedKeyStored SkippedKeyEventKind = iota + 1
+
+	// SkippedKeyConsumed is the kind of the event of the key used to decrypt its message.
+	SkippedKeyConsumed
+
+	// SkippedKeyEvicted is the kind of the event of the key evicted by the storage limits or
+	// the max age.
+	SkippedKeyEvicted
+)
+
+// String returns the name of the kind.
+func (kind SkippedKeyEventKind) String() string {
+	switch kind {
+	case SkippedKeyStored:
+		return "stored"
+	case SkippedKeyConsumed:
+		return "consumed"
+	case SkippedKeyEvicted:
+		return "evicted"
+	default:
+		return "unknown"
+	}
+}
+
+// DecryptStage is the stage of the decryption. The stages are ordered, so the later stage
+// means the decryption went further.
+type DecryptStage uint8
+
+const (
+	// DecryptStageUnknown is the stage of the errors, which are not produced by the chain.
+	DecryptStageUnknown DecryptStage = iota
+
+	// DecryptStageHeader is the stage of the header, which is not decrypted with any key.
+	DecryptStageHeader
+
+	// DecryptStageSkipKeys is the stage of the keys skipped by the message.
+	DecryptStageSkipKeys
+
+	// DecryptStageRatchet is the stage of the Diffie-Hellman ratchet of the new epoch.
+	DecryptStageRatchet
+
+	// DecryptStageMessage is the stage of the message, which is not authenticated with its
+	// message key, or whose message key is already used or evicted.
+	DecryptStageMessage
+
+	// DecryptStageCommit is the stage of the skipped keys storage update.
+	DecryptStageCommit
+)
+
+// String returns the name of the stage.
+func (stage DecryptStage) String() string {
+	switch stage {
+	case DecryptStageHeader:
+		return "header"
+	case DecryptStageSkipKeys:
+		return "skip keys"
+	case DecryptStageRatchet:
+		return "ratchet"
+	case DecryptStageMessage:
+		return "message"
+	case DecryptStageCommit:
+		return "commit"
+	default:
+		return "unknown"
+	}
+}
+
+// decryptStageErrors are the errors of the decryption stages from the last stage.
+var decryptStageErrors = []struct {
+	stage DecryptStage
+	errs  []error
+}{
+	{DecryptStageCommit, []error{ErrCommitSkippedKeys, ErrDeleteSkippedKeys}},
+	{
+		DecryptStageMessage,
+		[]error{
+			ErrDuplicateMessage,
+			ErrMessageKeyEvicted,
+			ErrOpenMessage,
+			ErrDecryptMessage,
+			ErrUnpad,
+		},
+	},
+	{DecryptStageRatchet, []error{ErrRatchet}},
+	{
+		DecryptStageSkipKeys,
+		[]error{ErrTooManySkippedMessages, ErrSkipPreviousChainKeys, ErrSkipCurrentChainKeys},
+	},
+	{DecryptStageHeader, []error{ErrUnknownEpoch, ErrDecryptHeaderWithCurrentOrNextKey}},
+}
+
+// DecryptStageOf returns the furthest stage of passed decryption error. The errors of both
+// decryption with the skipped keys and with the chain keys may be joined, so a message
+// found by a skipped key, but not authenticated, is reported at the message stage.
+func DecryptStageOf(err error) DecryptStage {
+	var decryptErr *DecryptError
+	if errors.As(err, &decryptErr) {
+		return decryptErr.Stage
+	}
+
+	for _, stageErrors := range decryptStageErrors {
+		for _, stageErr := range stageErrors.errs {
+			if errors.Is(err, stageErr) {
+				return stageErrors.stage
+			}
+		}
+	}
+
+	return DecryptStageUnknown
+}
+
+func (o Observer) decryptFailed(err *DecryptError) {
+	if o.OnDecryptFailed != nil {
+		o.OnDecryptFailed(DecryptFailedEvent{Stage: err.Stage, Err: err})
+	}
+}
+
+func (o Observer) headerDecrypted(messageNumber uint64, nextHeaderKey bool) {
+	if o.OnHeaderDecrypted != nil {
+		o.OnHeaderDecrypted(HeaderDecryptedEvent{
+			MessageNumber: messageNumber,
+			NextHeaderKey: nextHeaderKey,
+		})
+	}
+}
+
+func (o Observer) skippedKey(kind SkippedKeyEventKind, messageNumber uint64) {
+	if o.OnSkippedKey != nil {
+		o.OnSkippedKey(SkippedKeyEvent{Kind: kind, MessageNumber: messageNumber})
+	}
+}
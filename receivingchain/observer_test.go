@@ -0,0 +1,63 @@
+package receivingchain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecryptStageOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		err           error
+		expectedStage DecryptStage
+		expectedName  string
+	}{
+		{"unknown", errors.New("other"), DecryptStageUnknown, "unknown"},
+		{
+			"header",
+			errors.Join(ErrSkippedKeysNotFound, ErrDecryptHeaderWithCurrentOrNextKey),
+			DecryptStageHeader,
+			"header",
+		},
+		{"skip keys", &TooManySkippedMessagesError{Gap: 2}, DecryptStageSkipKeys, "skip keys"},
+		{
+			"ratchet",
+			errors.Join(ErrHandleEncryptedHeader, ErrRatchet),
+			DecryptStageRatchet,
+			"ratchet",
+		},
+		{
+			"skipped key message",
+			errors.Join(ErrOpenMessage, ErrDecryptHeaderWithCurrentOrNextKey),
+			DecryptStageMessage,
+			"message",
+		},
+		{"commit", errors.Join(ErrCommitSkippedKeys), DecryptStageCommit, "commit"},
+		{"duplicate message", ErrDuplicateMessage, DecryptStageMessage, "message"},
+		{"evicted message key", ErrMessageKeyEvicted, DecryptStageMessage, "message"},
+		{"unknown epoch", ErrUnknownEpoch, DecryptStageHeader, "header"},
+		{
+			"decrypt error",
+			errors.Join(ErrAddSkippedKey, &DecryptError{Stage: DecryptStageRatchet}),
+			DecryptStageRatchet,
+			"ratchet",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			stage := DecryptStageOf(test.err)
+			if stage != test.expectedStage {
+				t.Fatalf("DecryptStageOf(): expected %v but got %v", test.expectedStage, stage)
+			}
+
+			if stage.String() != test.expectedName {
+				t.Fatalf("String(): expected %q but got %q", test.expectedName, stage.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package receivingchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"slices"
+
+	"github.com/platform-source/aegis/header"
+	"github.com/platform-source/aegis/keys"
+)
+
+const defaultReplayWindowSize = 256
+
+// replayWindow is the bounded window of the recently consumed messages, which recognizes
+// the replays of the messages, whose keys are already deleted. The oldest message is
+// forgotten when the window is full.
+//
+// A message is identified by the SHA-256 digest of its encrypted header, so no header keys
+// are kept. The public key of the epoch, the message number and the previous messages count
+// are kept to report the header of the replay. The public key is shared by the messages of
+// the epoch and dropped with the last of them.
+type replayWindow struct {
+	size     uint64
+	epochs   []*replayEpoch
+	messages []consumedMessage
+	next     int
+}
+
+type replayEpoch struct {
+	publicKey keys.Public
+	messages  int
+}
+
+type consumedMessage struct {
+	epoch                 *replayEpoch
+	digest                [sha256.Size]byte
+	messageNumber         uint64
+	previousMessagesCount uint64
+}
+
+func newReplayWindow(size uint64) *replayWindow {
+	if size == 0 {
+		return nil
+	}
+
+	return &replayWindow{size: size}
+}
+
+// add adds the consumed message with passed encrypted header and decrypted header. The public
+// key is cloned only for a new epoch, so the messages of the current epoch are added without
+// allocations.
+func (w *replayWindow) add(encryptedHeader []byte, head header.Header) {
+	if w == nil {
+		return
+	}
+
+	w.addDigest(
+		sha256.Sum256(encryptedHeader),
+		head.PublicKey,
+		head.MessageNumber,
+		head.PreviousSendingChainMessagesCount,
+	)
+}
+
+func (w *replayWindow) addDigest(
+	digest [sha256.Size]byte,
+	publicKey keys.Public,
+	messageNumber uint64,
+	previousMessagesCount uint64,
+) {
+	if w.messages == nil {
+		w.messages = make([]consumedMessage, 0, w.size)
+	}
+
+	epoch := w.findEpoch(publicKey.Bytes)
+	if epoch == nil {
+		epoch = &replayEpoch{publicKey: publicKey.Clone()}
+		w.epochs = append(w.epochs, epoch)
+	}
+
+	epoch.messages++
+	message := consumedMessage{
+		epoch:                 epoch,
+		digest:                digest,
+		messageNumber:         messageNumber,
+		previousMessagesCount: previousMessagesCount,
+	}
+
+	if uint64(len(w.messages)) < w.size {
+		w.messages = append(w.messages, message)
+
+		return
+	}
+
+	w.forget(w.messages[w.next].epoch)
+	w.messages[w.next] = message
+	w.next = (w.next + 1) % len(w.messages)
+}
+
+func (w *replayWindow) clone() *replayWindow {
+	if w == nil {
+		return nil
+	}
+
+	epochs := make(map[*replayEpoch]*replayEpoch, len(w.epochs))
+	clone := &replayWindow{
+		size:     w.size,
+		epochs:   make([]*replayEpoch, 0, len(w.epochs)),
+		messages: slices.Clone(w.messages),
+		next:     w.next,
+	}
+
+	for _, epoch := range w.epochs {
+		epochs[epoch] = &replayEpoch{publicKey: epoch.publicKey.Clone(), messages: epoch.messages}
+		clone.epochs = append(clone.epochs, epochs[epoch])
+	}
+
+	for i := range clone.messages {
+		clone.messages[i].epoch = epochs[clone.messages[i].epoch]
+	}
+
+	return clone
+}
+
+// find returns the header of the consumed message with passed encrypted header and reports
+// whether the message is found. The header has no KEM fields.
+func (w *replayWindow) find(encryptedHeader []byte) (header.Header, bool) {
+	if w == nil {
+		return header.Header{}, false
+	}
+
+	digest := sha256.Sum256(encryptedHeader)
+
+	for _, message := range w.messages {
+		if message.digest != digest {
+			continue
+		}
+
+		return header.Header{
+			PublicKey:                         message.epoch.publicKey.Clone(),
+			PreviousSendingChainMessagesCount: message.previousMessagesCount,
+			MessageNumber:                     message.messageNumber,
+		}, true
+	}
+
+	return header.Header{}, false
+}
+
+// ordered returns the consumed messages from the oldest one.
+func (w *replayWindow) ordered() []consumedMessage {
+	if w == nil {
+		return nil
+	}
+
+	return append(slices.Clone(w.messages[w.next:]), w.messages[:w.next]...)
+}
+
+func (w *replayWindow) wipe() {
+	if w == nil {
+		return
+	}
+
+	w.epochs = nil
+	w.messages = nil
+	w.next = 0
+}
+
+// findEpoch returns the epoch with passed public key. The latest epochs are checked first,
+// because most messages belong to the current epoch.
+func (w *replayWindow) findEpoch(publicKey []byte) *replayEpoch {
+	for i := len(w.epochs) - 1; i >= 0; i-- {
+		if bytes.Equal(w.epochs[i].publicKey.Bytes, publicKey) {
+			return w.epochs[i]
+		}
+	}
+
+	return nil
+}
+
+// forget forgets the message of passed epoch and drops the epoch without messages.
+func (w *replayWindow) forget(epoch *replayEpoch) {
+	epoch.messages--
+	if epoch.messages > 0 {
+		return
+	}
+
+	w.epochs = slices.DeleteFunc(w.epochs, func(other *replayEpoch) bool {
+		return other == epoch
+	})
+}
@@ -0,0 +1,90 @@
+package receivingchain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/platform-source/aegis/header"
+	"github.com/platform-source/aegis/keys"
+)
+
+func TestReplayWindow(t *testing.T) {
+	t.Parallel()
+
+	firstPublicKey := keys.Public{Bytes: []byte{1}}
+	secondPublicKey := keys.Public{Bytes: []byte{2}}
+
+	window := newReplayWindow(3)
+	window.add([]byte{1, 0}, header.Header{PublicKey: firstPublicKey})
+	window.add([]byte{2, 0}, header.Header{PublicKey: secondPublicKey})
+	window.add(
+		[]byte{2, 1},
+		header.Header{
+			PublicKey:                         secondPublicKey,
+			PreviousSendingChainMessagesCount: 4,
+			MessageNumber:                     1,
+		},
+	)
+
+	clone := window.clone()
+
+	window.add([]byte{2, 2}, header.Header{PublicKey: secondPublicKey, MessageNumber: 2})
+
+	if len(window.epochs) != 1 {
+		t.Fatalf("add(): expected only the second epoch but got %d epochs", len(window.epochs))
+	}
+
+	tests := []struct {
+		name            string
+		window          *replayWindow
+		encryptedHeader []byte
+		expectedHeader  *header.Header
+	}{
+		{"forgotten message", window, []byte{1, 0}, nil},
+		{
+			"consumed message",
+			window,
+			[]byte{2, 1},
+			&header.Header{
+				PublicKey:                         secondPublicKey,
+				PreviousSendingChainMessagesCount: 4,
+				MessageNumber:                     1,
+			},
+		},
+		{
+			"latest message",
+			window,
+			[]byte{2, 2},
+			&header.Header{PublicKey: secondPublicKey, MessageNumber: 2},
+		},
+		{"unknown message", window, []byte{2, 3}, nil},
+		{"cloned message", clone, []byte{1, 0}, &header.Header{PublicKey: firstPublicKey}},
+		{"message added after clone", clone, []byte{2, 2}, nil},
+		{"disabled window", newReplayWindow(0), []byte{1, 0}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			head, found := test.window.find(test.encryptedHeader)
+			if found != (test.expectedHeader != nil) {
+				t.Fatalf(
+					"find(%v): expected found %t but got %t",
+					test.encryptedHeader,
+					test.expectedHeader != nil,
+					found,
+				)
+			}
+
+			if test.expectedHeader != nil && !reflect.DeepEqual(head, *test.expectedHeader) {
+				t.Fatalf(
+					"find(%v): expected header %+v but got %+v",
+					test.encryptedHeader,
+					*test.expectedHeader,
+					head,
+				)
+			}
+		})
+	}
+}
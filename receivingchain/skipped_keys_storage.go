@@ -21,6 +21,8 @@ type (
 	SkippedMessageNumberKeysYield func(number uint64, key keys.Message) bool
 
 	// SkippedKeysStorage is the storage of skipped keys of the receiving chain.
+	//
+	// The storage may also have the Wipe method, which is called when the chain is wiped.
 	SkippedKeysStorage interface {
 		// Add must add new skipped keys to storage.
 		Add(headerKey keys.Header, messageNumber uint64, messageKey keys.Message) error
@@ -28,10 +30,20 @@ type (
 		// Clone must deep clone a storage.
 		Clone() SkippedKeysStorage
 
-		// Delete must delete skipped keys by header key and message number.
+		// Delete must delete skipped keys by header key and message number. The deleted
+		// message key should be wiped.
 		Delete(headerKey keys.Header, messageNumber uint64) error
 
 		// GetIter must return function, which iterates over all skipped keys.
 		GetIter() (SkippedKeysIter, error)
 	}
+
+	// SkippedKeysGetter is the optional interface of the skipped keys storage, which finds
+	// the key without iteration. The chain uses it in the plaintext header mode, where the
+	// header key of the epoch is the public key of the remote sending chain.
+	SkippedKeysGetter interface {
+		// Get must return the skipped key by header key and message number and report
+		// whether it exists.
+		Get(headerKey keys.Header, messageNumber uint64) (keys.Message, bool, error)
+	}
 )
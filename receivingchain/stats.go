@@ -0,0 +1,51 @@
+package receivingchain
+
+// Stats are the statistics of the receiving chain.
+type Stats struct {
+	// Active reports whether the chain has the master key, so it can decrypt the messages of
+	// the current epoch.
+	Active bool
+
+	// NextMessageNumber is the number of the next message of the current epoch.
+	NextMessageNumber uint64
+
+	// SkippedKeys is the count of the keys in the skipped keys storage.
+	SkippedKeys uint64
+
+	// SkippedKeysPerEpoch are the counts of the skipped keys of each epoch in the order of
+	// the storage iteration, which is the insertion order for the default storage.
+	SkippedKeysPerEpoch []uint64
+
+	// SkippedKeysErr is the error of the skipped keys storage iteration, so the skipped keys
+	// are not counted.
+	SkippedKeysErr error
+}
+
+// Stats returns the statistics of the chain. The skipped keys are counted with the iteration
+// over the storage, so the default storage purges expired keys before.
+func (ch Chain) Stats() Stats {
+	stats := Stats{
+		Active:            ch.masterKey != nil,
+		NextMessageNumber: ch.nextMessageNumber,
+	}
+
+	iter, err := ch.cfg.skippedKeysStorage.GetIter()
+	if err != nil {
+		stats.SkippedKeysErr = err
+
+		return stats
+	}
+
+	for _, messageNumberKeys := range iter {
+		var epochKeys uint64
+
+		for range messageNumberKeys {
+			epochKeys++
+		}
+
+		stats.SkippedKeys += epochKeys
+		stats.SkippedKeysPerEpoch = append(stats.SkippedKeysPerEpoch, epochKeys)
+	}
+
+	return stats
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/tools/slices"
 )
 
 // Chain is the ratchet root chain.
@@ -14,39 +15,67 @@ type Chain struct {
 
 // New creates a new root chain.
 func New(rootKey keys.Root, options ...Option) (Chain, error) {
-	chain := Chain{
-		rootKey: rootKey,
-	}
-
-	var err error
-
-	chain.cfg, err = newConfig(options...)
+	cfg, err := newConfig(options...)
 	if err != nil {
 		return Chain{}, errors.Join(ErrNewConfig, err)
 	}
 
+	chain := Chain{
+		cfg: cfg,
+	}
+	chain.rootKey = chain.protectRootKey(rootKey)
+
 	return chain, nil
 }
 
-// Advance advances root chain and creates a new master key and next header key.
+// Advance advances root chain and creates a new master key and next header key. The old
+// root key is wiped.
 func (ch *Chain) Advance(sharedKey keys.Shared) (keys.Master, keys.Header, error) {
-	var (
-		masterKey     keys.Master
-		nextHeaderKey keys.Header
-		err           error
-	)
-
-	ch.rootKey, masterKey, nextHeaderKey, err = ch.cfg.crypto.AdvanceChain(ch.rootKey, sharedKey)
+	rootKey, masterKey, nextHeaderKey, err := ch.cfg.crypto.AdvanceChain(ch.rootKey, sharedKey)
 	if err != nil {
 		return keys.Master{}, keys.Header{}, errors.Join(ErrAdvanceChain, err)
 	}
 
+	ch.rootKey.Wipe()
+	ch.rootKey = ch.protectRootKey(rootKey)
+
 	return masterKey, nextHeaderKey, nil
 }
 
+// AdvanceWithKEM advances root chain as Advance does, but mixes passed KEM shared
+// key into the key derivation along with the Diffie-Hellman shared key.
+func (ch *Chain) AdvanceWithKEM(
+	sharedKey keys.Shared,
+	kemSharedKey keys.Shared,
+) (keys.Master, keys.Header, error) {
+	hybridSharedKey := keys.Shared{
+		Bytes: slices.ConcatBytes(sharedKey.Bytes, kemSharedKey.Bytes),
+	}
+	defer hybridSharedKey.Wipe()
+
+	return ch.Advance(hybridSharedKey)
+}
+
 // Clone clones a root chain.
 func (ch Chain) Clone() Chain {
 	ch.rootKey = ch.rootKey.Clone()
 
 	return ch
 }
+
+// Wipe wipes the root key. The chain must not be used after the call.
+func (ch *Chain) Wipe() {
+	ch.rootKey.Wipe()
+}
+
+// protectRootKey moves passed root key into the memory of the allocator if it is set.
+func (ch *Chain) protectRootKey(rootKey keys.Root) keys.Root {
+	if ch.cfg.allocator == nil {
+		return rootKey
+	}
+
+	protectedRootKey := rootKey.CloneTo(ch.cfg.allocator)
+	rootKey.Wipe()
+
+	return protectedRootKey
+}
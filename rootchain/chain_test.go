@@ -187,3 +187,65 @@ func TestChainClone(t *testing.T) {
 		})
 	}
 }
+
+func TestChainAdvanceWithKEM(t *testing.T) {
+	t.Parallel()
+
+	rootKey := keys.Root{
+		Bytes: []byte{1, 2, 3, 4, 5},
+	}
+	sharedKey := keys.Shared{
+		Bytes: []byte{6, 7, 8},
+	}
+
+	classicChain, err := New(rootKey.Clone())
+	if err != nil {
+		t.Fatalf("New(): expected no error but got %v", err)
+	}
+
+	hybridChain := classicChain.Clone()
+
+	classicMasterKey, _, err := classicChain.Advance(sharedKey)
+	if err != nil {
+		t.Fatalf("Advance(): expected no error but got %v", err)
+	}
+
+	hybridMasterKey, _, err := hybridChain.AdvanceWithKEM(sharedKey, keys.Shared{Bytes: []byte{9}})
+	if err != nil {
+		t.Fatalf("AdvanceWithKEM(): expected no error but got %v", err)
+	}
+
+	if reflect.DeepEqual(classicMasterKey, hybridMasterKey) {
+		t.Fatal("AdvanceWithKEM(): KEM shared key did not affect master key")
+	}
+
+	if reflect.DeepEqual(classicChain.rootKey, hybridChain.rootKey) {
+		t.Fatal("AdvanceWithKEM(): KEM shared key did not affect root key")
+	}
+}
+
+func TestChainAdvanceWipesRootKey(t *testing.T) {
+	t.Parallel()
+
+	rootKey := keys.Root{Bytes: []byte{1, 2, 3}}
+
+	chain, err := New(rootKey)
+	if err != nil {
+		t.Fatalf("New(): expected no error but got %v", err)
+	}
+
+	_, _, err = chain.Advance(keys.Shared{Bytes: []byte{4, 5, 6}})
+	if err != nil {
+		t.Fatalf("Advance(): expected no error but got %v", err)
+	}
+
+	if !reflect.DeepEqual(rootKey.Bytes, []byte{0, 0, 0}) {
+		t.Fatalf("Advance(): expected old root key to be wiped but got %v", rootKey.Bytes)
+	}
+
+	chain.Wipe()
+
+	if chain.rootKey.Bytes != nil {
+		t.Fatalf("Wipe(): expected root key to be wiped but got %v", chain.rootKey.Bytes)
+	}
+}
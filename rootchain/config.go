@@ -3,16 +3,21 @@ package rootchain
 import (
 	"errors"
 
+	"github.com/platform-source/aegis/aead"
+	"github.com/platform-source/aegis/keys"
 	"github.com/platform-source/tools/check"
 )
 
 type config struct {
-	crypto Crypto
+	allocator   keys.Allocator
+	crypto      Crypto
+	cryptoSet   bool
+	suiteCrypto Crypto
 }
 
 func newConfig(options ...Option) (config, error) {
 	cfg := config{
-		crypto: newDefaultCrypto(),
+		crypto: newDefaultCrypto(aead.XChaCha20Poly1305()),
 	}
 
 	err := cfg.applyOptions(options...)
@@ -20,6 +25,14 @@ func newConfig(options ...Option) (config, error) {
 		return config{}, errors.Join(ErrApplyOptions, err)
 	}
 
+	if cfg.suiteCrypto != nil {
+		if cfg.cryptoSet {
+			return config{}, errors.Join(ErrApplyOptions, ErrMixedCrypto)
+		}
+
+		cfg.crypto = cfg.suiteCrypto
+	}
+
 	return cfg, nil
 }
 
@@ -37,6 +50,33 @@ func (cfg *config) applyOptions(options ...Option) error {
 // Option is the way to modify config default values.
 type Option func(cfg *config) error
 
+// WithAEAD is an option to set the default crypto with passed AEAD suite.
+func WithAEAD(suite aead.Suite) Option {
+	return func(cfg *config) error {
+		if !suite.Valid() {
+			return ErrInvalidAEAD
+		}
+
+		cfg.crypto = newDefaultCrypto(suite)
+		cfg.cryptoSet = true
+
+		return nil
+	}
+}
+
+// WithAllocator sets passed allocator, which holds the secret keys of the chain.
+func WithAllocator(allocator keys.Allocator) Option {
+	return func(cfg *config) error {
+		if check.IsNil(allocator) {
+			return ErrAllocatorIsNil
+		}
+
+		cfg.allocator = allocator
+
+		return nil
+	}
+}
+
 // WithCrypto is an option to set specific crypto to the config.
 func WithCrypto(crypto Crypto) Option {
 	return func(cfg *config) error {
@@ -45,6 +85,21 @@ func WithCrypto(crypto Crypto) Option {
 		}
 
 		cfg.crypto = crypto
+		cfg.cryptoSet = true
+
+		return nil
+	}
+}
+
+// WithSuiteCrypto sets passed crypto of the ratchet suite. Unlike WithCrypto, it can not be
+// combined with WithCrypto and WithAEAD, so the chain never mixes crypto of different suites.
+func WithSuiteCrypto(crypto Crypto) Option {
+	return func(cfg *config) error {
+		if check.IsNil(crypto) {
+			return ErrCryptoIsNil
+		}
+
+		cfg.suiteCrypto = crypto
 
 		return nil
 	}
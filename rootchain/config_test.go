@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/platform-source/aegis/aead"
 	"github.com/platform-source/aegis/keys"
 )
 
@@ -37,6 +38,25 @@ var newConfigTests = []struct {
 		nil,
 		testCrypto{},
 	},
+	{
+		"AEAD option success",
+		[]Option{
+			WithAEAD(aead.AES256GCMSIV()),
+		},
+		nil,
+		defaultCrypto{},
+	},
+	{
+		"invalid AEAD",
+		[]Option{
+			WithAEAD(aead.Suite{}),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrInvalidAEAD,
+		},
+		nil,
+	},
 	{
 		"nil crypto",
 		[]Option{
@@ -48,6 +68,37 @@ var newConfigTests = []struct {
 		},
 		nil,
 	},
+	{
+		"suite crypto option success",
+		[]Option{
+			WithSuiteCrypto(testCrypto{}),
+		},
+		nil,
+		testCrypto{},
+	},
+	{
+		"mixed crypto",
+		[]Option{
+			WithAEAD(aead.AES256GCM()),
+			WithSuiteCrypto(testCrypto{}),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrMixedCrypto,
+		},
+		nil,
+	},
+	{
+		"nil suite crypto",
+		[]Option{
+			WithSuiteCrypto(nil),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrCryptoIsNil,
+		},
+		nil,
+	},
 }
 
 func TestNewConfig(t *testing.T) {
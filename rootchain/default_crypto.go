@@ -5,6 +5,7 @@ import (
 	"hash"
 	"io"
 
+	"github.com/platform-source/aegis/aead"
 	"github.com/platform-source/aegis/keys"
 	"golang.org/x/crypto/blake2b"
 	"golang.org/x/crypto/hkdf"
@@ -12,15 +13,24 @@ import (
 
 var defaultCryptoKDFInfo = []byte("advance root chain")
 
-type defaultCrypto struct{}
+type defaultCrypto struct {
+	aead aead.Suite
+}
+
+// NewDefaultCrypto returns the default crypto of the chain with passed AEAD suite.
+func NewDefaultCrypto(suite aead.Suite) Crypto {
+	return newDefaultCrypto(suite)
+}
 
-func newDefaultCrypto() defaultCrypto {
-	crypto := defaultCrypto{}
+func newDefaultCrypto(suite aead.Suite) defaultCrypto {
+	crypto := defaultCrypto{
+		aead: suite,
+	}
 
 	return crypto
 }
 
-func (defaultCrypto) AdvanceChain(
+func (c defaultCrypto) AdvanceChain(
 	rootKey keys.Root,
 	sharedKey keys.Shared,
 ) (keys.Root, keys.Master, keys.Header, error) {
@@ -39,7 +49,8 @@ func (defaultCrypto) AdvanceChain(
 		rootKey.Bytes,
 		defaultCryptoKDFInfo,
 	)
-	kdfOutput := make([]byte, 3*kdfOutputKeySize)
+	// The header key is sized for the AEAD suite.
+	kdfOutput := make([]byte, 2*kdfOutputKeySize+c.aead.KeySize())
 
 	_, err := io.ReadFull(kdf, kdfOutput)
 	if err != nil {
@@ -59,7 +70,7 @@ func (defaultCrypto) AdvanceChain(
 	}
 
 	nextHeaderKey := keys.Header{
-		Bytes: kdfOutput[2*kdfOutputKeySize : 3*kdfOutputKeySize],
+		Bytes: kdfOutput[2*kdfOutputKeySize:],
 	}
 
 	return newRootKey, masterKey, nextHeaderKey, nil
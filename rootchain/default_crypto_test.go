@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/platform-source/aegis/aead"
 	"github.com/platform-source/aegis/keys"
 )
 
@@ -45,7 +46,7 @@ var defaultCryptoAdvanceChainTests = []struct {
 func TestDefaultCryptoAdvanceChain(t *testing.T) {
 	t.Parallel()
 
-	crypto := newDefaultCrypto()
+	crypto := newDefaultCrypto(aead.XChaCha20Poly1305())
 
 	for _, test := range defaultCryptoAdvanceChainTests {
 		t.Run(test.name, func(t *testing.T) {
@@ -8,18 +8,39 @@ var (
 	// ErrAdvanceChain is the chain crypto advance error.
 	ErrAdvanceChain = errors.New("advance")
 
+	// ErrAllocatorIsNil is the nil allocator error.
+	ErrAllocatorIsNil = errors.New("allocator is nil")
+
 	// ErrApplyOptions is config options apply error.
 	ErrApplyOptions = errors.New("apply options")
 
+	// ErrBuildBytes is the bytes building error.
+	ErrBuildBytes = errors.New("build bytes")
+
 	// ErrCryptoIsNil is an error when nil crypto was passed.
 	ErrCryptoIsNil = errors.New("crypto is nil")
 
+	// ErrInvalidAEAD is an error when the AEAD suite is not valid.
+	ErrInvalidAEAD = errors.New("invalid AEAD")
+
+	// ErrInvalidEncoding is an error when encoded state is malformed.
+	ErrInvalidEncoding = errors.New("invalid encoding")
+
 	// ErrKDF is the key derivation error.
 	ErrKDF = errors.New("KDF")
 
+	// ErrMixedCrypto is an error when the suite crypto is combined with other crypto options.
+	ErrMixedCrypto = errors.New("mixed crypto")
+
+	// ErrNewChain is the chain initialization error.
+	ErrNewChain = errors.New("new chain")
+
 	// ErrNewConfig is the config initialization error.
 	ErrNewConfig = errors.New("new config")
 
 	// ErrNewHasher is the hasher initialization error.
 	ErrNewHasher = errors.New("new hasher")
+
+	// ErrUnsupportedVersion is an error when encoded state has unknown version.
+	ErrUnsupportedVersion = errors.New("unsupported version")
 )
@@ -0,0 +1,62 @@
+package rootchain
+
+import (
+	"errors"
+
+	"github.com/platform-source/aegis/keys"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const marshalVersion = 1
+
+// MarshalBinary encodes the root chain state to bytes.
+//
+// Note that the config is not encoded, so the same options must be passed to Unmarshal.
+func (ch Chain) MarshalBinary() ([]byte, error) {
+	builder := cryptobyte.NewBuilder(nil)
+	builder.AddUint8(marshalVersion)
+	builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(ch.rootKey.Bytes)
+	})
+
+	data, err := builder.Bytes()
+	if err != nil {
+		return nil, errors.Join(ErrBuildBytes, err)
+	}
+
+	return data, nil
+}
+
+// Unmarshal decodes the root chain state from bytes produced by MarshalBinary and
+// applies passed options to it.
+func Unmarshal(data []byte, options ...Option) (Chain, error) {
+	input := cryptobyte.String(data)
+
+	var (
+		version      uint8
+		rootKeyBytes cryptobyte.String
+	)
+
+	if !input.ReadUint8(&version) {
+		return Chain{}, ErrInvalidEncoding
+	}
+
+	if version != marshalVersion {
+		return Chain{}, ErrUnsupportedVersion
+	}
+
+	if !input.ReadUint16LengthPrefixed(&rootKeyBytes) || !input.Empty() {
+		return Chain{}, ErrInvalidEncoding
+	}
+
+	rootKey := keys.Root{
+		Bytes: []byte(rootKeyBytes),
+	}
+
+	chain, err := New(rootKey.Clone(), options...)
+	if err != nil {
+		return Chain{}, errors.Join(ErrNewChain, err)
+	}
+
+	return chain, nil
+}
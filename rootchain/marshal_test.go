@@ -0,0 +1,125 @@
+package rootchain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/platform-source/aegis/keys"
+)
+
+var chainMarshalBinaryTests = []struct {
+	name    string
+	rootKey keys.Root
+}{
+	{
+		"zero key",
+		keys.Root{},
+	},
+	{
+		"non-empty key",
+		keys.Root{
+			Bytes: []byte{1, 2, 3, 4, 5},
+		},
+	},
+}
+
+func TestChainMarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range chainMarshalBinaryTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			chain, err := New(test.rootKey)
+			if err != nil {
+				t.Fatalf("New(): expected no error but got %v", err)
+			}
+
+			data, err := chain.MarshalBinary()
+			if err != nil {
+				t.Fatalf("%+v.MarshalBinary(): expected no error but got %v", chain, err)
+			}
+
+			restored, err := Unmarshal(data)
+			if err != nil {
+				t.Fatalf("Unmarshal(%v): expected no error but got %v", data, err)
+			}
+
+			if !bytes.Equal(restored.rootKey.Bytes, chain.rootKey.Bytes) {
+				t.Fatalf(
+					"Unmarshal(%v): expected root key %v but got %v",
+					data,
+					chain.rootKey,
+					restored.rootKey,
+				)
+			}
+		})
+	}
+}
+
+var unmarshalTests = []struct {
+	name          string
+	data          []byte
+	options       []Option
+	errCategories []error
+}{
+	{
+		"nil data",
+		nil,
+		nil,
+		[]error{
+			ErrInvalidEncoding,
+		},
+	},
+	{
+		"unsupported version",
+		[]byte{0xFF, 0x00, 0x00},
+		nil,
+		[]error{
+			ErrUnsupportedVersion,
+		},
+	},
+	{
+		"trailing bytes",
+		[]byte{marshalVersion, 0x00, 0x01, 0x01, 0x02},
+		nil,
+		[]error{
+			ErrInvalidEncoding,
+		},
+	},
+	{
+		"nil crypto",
+		[]byte{marshalVersion, 0x00, 0x01, 0x01},
+		[]Option{
+			WithCrypto(nil),
+		},
+		[]error{
+			ErrNewChain,
+			ErrCryptoIsNil,
+		},
+	},
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range unmarshalTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Unmarshal(test.data, test.options...)
+
+			for _, errCategory := range test.errCategories {
+				if !errors.Is(err, errCategory) {
+					t.Fatalf(
+						"Unmarshal(%v): expected error %v but got %v",
+						test.data,
+						errCategory,
+						err,
+					)
+				}
+			}
+		})
+	}
+}
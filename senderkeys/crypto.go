@@ -0,0 +1,13 @@
+package senderkeys
+
+import (
+	"github.com/platform-source/aegis/keys"
+)
+
+// Crypto is the crypto interface for the sender keys.
+type Crypto interface {
+	GenerateChainKeys() (keys.Master, keys.Header, error)
+	GenerateSigningKeyPair() (keys.Private, keys.Public, error)
+	Sign(privateKey keys.Private, data []byte) ([]byte, error)
+	Verify(publicKey keys.Public, data []byte, signature []byte) error
+}
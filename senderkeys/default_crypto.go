@@ -0,0 +1,76 @@
+package senderkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+
+	"github.com/platform-source/aegis/keys"
+)
+
+const defaultCryptoChainKeySize = 32
+
+type defaultCrypto struct{}
+
+func newDefaultCrypto() defaultCrypto {
+	return defaultCrypto{}
+}
+
+func (defaultCrypto) GenerateChainKeys() (keys.Master, keys.Header, error) {
+	keysBytes := make([]byte, 2*defaultCryptoChainKeySize)
+
+	_, err := io.ReadFull(rand.Reader, keysBytes)
+	if err != nil {
+		return keys.Master{}, keys.Header{}, errors.Join(ErrReadRandom, err)
+	}
+
+	masterKey := keys.Master{
+		Bytes: keysBytes[:defaultCryptoChainKeySize],
+	}
+
+	headerKey := keys.Header{
+		Bytes: keysBytes[defaultCryptoChainKeySize:],
+	}
+
+	return masterKey, headerKey, nil
+}
+
+func (defaultCrypto) GenerateSigningKeyPair() (keys.Private, keys.Public, error) {
+	publicKeyBytes, privateKeyBytes, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return keys.Private{}, keys.Public{}, errors.Join(ErrGenerateSigningKey, err)
+	}
+
+	privateKey := keys.Private{
+		Bytes: privateKeyBytes,
+	}
+
+	publicKey := keys.Public{
+		Bytes: publicKeyBytes,
+	}
+
+	return privateKey, publicKey, nil
+}
+
+func (defaultCrypto) Sign(privateKey keys.Private, data []byte) ([]byte, error) {
+	if len(privateKey.Bytes) != ed25519.PrivateKeySize {
+		return nil, ErrInvalidSigningKey
+	}
+
+	signature := ed25519.Sign(privateKey.Bytes, data)
+
+	return signature, nil
+}
+
+func (defaultCrypto) Verify(publicKey keys.Public, data []byte, signature []byte) error {
+	if len(publicKey.Bytes) != ed25519.PublicKeySize {
+		return ErrInvalidSigningKey
+	}
+
+	if !ed25519.Verify(publicKey.Bytes, data, signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
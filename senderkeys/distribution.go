@@ -0,0 +1,81 @@
+package senderkeys
+
+import (
+	"errors"
+
+	"github.com/platform-source/aegis/keys"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const distributionMessageMarshalVersion = 1
+
+// DistributionMessage is the sending chain state of the group member, which is sent to
+// other members over pairwise ratchets so they can decrypt member messages.
+type DistributionMessage struct {
+	SigningPublicKey keys.Public
+	MasterKey        keys.Master
+	HeaderKey        keys.Header
+	MessageNumber    uint64
+}
+
+// UnmarshalDistributionMessage decodes distribution message from bytes produced by
+// DistributionMessage.MarshalBinary.
+func UnmarshalDistributionMessage(data []byte) (DistributionMessage, error) {
+	input := cryptobyte.String(data)
+
+	var (
+		version               uint8
+		signingPublicKeyBytes cryptobyte.String
+		masterKeyBytes        cryptobyte.String
+		headerKeyBytes        cryptobyte.String
+		messageNumber         uint64
+	)
+
+	if !input.ReadUint8(&version) {
+		return DistributionMessage{}, ErrInvalidEncoding
+	}
+
+	if version != distributionMessageMarshalVersion {
+		return DistributionMessage{}, ErrUnsupportedVersion
+	}
+
+	if !input.ReadUint16LengthPrefixed(&signingPublicKeyBytes) ||
+		!input.ReadUint16LengthPrefixed(&masterKeyBytes) ||
+		!input.ReadUint16LengthPrefixed(&headerKeyBytes) ||
+		!input.ReadUint64(&messageNumber) ||
+		!input.Empty() {
+		return DistributionMessage{}, ErrInvalidEncoding
+	}
+
+	message := DistributionMessage{
+		SigningPublicKey: keys.Public{Bytes: signingPublicKeyBytes}.Clone(),
+		MasterKey:        keys.Master{Bytes: masterKeyBytes}.Clone(),
+		HeaderKey:        keys.Header{Bytes: headerKeyBytes}.Clone(),
+		MessageNumber:    messageNumber,
+	}
+
+	return message, nil
+}
+
+// MarshalBinary encodes distribution message to bytes.
+func (dm DistributionMessage) MarshalBinary() ([]byte, error) {
+	builder := cryptobyte.NewBuilder(nil)
+	builder.AddUint8(distributionMessageMarshalVersion)
+	builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(dm.SigningPublicKey.Bytes)
+	})
+	builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(dm.MasterKey.Bytes)
+	})
+	builder.AddUint16LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(dm.HeaderKey.Bytes)
+	})
+	builder.AddUint64(dm.MessageNumber)
+
+	data, err := builder.Bytes()
+	if err != nil {
+		return nil, errors.Join(ErrBuildBytes, err)
+	}
+
+	return data, nil
+}
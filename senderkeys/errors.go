@@ -0,0 +1,74 @@
+package senderkeys
+
+import (
+	"errors"
+)
+
+var (
+	// ErrApplyOptions is the config options apply error.
+	ErrApplyOptions = errors.New("apply options")
+
+	// ErrBuildBytes is the bytes building error.
+	ErrBuildBytes = errors.New("build bytes")
+
+	// ErrCryptoIsNil is an error when nil crypto was passed.
+	ErrCryptoIsNil = errors.New("crypto is nil")
+
+	// ErrGenerateChainKeys is the chain keys generation error.
+	ErrGenerateChainKeys = errors.New("generate chain keys")
+
+	// ErrGenerateSigningKey is the signing key generation error.
+	ErrGenerateSigningKey = errors.New("generate signing key")
+
+	// ErrGenerateSigningKeyPair is the signing key pair generation error.
+	ErrGenerateSigningKeyPair = errors.New("generate signing key pair")
+
+	// ErrInvalidEncoding is an error when encoded data is malformed.
+	ErrInvalidEncoding = errors.New("invalid encoding")
+
+	// ErrInvalidSignature is an error when the signature does not match the data.
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrInvalidSigningKey is an error when the signing key has invalid size.
+	ErrInvalidSigningKey = errors.New("invalid signing key")
+
+	// ErrMarshalSendingChain is the sending chain marshaling error.
+	ErrMarshalSendingChain = errors.New("marshal sending chain")
+
+	// ErrNewConfig is the new config creation error.
+	ErrNewConfig = errors.New("new config")
+
+	// ErrNewReceivingChain is the new receiving chain creation error.
+	ErrNewReceivingChain = errors.New("new receiving chain")
+
+	// ErrNewSendingChain is the new sending chain creation error.
+	ErrNewSendingChain = errors.New("new sending chain")
+
+	// ErrPrepareSignedData is the signed data preparation error.
+	ErrPrepareSignedData = errors.New("prepare signed data")
+
+	// ErrReadRandom is the random bytes read error.
+	ErrReadRandom = errors.New("read random")
+
+	// ErrReceivingChainDecrypt is the receiving chain decryption error.
+	ErrReceivingChainDecrypt = errors.New("receiving chain decrypt")
+
+	// ErrSendingChainEncrypt is the sending chain encryption error.
+	ErrSendingChainEncrypt = errors.New("sending chain encrypt")
+
+	// ErrSign is the signing error.
+	ErrSign = errors.New("sign")
+
+	// ErrUnexpectedRatchet is an error when the message requires the chain ratchet,
+	// which never happens with sender keys.
+	ErrUnexpectedRatchet = errors.New("unexpected ratchet")
+
+	// ErrUnmarshalSendingChain is the sending chain unmarshaling error.
+	ErrUnmarshalSendingChain = errors.New("unmarshal sending chain")
+
+	// ErrUnsupportedVersion is an error when encoded data has unknown version.
+	ErrUnsupportedVersion = errors.New("unsupported version")
+
+	// ErrVerifySignature is the signature verification error.
+	ErrVerifySignature = errors.New("verify signature")
+)
@@ -0,0 +1,36 @@
+package senderkeys
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+var messageSignatureContext = []byte("sender keys message")
+
+// Message is the encrypted group message of the member.
+type Message struct {
+	EncryptedHeader []byte
+	EncryptedData   []byte
+	Signature       []byte
+}
+
+// signedData returns the data covered by the message signature.
+func (m Message) signedData(auth []byte) ([]byte, error) {
+	builder := cryptobyte.NewBuilder(nil)
+	builder.AddBytes(messageSignatureContext)
+	builder.AddUint32LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(m.EncryptedHeader)
+	})
+	builder.AddUint32LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(m.EncryptedData)
+	})
+	builder.AddBytes(auth)
+
+	data, err := builder.Bytes()
+	if err != nil {
+		return nil, errors.Join(ErrBuildBytes, err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,86 @@
+package senderkeys
+
+import (
+	"errors"
+
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/receivingchain"
+	"github.com/platform-source/tools/convert"
+)
+
+// Receiver is the receiving side of the group member for one sender. Members keep one
+// receiver per other member. Messages may be delivered out of order.
+//
+// Please note that the structure is not safe for concurrent programs.
+type Receiver struct {
+	signingPublicKey keys.Public
+	chain            receivingchain.Chain
+	cfg              config
+}
+
+// NewReceiver creates a new receiver from the distribution message of the sender.
+func NewReceiver(distribution DistributionMessage, options ...Option) (Receiver, error) {
+	receiver := Receiver{
+		signingPublicKey: distribution.SigningPublicKey.Clone(),
+	}
+
+	var err error
+
+	receiver.cfg, err = newConfig(options...)
+	if err != nil {
+		return Receiver{}, errors.Join(ErrNewConfig, err)
+	}
+
+	receiver.chain, err = receivingchain.New(
+		convert.ToPtr(distribution.MasterKey.Clone()),
+		convert.ToPtr(distribution.HeaderKey.Clone()),
+		keys.Header{},
+		distribution.MessageNumber,
+		receiver.cfg.receivingOptions...,
+	)
+	if err != nil {
+		return Receiver{}, errors.Join(ErrNewReceivingChain, err)
+	}
+
+	return receiver, nil
+}
+
+// Clone clones receiver.
+func (r Receiver) Clone() Receiver {
+	r.signingPublicKey = r.signingPublicKey.Clone()
+	r.chain = r.chain.Clone()
+
+	return r
+}
+
+// Decrypt verifies the signature of passed message, decrypts it and authenticates it
+// with auth.
+func (r *Receiver) Decrypt(message Message, auth []byte) ([]byte, error) {
+	signedData, err := message.signedData(auth)
+	if err != nil {
+		return nil, errors.Join(ErrPrepareSignedData, err)
+	}
+
+	err = r.cfg.crypto.Verify(r.signingPublicKey, signedData, message.Signature)
+	if err != nil {
+		return nil, errors.Join(ErrVerifySignature, err)
+	}
+
+	data, err := r.chain.Decrypt(
+		message.EncryptedHeader,
+		message.EncryptedData,
+		auth,
+		rejectRatchet,
+	)
+	if err != nil {
+		return nil, errors.Join(ErrReceivingChainDecrypt, err)
+	}
+
+	return data, nil
+}
+
+// rejectRatchet rejects the ratchet of the receiving chain, because the sender chain
+// is never upgraded.
+func rejectRatchet(_ keys.Public) error {
+	return ErrUnexpectedRatchet
+}
@@ -0,0 +1,131 @@
+package senderkeys
+
+import (
+	"errors"
+
+	"github.com/platform-source/aegis/internal/chainstate"
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/sendingchain"
+)
+
+// Sender is the sending side of the group member. Each member has one sender, and its
+// distribution message is sent to every other member.
+//
+// Please note that the structure is not safe for concurrent programs.
+type Sender struct {
+	signingPrivateKey keys.Private
+	signingPublicKey  keys.Public
+	chain             sendingchain.Chain
+	cfg               config
+}
+
+// NewSender creates a new sender with random chain keys and signing key pair.
+func NewSender(options ...Option) (Sender, error) {
+	var (
+		sender Sender
+		err    error
+	)
+
+	sender.cfg, err = newConfig(options...)
+	if err != nil {
+		return Sender{}, errors.Join(ErrNewConfig, err)
+	}
+
+	sender.signingPrivateKey, sender.signingPublicKey, err = sender.cfg.crypto.
+		GenerateSigningKeyPair()
+	if err != nil {
+		return Sender{}, errors.Join(ErrGenerateSigningKeyPair, err)
+	}
+
+	masterKey, headerKey, err := sender.cfg.crypto.GenerateChainKeys()
+	if err != nil {
+		return Sender{}, errors.Join(ErrGenerateChainKeys, err)
+	}
+
+	// Note that the next header key is never used, because the chain is never upgraded.
+	sender.chain, err = sendingchain.New(
+		&masterKey,
+		&headerKey,
+		keys.Header{},
+		0,
+		0,
+		sender.cfg.sendingOptions...,
+	)
+	if err != nil {
+		return Sender{}, errors.Join(ErrNewSendingChain, err)
+	}
+
+	return sender, nil
+}
+
+// Clone clones sender.
+func (s Sender) Clone() Sender {
+	s.signingPrivateKey = s.signingPrivateKey.Clone()
+	s.signingPublicKey = s.signingPublicKey.Clone()
+	s.chain = s.chain.Clone()
+
+	return s
+}
+
+// DistributionMessage returns the distribution message with the current chain state.
+// The members, which receive it, will be able to decrypt only the following messages.
+func (s Sender) DistributionMessage() (DistributionMessage, error) {
+	// Note that the sending chain does not expose its keys, so they are taken from its
+	// encoded state.
+	data, err := s.chain.MarshalBinary()
+	if err != nil {
+		return DistributionMessage{}, errors.Join(ErrMarshalSendingChain, err)
+	}
+	defer clear(data)
+
+	state, err := chainstate.UnmarshalSending(data)
+	if err != nil {
+		return DistributionMessage{}, errors.Join(ErrUnmarshalSendingChain, err)
+	}
+
+	message := DistributionMessage{
+		SigningPublicKey: s.signingPublicKey.Clone(),
+		MessageNumber:    state.NextMessageNumber,
+	}
+
+	if state.MasterKey != nil {
+		message.MasterKey = *state.MasterKey
+	}
+
+	if state.HeaderKey != nil {
+		message.HeaderKey = *state.HeaderKey
+	}
+
+	return message, nil
+}
+
+// Encrypt encrypts passed data, authenticates it with auth and signs the result.
+func (s *Sender) Encrypt(data []byte, auth []byte) (Message, error) {
+	dirtyChain := s.chain.Clone()
+
+	var (
+		message Message
+		err     error
+	)
+
+	header := dirtyChain.PrepareHeader(keys.Public{})
+
+	message.EncryptedHeader, message.EncryptedData, err = dirtyChain.Encrypt(header, data, auth)
+	if err != nil {
+		return Message{}, errors.Join(ErrSendingChainEncrypt, err)
+	}
+
+	signedData, err := message.signedData(auth)
+	if err != nil {
+		return Message{}, errors.Join(ErrPrepareSignedData, err)
+	}
+
+	message.Signature, err = s.cfg.crypto.Sign(s.signingPrivateKey, signedData)
+	if err != nil {
+		return Message{}, errors.Join(ErrSign, err)
+	}
+
+	s.chain = dirtyChain
+
+	return message, nil
+}
@@ -0,0 +1,208 @@
+package senderkeys
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func newTestSender(t *testing.T) Sender {
+	t.Helper()
+
+	sender, err := NewSender()
+	if err != nil {
+		t.Fatalf("NewSender(): expected no error but got %v", err)
+	}
+
+	return sender
+}
+
+func newTestReceiver(t *testing.T, sender Sender) Receiver {
+	t.Helper()
+
+	distribution, err := sender.DistributionMessage()
+	if err != nil {
+		t.Fatalf("DistributionMessage(): expected no error but got %v", err)
+	}
+
+	data, err := distribution.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary(): expected no error but got %v", err)
+	}
+
+	distribution, err = UnmarshalDistributionMessage(data)
+	if err != nil {
+		t.Fatalf("UnmarshalDistributionMessage(%v): expected no error but got %v", data, err)
+	}
+
+	receiver, err := NewReceiver(distribution)
+	if err != nil {
+		t.Fatalf("NewReceiver(): expected no error but got %v", err)
+	}
+
+	return receiver
+}
+
+func encryptTestMessage(t *testing.T, sender *Sender, data []byte) Message {
+	t.Helper()
+
+	message, err := sender.Encrypt(data, []byte("group"))
+	if err != nil {
+		t.Fatalf("Encrypt(%v): expected no error but got %v", data, err)
+	}
+
+	return message
+}
+
+func decryptTestMessage(t *testing.T, receiver *Receiver, message Message, expected []byte) {
+	t.Helper()
+
+	data, err := receiver.Decrypt(message, []byte("group"))
+	if err != nil {
+		t.Fatalf("Decrypt(): expected no error but got %v", err)
+	}
+
+	if !bytes.Equal(data, expected) {
+		t.Fatalf("Decrypt(): expected %v but got %v", expected, data)
+	}
+}
+
+func TestGroupConversation(t *testing.T) {
+	t.Parallel()
+
+	sender := newTestSender(t)
+	firstReceiver := newTestReceiver(t, sender)
+
+	first := encryptTestMessage(t, &sender, []byte("first"))
+	second := encryptTestMessage(t, &sender, []byte("second"))
+
+	lateReceiver := newTestReceiver(t, sender)
+
+	third := encryptTestMessage(t, &sender, []byte("third"))
+
+	decryptTestMessage(t, &firstReceiver, third, []byte("third"))
+	decryptTestMessage(t, &firstReceiver, first, []byte("first"))
+	decryptTestMessage(t, &firstReceiver, second, []byte("second"))
+
+	decryptTestMessage(t, &lateReceiver, third, []byte("third"))
+
+	_, err := lateReceiver.Decrypt(first, []byte("group"))
+	if err == nil {
+		t.Fatal("Decrypt(): expected error for message sent before distribution")
+	}
+
+	_, err = firstReceiver.Decrypt(first, []byte("group"))
+	if err == nil {
+		t.Fatal("Decrypt(): expected error for already decrypted message")
+	}
+}
+
+func TestReceiverDecryptForgedMessage(t *testing.T) {
+	t.Parallel()
+
+	sender := newTestSender(t)
+	receiver := newTestReceiver(t, sender)
+
+	// Note that the forger knows the chain keys, as every group member does, but not
+	// the signing key of the sender.
+	forger := sender.Clone()
+	forger.signingPrivateKey, _, _ = newDefaultCrypto().GenerateSigningKeyPair()
+
+	forged := encryptTestMessage(t, &forger, []byte("forged"))
+
+	_, err := receiver.Decrypt(forged, []byte("group"))
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Decrypt(): expected invalid signature error but got %v", err)
+	}
+
+	message := encryptTestMessage(t, &sender, []byte("message"))
+	decryptTestMessage(t, &receiver, message, []byte("message"))
+}
+
+var unmarshalDistributionMessageTests = []struct {
+	name          string
+	data          []byte
+	errCategories []error
+}{
+	{
+		"nil data",
+		nil,
+		[]error{
+			ErrInvalidEncoding,
+		},
+	},
+	{
+		"unsupported version",
+		[]byte{0xFF},
+		[]error{
+			ErrUnsupportedVersion,
+		},
+	},
+	{
+		"trailing bytes",
+		[]byte{
+			distributionMessageMarshalVersion, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+		},
+		[]error{
+			ErrInvalidEncoding,
+		},
+	},
+}
+
+func TestUnmarshalDistributionMessage(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range unmarshalDistributionMessageTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := UnmarshalDistributionMessage(test.data)
+
+			for _, errCategory := range test.errCategories {
+				if !errors.Is(err, errCategory) {
+					t.Fatalf(
+						"UnmarshalDistributionMessage(%v): expected error %v but got %v",
+						test.data,
+						errCategory,
+						err,
+					)
+				}
+			}
+		})
+	}
+}
+
+func TestDistributionMessageMarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	sender := newTestSender(t)
+	encryptTestMessage(t, &sender, []byte("message"))
+
+	distribution, err := sender.DistributionMessage()
+	if err != nil {
+		t.Fatalf("DistributionMessage(): expected no error but got %v", err)
+	}
+
+	data, err := distribution.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary(): expected no error but got %v", err)
+	}
+
+	restored, err := UnmarshalDistributionMessage(data)
+	if err != nil {
+		t.Fatalf("UnmarshalDistributionMessage(%v): expected no error but got %v", data, err)
+	}
+
+	if !reflect.DeepEqual(restored, distribution) {
+		t.Fatalf("UnmarshalDistributionMessage(): expected %+v but got %+v", distribution, restored)
+	}
+
+	if restored.MessageNumber != 1 {
+		t.Fatalf(
+			"DistributionMessage(): expected message number 1 but got %d",
+			restored.MessageNumber,
+		)
+	}
+}
@@ -21,6 +21,47 @@ type Chain struct {
 	nextMessageNumber          uint64
 	previousChainMessagesCount uint64
 	cfg                        config
+	scratch                    *scratch
+}
+
+// scratch is the memory reused by EncryptAppend. The master keys are double-buffered, so the
+// current master key is kept intact until the new one is committed.
+type scratch struct {
+	masterKeys    [2]keys.Master
+	nextMasterKey int
+	messageKey    []byte
+	auth          []byte
+	paddedData    []byte
+}
+
+// scratchMasterKeySize is the size of the master keys derived by the default crypto, which
+// is reserved for the scratch master keys in the memory of the allocator.
+const scratchMasterKeySize = 64
+
+func newScratch(allocator keys.Allocator) *scratch {
+	sc := &scratch{}
+
+	if allocator != nil {
+		for i := range sc.masterKeys {
+			sc.masterKeys[i] = keys.Master{
+				Bytes: make([]byte, scratchMasterKeySize),
+			}.CloneTo(allocator)
+		}
+	}
+
+	return sc
+}
+
+// wipeMasterKey wipes passed master key. The memory of the scratch master keys is only
+// zeroed, so it is reused.
+func (sc *scratch) wipeMasterKey(masterKey *keys.Master) {
+	if masterKey == &sc.masterKeys[0] || masterKey == &sc.masterKeys[1] {
+		clear(masterKey.Bytes)
+
+		return
+	}
+
+	masterKey.Wipe()
 }
 
 // New creates a new sending chain.
@@ -33,7 +74,6 @@ func New(
 	options ...Option,
 ) (Chain, error) {
 	chain := Chain{
-		masterKey:                  masterKey,
 		headerKey:                  headerKey,
 		nextHeaderKey:              nextHeaderKey,
 		nextMessageNumber:          nextMessageNumber,
@@ -47,6 +87,10 @@ func New(
 		return Chain{}, errors.Join(ErrNewConfig, err)
 	}
 
+	if masterKey != nil {
+		chain.masterKey = chain.protectMasterKey(*masterKey)
+	}
+
 	return chain, nil
 }
 
@@ -55,21 +99,146 @@ func (ch Chain) Clone() Chain {
 	ch.masterKey = ch.masterKey.ClonePtr()
 	ch.headerKey = ch.headerKey.ClonePtr()
 	ch.nextHeaderKey = ch.nextHeaderKey.Clone()
+	ch.scratch = nil
 
 	return ch
 }
 
-// Encrypt encrypts passed header and data and authenticates with passed auth.
+// SealCallback seals the message with its message key. The encrypted header must be
+// authenticated together with the message.
+type SealCallback func(messageKey keys.Message, encryptedHeader []byte) ([]byte, error)
+
+// Encrypt encrypts passed header and data and authenticates with passed auth. The data is
+// padded if the padding is set.
 func (ch *Chain) Encrypt(
 	head header.Header,
 	data []byte,
 	auth []byte,
 ) (encryptedHeader []byte, encryptedData []byte, err error) {
-	if ch.headerKey == nil {
+	if ch.cfg.padding.Valid() {
+		data = ch.cfg.padding.AppendPad(nil, data)
+		defer clear(data)
+	}
+
+	return ch.Seal(head, func(messageKey keys.Message, encryptedHeader []byte) ([]byte, error) {
+		encryptedData, err := ch.cfg.crypto.EncryptMessage(
+			messageKey,
+			data,
+			slices.ConcatBytes(encryptedHeader, auth),
+		)
+		if err != nil {
+			return nil, errors.Join(ErrEncryptMessage, err)
+		}
+
+		return encryptedData, nil
+	})
+}
+
+// EncryptAppend encrypts passed header and data like Encrypt, but appends the encrypted
+// header to dstHeader and the encrypted data to dstData. The chain state is changed only if
+// there are no errors.
+//
+// If the crypto implements AppendCrypto, the chain reuses its own memory for the keys, so
+// it does not allocate when the destination buffers have enough capacity.
+func (ch *Chain) EncryptAppend(
+	dstHeader []byte,
+	dstData []byte,
+	head header.Header,
+	data []byte,
+	auth []byte,
+) (encryptedHeader []byte, encryptedData []byte, err error) {
+	crypto, ok := ch.cfg.crypto.(AppendCrypto)
+	if !ok {
+		// Note that Encrypt advances the chain before the message is encrypted, so it is
+		// called on a clone, which replaces the chain only on success.
+		dirty := ch.Clone()
+
+		encryptedHeader, encryptedData, err = dirty.Encrypt(head, data, auth)
+		if err != nil {
+			dirty.Wipe()
+
+			return nil, nil, err
+		}
+
+		ch.Wipe()
+		*ch = dirty
+
+		return append(dstHeader, encryptedHeader...), append(dstData, encryptedData...), nil
+	}
+
+	if ch.headerKey == nil && !ch.cfg.plaintextHeaders {
 		return nil, nil, ErrHeaderKeyIsNil
 	}
 
-	encryptedHeader, err = ch.cfg.crypto.EncryptHeader(*ch.headerKey, head)
+	if ch.masterKey == nil {
+		return nil, nil, ErrMasterKeyIsNil
+	}
+
+	if ch.scratch == nil {
+		ch.scratch = newScratch(ch.cfg.allocator)
+	}
+
+	head.MinEncodedLen = max(head.MinEncodedLen, ch.cfg.headerSize)
+
+	if ch.cfg.plaintextHeaders {
+		encryptedHeader, err = head.AppendEncode(dstHeader)
+		if err != nil {
+			return nil, nil, errors.Join(ErrEncodeHeader, err)
+		}
+	} else {
+		encryptedHeader, err = crypto.EncryptHeaderAppend(dstHeader, *ch.headerKey, head)
+		if err != nil {
+			return nil, nil, errors.Join(ErrEncryptHeader, err)
+		}
+	}
+
+	nextMasterKey := &ch.scratch.masterKeys[ch.scratch.nextMasterKey]
+
+	newMasterKey, messageKey, err := crypto.AdvanceChainAppend(
+		nextMasterKey.Bytes[:0],
+		ch.scratch.messageKey[:0],
+		*ch.masterKey,
+	)
+	if err != nil {
+		return nil, nil, errors.Join(ErrAdvanceChain, ErrCryptoAdvanceChain, err)
+	}
+
+	ch.scratch.messageKey = messageKey.Bytes
+	ch.scratch.auth = append(ch.scratch.auth[:0], encryptedHeader[len(dstHeader):]...)
+	ch.scratch.auth = append(ch.scratch.auth, auth...)
+
+	if ch.cfg.padding.Valid() {
+		ch.scratch.paddedData = ch.cfg.padding.AppendPad(ch.scratch.paddedData[:0], data)
+		data = ch.scratch.paddedData
+	}
+
+	encryptedData, err = crypto.EncryptMessageAppend(dstData, messageKey, data, ch.scratch.auth)
+	clear(ch.scratch.messageKey)
+	clear(ch.scratch.paddedData)
+
+	if err != nil {
+		clear(newMasterKey.Bytes)
+
+		return nil, nil, errors.Join(ErrEncryptMessage, err)
+	}
+
+	ch.wipeMasterKey()
+	nextMasterKey.Bytes = newMasterKey.Bytes
+	ch.masterKey = nextMasterKey
+	ch.scratch.nextMasterKey ^= 1
+	ch.nextMessageNumber++
+
+	return encryptedHeader, encryptedData, nil
+}
+
+// Seal encrypts passed header, advances the chain and passes the message key to the seal
+// callback. The chain is advanced even if the callback fails. The message key is wiped after
+// the callback returns, so the callback must not retain it.
+func (ch *Chain) Seal(
+	head header.Header,
+	seal SealCallback,
+) (encryptedHeader []byte, sealedData []byte, err error) {
+	encryptedHeader, err = ch.encryptHeader(head)
 	if err != nil {
 		return nil, nil, errors.Join(ErrEncryptHeader, err)
 	}
@@ -79,14 +248,19 @@ func (ch *Chain) Encrypt(
 		return nil, nil, errors.Join(ErrAdvanceChain, err)
 	}
 
-	auth = slices.ConcatBytes(encryptedHeader, auth)
+	sealedData, err = seal(messageKey, encryptedHeader)
+	messageKey.Wipe()
 
-	encryptedData, err = ch.cfg.crypto.EncryptMessage(messageKey, data, auth)
 	if err != nil {
-		return nil, nil, errors.Join(ErrEncryptMessage, err)
+		return nil, nil, errors.Join(ErrSeal, err)
 	}
 
-	return encryptedHeader, encryptedData, nil
+	return encryptedHeader, sealedData, nil
+}
+
+// NextMessageNumber returns the number of the next message to send.
+func (ch Chain) NextMessageNumber() uint64 {
+	return ch.nextMessageNumber
 }
 
 // PrepareHeader prepares a new header to send.
@@ -100,15 +274,34 @@ func (ch *Chain) PrepareHeader(publicKey keys.Public) header.Header {
 	return head
 }
 
-// Upgrade upgrades sending chain with new starting values.
+// Upgrade upgrades sending chain with new starting values. The old keys are wiped.
 func (ch *Chain) Upgrade(masterKey keys.Master, nextHeaderKey keys.Header) {
-	ch.masterKey = &masterKey
-	ch.headerKey = convert.ToPtr(ch.nextHeaderKey.Clone())
+	ch.wipeMasterKey()
+	ch.masterKey = ch.protectMasterKey(masterKey)
+	ch.headerKey.Wipe()
+	ch.headerKey = convert.ToPtr(ch.nextHeaderKey)
 	ch.nextHeaderKey = nextHeaderKey
 	ch.previousChainMessagesCount = ch.nextMessageNumber
 	ch.nextMessageNumber = 0
 }
 
+// Wipe wipes all keys of the chain. The chain must not be used after the call.
+func (ch *Chain) Wipe() {
+	ch.masterKey.Wipe()
+	ch.headerKey.Wipe()
+	ch.nextHeaderKey.Wipe()
+
+	if ch.scratch != nil {
+		for i := range ch.scratch.masterKeys {
+			ch.scratch.masterKeys[i].Wipe()
+		}
+
+		clear(ch.scratch.messageKey)
+		clear(ch.scratch.paddedData)
+		ch.scratch = nil
+	}
+}
+
 func (ch *Chain) advance() (keys.Message, error) {
 	if ch.masterKey == nil {
 		return keys.Message{}, ErrMasterKeyIsNil
@@ -119,8 +312,53 @@ func (ch *Chain) advance() (keys.Message, error) {
 		return keys.Message{}, errors.Join(ErrCryptoAdvanceChain, err)
 	}
 
-	ch.masterKey = &newMasterKey
+	ch.wipeMasterKey()
+	ch.masterKey = ch.protectMasterKey(newMasterKey)
 	ch.nextMessageNumber++
 
 	return messageKey, nil
 }
+
+// encryptHeader encrypts passed header with the current header key. In the plaintext header
+// mode the header is only encoded. The header is padded to the header size of the config.
+func (ch *Chain) encryptHeader(head header.Header) ([]byte, error) {
+	head.MinEncodedLen = max(head.MinEncodedLen, ch.cfg.headerSize)
+
+	if ch.cfg.plaintextHeaders {
+		encodedHeader, err := head.AppendEncode(nil)
+		if err != nil {
+			return nil, errors.Join(ErrEncodeHeader, err)
+		}
+
+		return encodedHeader, nil
+	}
+
+	if ch.headerKey == nil {
+		return nil, ErrHeaderKeyIsNil
+	}
+
+	return ch.cfg.crypto.EncryptHeader(*ch.headerKey, head)
+}
+
+// wipeMasterKey wipes the current master key, but keeps the memory of the scratch for reuse.
+func (ch *Chain) wipeMasterKey() {
+	if ch.scratch == nil {
+		ch.masterKey.Wipe()
+
+		return
+	}
+
+	ch.scratch.wipeMasterKey(ch.masterKey)
+}
+
+// protectMasterKey moves passed master key into the memory of the allocator if it is set.
+func (ch *Chain) protectMasterKey(masterKey keys.Master) *keys.Master {
+	if ch.cfg.allocator == nil {
+		return &masterKey
+	}
+
+	protectedMasterKey := masterKey.CloneTo(ch.cfg.allocator)
+	masterKey.Wipe()
+
+	return &protectedMasterKey
+}
@@ -5,11 +5,16 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/platform-source/aegis/aead"
+	"github.com/platform-source/aegis/chainscommon"
 	"github.com/platform-source/aegis/header"
 	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/tools/slices"
 	cipher "golang.org/x/crypto/chacha20poly1305"
 )
 
+var errTestEncryptMessage = errors.New("test encrypt message error")
+
 type newChainTestArgs struct {
 	masterKey                  *keys.Master
 	headerKey                  *keys.Header
@@ -429,7 +434,12 @@ func TestChainEncrypt(t *testing.T) {
 				)
 			}
 
-			if reflect.DeepEqual(encryptedHeader, header.Encode()) {
+			encodedHeader, err := header.Encode()
+			if err != nil {
+				t.Fatalf("%+v.Encode(): expected no error but got %v", header, err)
+			}
+
+			if reflect.DeepEqual(encryptedHeader, encodedHeader) {
 				t.Fatalf(
 					"%+v.Encrypt(%+v, %v, %v) returned input header bytes",
 					chain,
@@ -452,6 +462,119 @@ func TestChainEncrypt(t *testing.T) {
 	}
 }
 
+func TestChainEncryptAppend(t *testing.T) {
+	t.Parallel()
+
+	var (
+		masterKey = keys.Master{Bytes: []byte{1, 2, 3}}
+		headerKey = keys.Header{Bytes: make([]byte, cipher.KeySize)}
+		data      = []byte{4, 5, 6}
+		auth      = []byte{7, 8}
+		prefix    = []byte{9}
+	)
+
+	chain, err := New(masterKey.ClonePtr(), headerKey.ClonePtr(), keys.Header{}, 0, 0)
+	if err != nil {
+		t.Fatalf("New(): expected no error but got %v", err)
+	}
+
+	for range 3 {
+		messageMasterKey := chain.masterKey.Clone()
+		head := chain.PrepareHeader(keys.Public{Bytes: []byte{10}})
+
+		encryptedHeader, encryptedData, err := chain.EncryptAppend(prefix, prefix, head, data, auth)
+		if err != nil {
+			t.Fatalf("EncryptAppend(): expected no error but got %v", err)
+		}
+
+		if encryptedHeader[0] != prefix[0] || encryptedData[0] != prefix[0] {
+			t.Fatalf("EncryptAppend(): expected prefix %v to be kept", prefix)
+		}
+
+		crypto := newDefaultCrypto(aead.XChaCha20Poly1305())
+
+		_, messageKey, err := crypto.AdvanceChain(messageMasterKey)
+		if err != nil {
+			t.Fatalf("AdvanceChain(): expected no error but got %v", err)
+		}
+
+		cipherKey, nonce, err := chainscommon.DeriveMessageCipherKeyAndNonce(messageKey)
+		if err != nil {
+			t.Fatalf("DeriveMessageCipherKeyAndNonce(): expected no error but got %v", err)
+		}
+
+		cipherX, err := cipher.NewX(cipherKey)
+		if err != nil {
+			t.Fatalf("NewX(): expected no error but got %v", err)
+		}
+
+		decryptedData, err := cipherX.Open(
+			nil,
+			nonce,
+			encryptedData[len(prefix):],
+			slices.ConcatBytes(encryptedHeader[len(prefix):], auth),
+		)
+		if err != nil {
+			t.Fatalf("Open(): expected no error but got %v", err)
+		}
+
+		if !reflect.DeepEqual(decryptedData, data) {
+			t.Fatalf("EncryptAppend(): expected data %v but got %v", data, decryptedData)
+		}
+	}
+
+	if chain.NextMessageNumber() != 3 {
+		t.Fatalf(
+			"EncryptAppend(): expected next message number 3 but got %d",
+			chain.NextMessageNumber(),
+		)
+	}
+}
+
+type failingMessageCrypto struct {
+	testCrypto
+}
+
+func (failingMessageCrypto) EncryptMessage(_ keys.Message, _, _ []byte) ([]byte, error) {
+	return nil, errTestEncryptMessage
+}
+
+func TestChainEncryptAppendFallbackError(t *testing.T) {
+	t.Parallel()
+
+	masterKey := keys.Master{Bytes: []byte{1, 2, 3}}
+
+	chain, err := New(
+		masterKey.ClonePtr(),
+		&keys.Header{},
+		keys.Header{},
+		0,
+		0,
+		WithCrypto(failingMessageCrypto{}),
+	)
+	if err != nil {
+		t.Fatalf("New(): expected no error but got %v", err)
+	}
+
+	head := chain.PrepareHeader(keys.Public{Bytes: []byte{4}})
+
+	_, _, err = chain.EncryptAppend(nil, nil, head, []byte{5}, nil)
+	if !errors.Is(err, errTestEncryptMessage) {
+		t.Fatalf("EncryptAppend(): expected error %v but got %v", errTestEncryptMessage, err)
+	}
+
+	if chain.NextMessageNumber() != 0 {
+		t.Fatalf(
+			"EncryptAppend(): expected unchanged chain but got next message number %d",
+			chain.NextMessageNumber(),
+		)
+	}
+
+	if !reflect.DeepEqual(*chain.masterKey, masterKey) {
+		t.Fatalf("EncryptAppend(): expected master key %v but got %v", masterKey, *chain.masterKey)
+	}
+}
+
 type chainPrepareHeaderTestArgs struct {
 	publicKey                  keys.Public
 	nextMessageNumber          uint64
@@ -588,3 +711,33 @@ func TestChainUpgrade(t *testing.T) {
 		)
 	}
 }
+
+func TestChainWipe(t *testing.T) {
+	t.Parallel()
+
+	masterKey := keys.Master{Bytes: []byte{1, 2, 3}}
+	headerKey := keys.Header{Bytes: make([]byte, cipher.KeySize)}
+
+	chain, err := New(&masterKey, &headerKey, keys.Header{Bytes: []byte{4, 5, 6}}, 0, 0)
+	if err != nil {
+		t.Fatalf("New(): expected no error but got %v", err)
+	}
+
+	oldMasterKeyBytes := masterKey.Bytes
+
+	_, _, err = chain.Encrypt(header.Header{}, []byte{7, 8, 9}, nil)
+	if err != nil {
+		t.Fatalf("Encrypt(): expected no error but got %v", err)
+	}
+
+	if !reflect.DeepEqual(oldMasterKeyBytes, []byte{0, 0, 0}) {
+		t.Fatalf("Encrypt(): expected old master key to be wiped but got %v", oldMasterKeyBytes)
+	}
+
+	chain.Wipe()
+
+	if chain.masterKey.Bytes != nil || chain.headerKey.Bytes != nil ||
+		chain.nextHeaderKey.Bytes != nil {
+		t.Fatalf("Wipe(): expected all keys to be wiped but got %+v", chain)
+	}
+}
@@ -3,16 +3,25 @@ package sendingchain
 import (
 	"errors"
 
+	"github.com/platform-source/aegis/aead"
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/padding"
 	"github.com/platform-source/tools/check"
 )
 
 type config struct {
-	crypto Crypto
+	allocator        keys.Allocator
+	crypto           Crypto
+	cryptoSet        bool
+	suiteCrypto      Crypto
+	plaintextHeaders bool
+	padding          padding.Scheme
+	headerSize       int
 }
 
 func newConfig(options ...Option) (config, error) {
 	cfg := config{
-		crypto: newDefaultCrypto(),
+		crypto: newDefaultCrypto(aead.XChaCha20Poly1305()),
 	}
 
 	err := cfg.applyOptions(options...)
@@ -20,6 +29,14 @@ func newConfig(options ...Option) (config, error) {
 		return config{}, errors.Join(ErrApplyOptions, err)
 	}
 
+	if cfg.suiteCrypto != nil {
+		if cfg.cryptoSet {
+			return config{}, errors.Join(ErrApplyOptions, ErrMixedCrypto)
+		}
+
+		cfg.crypto = cfg.suiteCrypto
+	}
+
 	return cfg, nil
 }
 
@@ -37,6 +54,33 @@ func (cfg *config) applyOptions(options ...Option) error {
 // Option is the way to modify default config values.
 type Option func(cfg *config) error
 
+// WithAEAD is an option to set the default crypto with passed AEAD suite.
+func WithAEAD(suite aead.Suite) Option {
+	return func(cfg *config) error {
+		if !suite.Valid() {
+			return ErrInvalidAEAD
+		}
+
+		cfg.crypto = newDefaultCrypto(suite)
+		cfg.cryptoSet = true
+
+		return nil
+	}
+}
+
+// WithAllocator sets passed allocator, which holds the secret keys of the chain.
+func WithAllocator(allocator keys.Allocator) Option {
+	return func(cfg *config) error {
+		if check.IsNil(allocator) {
+			return ErrAllocatorIsNil
+		}
+
+		cfg.allocator = allocator
+
+		return nil
+	}
+}
+
 // WithCrypto is an option to set specific crypto to the config.
 func WithCrypto(crypto Crypto) Option {
 	return func(cfg *config) error {
@@ -45,6 +89,60 @@ func WithCrypto(crypto Crypto) Option {
 		}
 
 		cfg.crypto = crypto
+		cfg.cryptoSet = true
+
+		return nil
+	}
+}
+
+// WithHeaderSize sets the min size of the encoded headers, so the headers are padded to
+// a constant size. Longer headers, e.g. with a post-quantum KEM step, are sent as is.
+func WithHeaderSize(size int) Option {
+	return func(cfg *config) error {
+		if size <= 0 {
+			return ErrInvalidHeaderSize
+		}
+
+		cfg.headerSize = size
+
+		return nil
+	}
+}
+
+// WithPadding sets passed padding scheme of the messages. The padding is added to the data
+// before encryption, so it is hidden in the ciphertext. The callbacks of Seal are not padded.
+func WithPadding(scheme padding.Scheme) Option {
+	return func(cfg *config) error {
+		if !scheme.Valid() {
+			return ErrInvalidPadding
+		}
+
+		cfg.padding = scheme
+
+		return nil
+	}
+}
+
+// WithPlaintextHeaders enables the plaintext header mode. The headers are only encoded, so
+// they are sent in clear and authenticated with the message, and the header keys are not
+// used.
+func WithPlaintextHeaders() Option {
+	return func(cfg *config) error {
+		cfg.plaintextHeaders = true
+
+		return nil
+	}
+}
+
+// WithSuiteCrypto sets passed crypto of the ratchet suite. Unlike WithCrypto, it can not be
+// combined with WithCrypto and WithAEAD, so the chain never mixes crypto of different suites.
+func WithSuiteCrypto(crypto Crypto) Option {
+	return func(cfg *config) error {
+		if check.IsNil(crypto) {
+			return ErrCryptoIsNil
+		}
+
+		cfg.suiteCrypto = crypto
 
 		return nil
 	}
@@ -5,8 +5,10 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/platform-source/aegis/aead"
 	"github.com/platform-source/aegis/header"
 	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/padding"
 )
 
 type testCrypto struct{}
@@ -43,6 +45,56 @@ var newConfigTests = []struct {
 		nil,
 		testCrypto{},
 	},
+	{
+		"AEAD option success",
+		[]Option{
+			WithAEAD(aead.AES256GCMSIV()),
+		},
+		nil,
+		defaultCrypto{},
+	},
+	{
+		"invalid AEAD",
+		[]Option{
+			WithAEAD(aead.Suite{}),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrInvalidAEAD,
+		},
+		nil,
+	},
+	{
+		"padding option success",
+		[]Option{
+			WithPadding(padding.Padme()),
+			WithHeaderSize(64),
+		},
+		nil,
+		defaultCrypto{},
+	},
+	{
+		"invalid padding",
+		[]Option{
+			WithPadding(padding.Block(0)),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrInvalidPadding,
+		},
+		nil,
+	},
+	{
+		"invalid header size",
+		[]Option{
+			WithHeaderSize(0),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrInvalidHeaderSize,
+		},
+		nil,
+	},
 	{
 		"nil crypto",
 		[]Option{
@@ -54,6 +106,37 @@ var newConfigTests = []struct {
 		},
 		nil,
 	},
+	{
+		"suite crypto option success",
+		[]Option{
+			WithSuiteCrypto(testCrypto{}),
+		},
+		nil,
+		testCrypto{},
+	},
+	{
+		"mixed crypto",
+		[]Option{
+			WithAEAD(aead.AES256GCM()),
+			WithSuiteCrypto(testCrypto{}),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrMixedCrypto,
+		},
+		nil,
+	},
+	{
+		"nil suite crypto",
+		[]Option{
+			WithSuiteCrypto(nil),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrCryptoIsNil,
+		},
+		nil,
+	},
 }
 
 func TestNewConfig(t *testing.T) {
@@ -11,3 +11,15 @@ type Crypto interface {
 	EncryptHeader(key keys.Header, head header.Header) ([]byte, error)
 	EncryptMessage(key keys.Message, message, auth []byte) ([]byte, error)
 }
+
+// AppendCrypto is the optional interface of the sending chain crypto, which appends its
+// outputs to passed buffers. The chain uses it to encrypt without allocations.
+type AppendCrypto interface {
+	AdvanceChainAppend(
+		dstMasterKey []byte,
+		dstMessageKey []byte,
+		masterKey keys.Master,
+	) (keys.Master, keys.Message, error)
+	EncryptHeaderAppend(dst []byte, key keys.Header, head header.Header) ([]byte, error)
+	EncryptMessageAppend(dst []byte, key keys.Message, message, auth []byte) ([]byte, error)
+}
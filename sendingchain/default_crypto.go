@@ -1,110 +1,116 @@
 package sendingchain
 
 import (
-	"crypto/hmac"
 	"crypto/rand"
 	"errors"
-	"hash"
+	"slices"
 
+	"github.com/platform-source/aegis/aead"
 	"github.com/platform-source/aegis/chainscommon"
 	"github.com/platform-source/aegis/header"
 	"github.com/platform-source/aegis/keys"
-	"github.com/platform-source/tools/slices"
-	"golang.org/x/crypto/blake2b"
-	cipher "golang.org/x/crypto/chacha20poly1305"
 )
 
-type defaultCrypto struct{}
-
-func newDefaultCrypto() defaultCrypto {
-	crypto := defaultCrypto{}
-
-	return crypto
+type defaultCrypto struct {
+	aead aead.Suite
 }
 
-func (defaultCrypto) AdvanceChain(masterKey keys.Master) (keys.Master, keys.Message, error) {
-	const (
-		masterKeyByte  = 0x02
-		messageKeyByte = 0x01
-	)
-
-	var newHashErr error
-
-	mac := hmac.New(
-		func() hash.Hash {
-			hasher, err := blake2b.New512(nil)
-			newHashErr = err
-
-			return hasher
-		},
-		masterKey.Bytes,
-	)
-
-	_, err := mac.Write([]byte{masterKeyByte})
-	if err != nil {
-		return keys.Master{}, keys.Message{}, errors.Join(ErrWriteMasterKeyByteToMAC, err)
-	}
+// NewDefaultCrypto returns the default crypto of the chain with passed AEAD suite.
+func NewDefaultCrypto(suite aead.Suite) Crypto {
+	return newDefaultCrypto(suite)
+}
 
-	newMasterKey := keys.Master{
-		Bytes: mac.Sum(nil),
+func newDefaultCrypto(suite aead.Suite) defaultCrypto {
+	crypto := defaultCrypto{
+		aead: suite,
 	}
-	mac.Reset()
 
-	_, err = mac.Write([]byte{messageKeyByte})
-	if err != nil {
-		return keys.Master{}, keys.Message{}, errors.Join(ErrWriteMessageKeyByteToMAC, err)
-	}
+	return crypto
+}
 
-	messageKey := keys.Message{
-		Bytes: mac.Sum(nil),
-	}
+func (c defaultCrypto) AdvanceChain(masterKey keys.Master) (keys.Master, keys.Message, error) {
+	return c.AdvanceChainAppend(nil, nil, masterKey)
+}
 
-	if newHashErr != nil {
-		return keys.Master{}, keys.Message{}, errors.Join(ErrNewHasher, newHashErr)
-	}
+func (defaultCrypto) AdvanceChainAppend(
+	dstMasterKey []byte,
+	dstMessageKey []byte,
+	masterKey keys.Master,
+) (keys.Master, keys.Message, error) {
+	newMasterKey, messageKey := chainscommon.AdvanceChainAppend(
+		dstMasterKey,
+		dstMessageKey,
+		masterKey,
+	)
 
 	return newMasterKey, messageKey, nil
 }
 
 func (c defaultCrypto) EncryptHeader(key keys.Header, head header.Header) ([]byte, error) {
-	var nonce [cipher.NonceSizeX]byte
+	return c.EncryptHeaderAppend(nil, key, head)
+}
+
+func (c defaultCrypto) EncryptHeaderAppend(
+	dst []byte,
+	key keys.Header,
+	head header.Header,
+) ([]byte, error) {
+	nonceStart := len(dst)
+	headerStart := nonceStart + c.aead.NonceSize()
 
-	_, err := rand.Read(nonce[:])
+	// The header is encoded right after the nonce and encrypted in place.
+	dst = slices.Grow(dst, c.aead.NonceSize()+head.EncodedLen()+c.aead.Overhead())
+	dst = dst[:headerStart]
+
+	_, err := rand.Read(dst[nonceStart:headerStart])
 	if err != nil {
 		return nil, errors.Join(ErrGenerateNonce, err)
 	}
 
-	encryptedHeader, err := c.encrypt(key.Bytes, nonce[:], head.Encode(), nil)
+	dst, err = head.AppendEncode(dst)
 	if err != nil {
-		return nil, errors.Join(ErrEncrypt, err)
+		return nil, errors.Join(ErrEncodeHeader, err)
 	}
 
-	encryptedHeader = slices.ConcatBytes(nonce[:], encryptedHeader)
+	nonce, encodedHeader := dst[nonceStart:headerStart], dst[headerStart:]
 
-	return encryptedHeader, nil
+	dst, err = c.encrypt(dst[:headerStart], key.Bytes, nonce, encodedHeader, nil)
+	if err != nil {
+		return nil, errors.Join(ErrEncrypt, err)
+	}
+
+	return dst, nil
 }
 
 func (c defaultCrypto) EncryptMessage(key keys.Message, message, auth []byte) ([]byte, error) {
-	cipherKey, nonce, err := chainscommon.DeriveMessageCipherKeyAndNonce(key)
-	if err != nil {
-		return nil, errors.Join(ErrDeriveMessageCipherKeyAndNonce, err)
-	}
+	return c.EncryptMessageAppend(nil, key, message, auth)
+}
+
+func (c defaultCrypto) EncryptMessageAppend(
+	dst []byte,
+	key keys.Message,
+	message []byte,
+	auth []byte,
+) ([]byte, error) {
+	var kdfOutput [chainscommon.MaxMessageCipherKeyAndNonceSize]byte
+	defer clear(kdfOutput[:])
 
-	encryptedMessage, err := c.encrypt(cipherKey, nonce, message, auth)
+	cipherKeyAndNonce := chainscommon.AppendMessageCipherKeyAndNonce(kdfOutput[:0], c.aead, key)
+	cipherKey, nonce := cipherKeyAndNonce[:c.aead.KeySize()], cipherKeyAndNonce[c.aead.KeySize():]
+
+	dst, err := c.encrypt(dst, cipherKey, nonce, message, auth)
 	if err != nil {
 		return nil, errors.Join(ErrEncrypt, err)
 	}
 
-	return encryptedMessage, nil
+	return dst, nil
 }
 
-func (defaultCrypto) encrypt(key, nonce, data, auth []byte) ([]byte, error) {
-	cipherX, err := cipher.NewX(key)
+func (c defaultCrypto) encrypt(dst, key, nonce, data, auth []byte) ([]byte, error) {
+	encryptedData, err := c.aead.Seal(dst, key, nonce, data, auth)
 	if err != nil {
 		return nil, errors.Join(ErrNewCipher, err)
 	}
 
-	encryptedData := cipherX.Seal(nil, nonce, data, auth)
-
 	return encryptedData, nil
 }
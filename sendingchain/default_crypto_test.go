@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/platform-source/aegis/aead"
 	"github.com/platform-source/aegis/header"
 	"github.com/platform-source/aegis/keys"
 	cipher "golang.org/x/crypto/chacha20poly1305"
@@ -29,7 +30,7 @@ var defaultCryptoAdvanceChainTests = []struct {
 func TestDefaultCryptoAdvanceChain(t *testing.T) {
 	t.Parallel()
 
-	crypto := newDefaultCrypto()
+	crypto := newDefaultCrypto(aead.XChaCha20Poly1305())
 
 	for _, test := range defaultCryptoAdvanceChainTests {
 		t.Run(test.name, func(t *testing.T) {
@@ -114,7 +115,7 @@ var defaultCryptoEncryptHeaderTests = []struct {
 func TestDefaultCryptoEncryptHeader(t *testing.T) {
 	t.Parallel()
 
-	crypto := newDefaultCrypto()
+	crypto := newDefaultCrypto(aead.XChaCha20Poly1305())
 
 	for _, test := range defaultCryptoEncryptHeaderTests {
 		t.Run(test.name, func(t *testing.T) {
@@ -202,7 +203,7 @@ var defaultCryptoEncryptMessageTests = []struct {
 func TestDefaultCryptoEncryptMessage(t *testing.T) {
 	t.Parallel()
 
-	crypto := newDefaultCrypto()
+	crypto := newDefaultCrypto(aead.XChaCha20Poly1305())
 
 	for _, test := range defaultCryptoEncryptMessageTests {
 		t.Run(test.name, func(t *testing.T) {
@@ -8,9 +8,15 @@ var (
 	// ErrAdvanceChain is the chain advance error.
 	ErrAdvanceChain = errors.New("advance chain")
 
+	// ErrAllocatorIsNil is the nil allocator error.
+	ErrAllocatorIsNil = errors.New("allocator is nil")
+
 	// ErrApplyOptions is the config options apply error.
 	ErrApplyOptions = errors.New("apply options")
 
+	// ErrBuildBytes is the bytes building error.
+	ErrBuildBytes = errors.New("build bytes")
+
 	// ErrCryptoAdvanceChain is an error to advance using crypto from config.
 	ErrCryptoAdvanceChain = errors.New("crypto advance chain")
 
@@ -20,6 +26,9 @@ var (
 	// ErrDeriveMessageCipherKeyAndNonce is the key and nonce derivation error.
 	ErrDeriveMessageCipherKeyAndNonce = errors.New("derive message cipher key and nonce")
 
+	// ErrEncodeHeader is the header encoding error.
+	ErrEncodeHeader = errors.New("encode header")
+
 	// ErrEncrypt is the encryption error.
 	ErrEncrypt = errors.New("encrypt")
 
@@ -35,9 +44,27 @@ var (
 	// ErrHeaderKeyIsNil is the header key nil error.
 	ErrHeaderKeyIsNil = errors.New("header key is nil")
 
+	// ErrInvalidAEAD is an error when the AEAD suite is not valid.
+	ErrInvalidAEAD = errors.New("invalid AEAD")
+
+	// ErrInvalidEncoding is an error when encoded state is malformed.
+	ErrInvalidEncoding = errors.New("invalid encoding")
+
+	// ErrInvalidHeaderSize is an error when the header size is not positive.
+	ErrInvalidHeaderSize = errors.New("invalid header size")
+
+	// ErrInvalidPadding is an error when the padding scheme is not valid.
+	ErrInvalidPadding = errors.New("invalid padding")
+
 	// ErrMasterKeyIsNil is the master key nil error.
 	ErrMasterKeyIsNil = errors.New("master key is nil")
 
+	// ErrMixedCrypto is an error when the suite crypto is combined with other crypto options.
+	ErrMixedCrypto = errors.New("mixed crypto")
+
+	// ErrNewChain is the chain initialization error.
+	ErrNewChain = errors.New("new chain")
+
 	// ErrNewCipher is the cipher initialization error.
 	ErrNewCipher = errors.New("new cipher")
 
@@ -47,6 +74,12 @@ var (
 	// ErrNewHasher is the hasher initialization error.
 	ErrNewHasher = errors.New("new hasher")
 
+	// ErrSeal is the message sealing error.
+	ErrSeal = errors.New("seal")
+
+	// ErrUnsupportedVersion is an error when encoded state has unknown version.
+	ErrUnsupportedVersion = errors.New("unsupported version")
+
 	// ErrWriteMasterKeyByteToMAC is the master key byte write error.
 	ErrWriteMasterKeyByteToMAC = errors.New("write master key byte to MAC")
 
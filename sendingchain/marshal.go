@@ -0,0 +1,52 @@
+package sendingchain
+
+import (
+	"errors"
+
+	"github.com/platform-source/aegis/internal/chainstate"
+)
+
+// MarshalBinary encodes the sending chain state to bytes.
+//
+// Note that the config is not encoded, so the same options must be passed to Unmarshal.
+func (ch Chain) MarshalBinary() ([]byte, error) {
+	data, err := chainstate.MarshalSending(chainstate.Sending{
+		MasterKey:                  ch.masterKey,
+		HeaderKey:                  ch.headerKey,
+		NextHeaderKey:              ch.nextHeaderKey,
+		NextMessageNumber:          ch.nextMessageNumber,
+		PreviousChainMessagesCount: ch.previousChainMessagesCount,
+	})
+	if err != nil {
+		return nil, errors.Join(ErrBuildBytes, err)
+	}
+
+	return data, nil
+}
+
+// Unmarshal decodes the sending chain state from bytes produced by MarshalBinary and
+// applies passed options to it.
+func Unmarshal(data []byte, options ...Option) (Chain, error) {
+	state, err := chainstate.UnmarshalSending(data)
+
+	switch {
+	case errors.Is(err, chainstate.ErrUnsupportedVersion):
+		return Chain{}, ErrUnsupportedVersion
+	case err != nil:
+		return Chain{}, ErrInvalidEncoding
+	}
+
+	chain, err := New(
+		state.MasterKey,
+		state.HeaderKey,
+		state.NextHeaderKey,
+		state.NextMessageNumber,
+		state.PreviousChainMessagesCount,
+		options...,
+	)
+	if err != nil {
+		return Chain{}, errors.Join(ErrNewChain, err)
+	}
+
+	return chain, nil
+}
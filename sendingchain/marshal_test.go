@@ -0,0 +1,129 @@
+package sendingchain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/platform-source/aegis/keys"
+)
+
+var chainMarshalBinaryTests = []struct {
+	name                       string
+	masterKey                  *keys.Master
+	headerKey                  *keys.Header
+	nextHeaderKey              keys.Header
+	nextMessageNumber          uint64
+	previousChainMessagesCount uint64
+}{
+	{
+		"nil keys",
+		nil,
+		nil,
+		keys.Header{
+			Bytes: []byte{7, 8, 9},
+		},
+		0,
+		0,
+	},
+	{
+		"full keys",
+		&keys.Master{
+			Bytes: []byte{1, 2, 3},
+		},
+		&keys.Header{
+			Bytes: []byte{4, 5, 6},
+		},
+		keys.Header{
+			Bytes: []byte{7, 8, 9},
+		},
+		12,
+		201,
+	},
+}
+
+func TestChainMarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range chainMarshalBinaryTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			chain, err := New(
+				test.masterKey,
+				test.headerKey,
+				test.nextHeaderKey,
+				test.nextMessageNumber,
+				test.previousChainMessagesCount,
+			)
+			if err != nil {
+				t.Fatalf("New(): expected no error but got %v", err)
+			}
+
+			data, err := chain.MarshalBinary()
+			if err != nil {
+				t.Fatalf("%+v.MarshalBinary(): expected no error but got %v", chain, err)
+			}
+
+			restored, err := Unmarshal(data)
+			if err != nil {
+				t.Fatalf("Unmarshal(%v): expected no error but got %v", data, err)
+			}
+
+			if !reflect.DeepEqual(restored, chain) {
+				t.Fatalf("Unmarshal(%v): expected %+v but got %+v", data, chain, restored)
+			}
+		})
+	}
+}
+
+var unmarshalTests = []struct {
+	name          string
+	data          []byte
+	errCategories []error
+}{
+	{
+		"nil data",
+		nil,
+		[]error{
+			ErrInvalidEncoding,
+		},
+	},
+	{
+		"unsupported version",
+		[]byte{0xFF},
+		[]error{
+			ErrUnsupportedVersion,
+		},
+	},
+	{
+		"truncated master key",
+		[]byte{0x01, 0x01, 0x00, 0x03, 0x01},
+		[]error{
+			ErrInvalidEncoding,
+		},
+	},
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range unmarshalTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Unmarshal(test.data)
+
+			for _, errCategory := range test.errCategories {
+				if !errors.Is(err, errCategory) {
+					t.Fatalf(
+						"Unmarshal(%v): expected error %v but got %v",
+						test.data,
+						errCategory,
+						err,
+					)
+				}
+			}
+		})
+	}
+}
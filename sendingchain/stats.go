@@ -0,0 +1,24 @@
+package sendingchain
+
+// Stats are the statistics of the sending chain.
+type Stats struct {
+	// Active reports whether the chain has the master key, so it can encrypt.
+	Active bool
+
+	// NextMessageNumber is the number of the next message to send.
+	NextMessageNumber uint64
+
+	// PreviousChainMessagesCount is the count of the messages sent with the previous chain.
+	PreviousChainMessagesCount uint64
+}
+
+// Stats returns the statistics of the chain.
+func (ch Chain) Stats() Stats {
+	stats := Stats{
+		Active:                     ch.masterKey != nil,
+		NextMessageNumber:          ch.nextMessageNumber,
+		PreviousChainMessagesCount: ch.previousChainMessagesCount,
+	}
+
+	return stats
+}
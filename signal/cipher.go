@@ -0,0 +1,71 @@
+package signal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+)
+
+const (
+	ivSize  = aes.BlockSize
+	macSize = sha256.Size
+)
+
+// seal encrypts passed plaintext with AES-256-CBC and PKCS#7 padding and appends the
+// ciphertext with HMAC-SHA256 of the associated data and the ciphertext to dst.
+func seal(dst []byte, derivedKeys cipherKeys, plaintext, associatedData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(derivedKeys.cipherKey)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidKeySize, err)
+	}
+
+	paddingLen := aes.BlockSize - len(plaintext)%aes.BlockSize
+	ciphertextStart := len(dst)
+
+	dst = append(dst, plaintext...)
+	for range paddingLen {
+		dst = append(dst, byte(paddingLen))
+	}
+
+	ciphertext := dst[ciphertextStart:]
+	cipher.NewCBCEncrypter(block, derivedKeys.iv).CryptBlocks(ciphertext, ciphertext)
+
+	return append(dst, computeHMAC(derivedKeys.macKey, associatedData, ciphertext)...), nil
+}
+
+// open authenticates and decrypts data produced by seal. The MAC is checked before the
+// decryption, so the padding is never inspected for forged data.
+func open(derivedKeys cipherKeys, data, associatedData []byte) ([]byte, error) {
+	if len(data) < aes.BlockSize+macSize || (len(data)-macSize)%aes.BlockSize != 0 {
+		return nil, ErrInvalidCiphertext
+	}
+
+	ciphertext, mac := data[:len(data)-macSize], data[len(data)-macSize:]
+
+	if !hmac.Equal(mac, computeHMAC(derivedKeys.macKey, associatedData, ciphertext)) {
+		return nil, ErrInvalidMAC
+	}
+
+	block, err := aes.NewCipher(derivedKeys.cipherKey)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidKeySize, err)
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, derivedKeys.iv).CryptBlocks(plaintext, ciphertext)
+
+	paddingLen := int(plaintext[len(plaintext)-1])
+	if paddingLen == 0 || paddingLen > aes.BlockSize {
+		return nil, ErrInvalidPadding
+	}
+
+	for _, paddingByte := range plaintext[len(plaintext)-paddingLen:] {
+		if int(paddingByte) != paddingLen {
+			return nil, ErrInvalidPadding
+		}
+	}
+
+	return plaintext[:len(plaintext)-paddingLen], nil
+}
@@ -0,0 +1,122 @@
+package signal
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/platform-source/aegis/keys"
+)
+
+func newTestCipherKeys(t *testing.T) cipherKeys {
+	t.Helper()
+
+	derivedKeys, err := deriveMessageKeys(keys.Message{Bytes: newTestBytes(0x60, KeySize)})
+	if err != nil {
+		t.Fatalf("deriveMessageKeys(): expected no error but got %v", err)
+	}
+
+	return derivedKeys
+}
+
+func TestSeal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		plaintext      []byte
+		associatedData []byte
+		expected       string
+	}{
+		{
+			"partial block",
+			[]byte("hello signal"),
+			[]byte("associated data"),
+			"b33cb75af5f409e245e46b6182007088" +
+				"f9211d95d3936ee52475661dadbf78eec73b36ed848848edff8d99c7a2392127",
+		},
+		{
+			"full block",
+			[]byte("0123456789abcdef"),
+			nil,
+			"43575b2ff8ae7b6d859e2cf75c5d893b3d61c38460e6b1f2c9f076c63406d4c0" +
+				"b849cb0acd751cb6fd06d5888b6e30d4bc90cf548859dd6768f0a8369ad2d4cc",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			derivedKeys := newTestCipherKeys(t)
+
+			sealed, err := seal(nil, derivedKeys, test.plaintext, test.associatedData)
+			if err != nil {
+				t.Fatalf("seal(): expected no error but got %v", err)
+			}
+
+			if hex.EncodeToString(sealed) != test.expected {
+				t.Fatalf("seal(): expected %s but got %x", test.expected, sealed)
+			}
+
+			opened, err := open(derivedKeys, sealed, test.associatedData)
+			if err != nil {
+				t.Fatalf("open(): expected no error but got %v", err)
+			}
+
+			if !bytes.Equal(opened, test.plaintext) {
+				t.Fatalf("open(): expected %q but got %q", test.plaintext, opened)
+			}
+		})
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	t.Parallel()
+
+	derivedKeys := newTestCipherKeys(t)
+
+	sealed, err := seal(nil, derivedKeys, []byte("data"), []byte("auth"))
+	if err != nil {
+		t.Fatalf("seal(): expected no error but got %v", err)
+	}
+
+	forged := bytes.Clone(sealed)
+	forged[0] ^= 1
+
+	block, err := aes.NewCipher(derivedKeys.cipherKey)
+	if err != nil {
+		t.Fatalf("NewCipher(): expected no error but got %v", err)
+	}
+
+	badPadding := bytes.Repeat([]byte{0xff}, aes.BlockSize)
+	cipher.NewCBCEncrypter(block, derivedKeys.iv).CryptBlocks(badPadding, badPadding)
+	badPadding = append(badPadding, computeHMAC(derivedKeys.macKey, badPadding)...)
+
+	tests := []struct {
+		name           string
+		data           []byte
+		associatedData []byte
+		expectedErr    error
+	}{
+		{"too short", sealed[:macSize], []byte("auth"), ErrInvalidCiphertext},
+		{"not block aligned", sealed[1:], []byte("auth"), ErrInvalidCiphertext},
+		{"forged ciphertext", forged, []byte("auth"), ErrInvalidMAC},
+		{"wrong associated data", sealed, []byte("other"), ErrInvalidMAC},
+		{"invalid padding", badPadding, nil, ErrInvalidPadding},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := open(derivedKeys, test.data, test.associatedData)
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("open(): expected error %v but got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package signal
+
+import (
+	"crypto/rand"
+	"errors"
+
+	"github.com/platform-source/aegis/header"
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/receivingchain"
+	"github.com/platform-source/aegis/rootchain"
+	"github.com/platform-source/aegis/sendingchain"
+)
+
+type rootCrypto struct{}
+
+// NewRootCrypto returns the root chain crypto of the Signal specification with header
+// encryption. The root KDF is HKDF-SHA256 with the "WhisperRatchet" info, and it derives
+// the next header key after the root key and the chain key.
+func NewRootCrypto() rootchain.Crypto {
+	return rootCrypto{}
+}
+
+func (rootCrypto) AdvanceChain(
+	rootKey keys.Root,
+	sharedKey keys.Shared,
+) (keys.Root, keys.Master, keys.Header, error) {
+	return advanceRootChain(rootKey, sharedKey, true)
+}
+
+type plaintextHeadersRootCrypto struct{}
+
+// NewPlaintextHeadersRootCrypto returns the root chain crypto of the Signal specification
+// without header encryption. It is KDF_RK of the specification: HKDF-SHA256 with the
+// "WhisperRatchet" info, which derives only the root key and the chain key, so the next
+// header key is empty.
+func NewPlaintextHeadersRootCrypto() rootchain.Crypto {
+	return plaintextHeadersRootCrypto{}
+}
+
+func (plaintextHeadersRootCrypto) AdvanceChain(
+	rootKey keys.Root,
+	sharedKey keys.Shared,
+) (keys.Root, keys.Master, keys.Header, error) {
+	return advanceRootChain(rootKey, sharedKey, false)
+}
+
+type sendingCrypto struct{}
+
+// NewSendingCrypto returns the sending chain crypto of the Signal specification. The chain
+// KDF is HMAC-SHA256 and the messages are encrypted with AES-256-CBC and HMAC-SHA256 with
+// the keys derived by HKDF-SHA256 from the message key. The headers are encrypted the same
+// way with the keys derived from the header key and a random IV, which is prepended to
+// the header.
+//
+// Only the cryptographic algorithms follow the specification. The header encoding and
+// the message framing are of this package, so the messages are not compatible with other
+// implementations, e.g. libsignal.
+func NewSendingCrypto() sendingchain.Crypto {
+	return sendingCrypto{}
+}
+
+func (sendingCrypto) AdvanceChain(masterKey keys.Master) (keys.Master, keys.Message, error) {
+	newMasterKey, messageKey := advanceChain(masterKey)
+
+	return newMasterKey, messageKey, nil
+}
+
+func (sendingCrypto) EncryptHeader(key keys.Header, head header.Header) ([]byte, error) {
+	derivedKeys, err := deriveHeaderKeys(key)
+	if err != nil {
+		return nil, err
+	}
+	defer derivedKeys.wipe()
+
+	derivedKeys.iv = make([]byte, ivSize)
+
+	_, err = rand.Read(derivedKeys.iv)
+	if err != nil {
+		return nil, errors.Join(ErrGenerateIV, err)
+	}
+
+	encodedHeader, err := head.AppendEncode(nil)
+	if err != nil {
+		return nil, errors.Join(ErrEncodeHeader, err)
+	}
+
+	return seal(append([]byte(nil), derivedKeys.iv...), derivedKeys, encodedHeader, derivedKeys.iv)
+}
+
+func (sendingCrypto) EncryptMessage(key keys.Message, message, auth []byte) ([]byte, error) {
+	derivedKeys, err := deriveMessageKeys(key)
+	if err != nil {
+		return nil, err
+	}
+	defer derivedKeys.wipe()
+
+	return seal(nil, derivedKeys, message, auth)
+}
+
+type receivingCrypto struct{}
+
+// NewReceivingCrypto returns the receiving chain crypto, which matches NewSendingCrypto.
+func NewReceivingCrypto() receivingchain.Crypto {
+	return receivingCrypto{}
+}
+
+func (receivingCrypto) AdvanceChain(masterKey keys.Master) (keys.Master, keys.Message, error) {
+	newMasterKey, messageKey := advanceChain(masterKey)
+
+	return newMasterKey, messageKey, nil
+}
+
+func (receivingCrypto) DecryptHeader(
+	key keys.Header,
+	encryptedHeader []byte,
+) (header.Header, error) {
+	if len(encryptedHeader) < ivSize {
+		return header.Header{}, ErrInvalidCiphertext
+	}
+
+	derivedKeys, err := deriveHeaderKeys(key)
+	if err != nil {
+		return header.Header{}, err
+	}
+	defer derivedKeys.wipe()
+
+	iv := encryptedHeader[:ivSize]
+	derivedKeys.iv = append([]byte(nil), iv...)
+
+	encodedHeader, err := open(derivedKeys, encryptedHeader[ivSize:], iv)
+	if err != nil {
+		return header.Header{}, err
+	}
+
+	head, err := header.Decode(encodedHeader)
+	if err != nil {
+		return header.Header{}, errors.Join(ErrDecodeHeader, err)
+	}
+
+	return head, nil
+}
+
+func (receivingCrypto) DecryptMessage(
+	key keys.Message,
+	encryptedMessage []byte,
+	auth []byte,
+) ([]byte, error) {
+	derivedKeys, err := deriveMessageKeys(key)
+	if err != nil {
+		return nil, err
+	}
+	defer derivedKeys.wipe()
+
+	return open(derivedKeys, encryptedMessage, auth)
+}
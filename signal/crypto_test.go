@@ -0,0 +1,98 @@
+package signal
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/platform-source/aegis/header"
+	"github.com/platform-source/aegis/keys"
+)
+
+func TestCryptoHeader(t *testing.T) {
+	t.Parallel()
+
+	headerKey := keys.Header{Bytes: newTestBytes(0x80, KeySize)}
+	head := header.Header{
+		PublicKey:                         keys.Public{Bytes: newTestBytes(0xa0, KeySize)},
+		PreviousSendingChainMessagesCount: 3,
+		MessageNumber:                     7,
+	}
+
+	encryptedHeader, err := NewSendingCrypto().EncryptHeader(headerKey, head)
+	if err != nil {
+		t.Fatalf("EncryptHeader(): expected no error but got %v", err)
+	}
+
+	otherEncryptedHeader, err := NewSendingCrypto().EncryptHeader(headerKey, head)
+	if err != nil {
+		t.Fatalf("EncryptHeader(): expected no error but got %v", err)
+	}
+
+	if bytes.Equal(encryptedHeader, otherEncryptedHeader) {
+		t.Fatal("EncryptHeader(): expected random IV")
+	}
+
+	decryptedHeader, err := NewReceivingCrypto().DecryptHeader(headerKey, encryptedHeader)
+	if err != nil {
+		t.Fatalf("DecryptHeader(): expected no error but got %v", err)
+	}
+
+	if !reflect.DeepEqual(decryptedHeader, head) {
+		t.Fatalf("DecryptHeader(): expected %+v but got %+v", head, decryptedHeader)
+	}
+
+	otherHeaderKey := keys.Header{Bytes: newTestBytes(0x81, KeySize)}
+
+	_, err = NewReceivingCrypto().DecryptHeader(otherHeaderKey, encryptedHeader)
+	if !errors.Is(err, ErrInvalidMAC) {
+		t.Fatalf("DecryptHeader(): expected error %v but got %v", ErrInvalidMAC, err)
+	}
+
+	_, err = NewReceivingCrypto().DecryptHeader(headerKey, encryptedHeader[:ivSize-1])
+	if !errors.Is(err, ErrInvalidCiphertext) {
+		t.Fatalf("DecryptHeader(): expected error %v but got %v", ErrInvalidCiphertext, err)
+	}
+}
+
+func TestCryptoMessage(t *testing.T) {
+	t.Parallel()
+
+	masterKey := keys.Master{Bytes: newTestBytes(0x40, KeySize)}
+
+	sendingMasterKey, sendingMessageKey, err := NewSendingCrypto().AdvanceChain(masterKey)
+	if err != nil {
+		t.Fatalf("AdvanceChain(): expected no error but got %v", err)
+	}
+
+	receivingMasterKey, receivingMessageKey, err := NewReceivingCrypto().AdvanceChain(masterKey)
+	if err != nil {
+		t.Fatalf("AdvanceChain(): expected no error but got %v", err)
+	}
+
+	if !bytes.Equal(sendingMasterKey.Bytes, receivingMasterKey.Bytes) ||
+		!bytes.Equal(sendingMessageKey.Bytes, receivingMessageKey.Bytes) {
+		t.Fatal("AdvanceChain(): expected same keys of the sending and receiving chains")
+	}
+
+	data := []byte("data")
+
+	encryptedData, err := NewSendingCrypto().EncryptMessage(sendingMessageKey, data, []byte("auth"))
+	if err != nil {
+		t.Fatalf("EncryptMessage(): expected no error but got %v", err)
+	}
+
+	decryptedData, err := NewReceivingCrypto().DecryptMessage(
+		receivingMessageKey,
+		encryptedData,
+		[]byte("auth"),
+	)
+	if err != nil {
+		t.Fatalf("DecryptMessage(): expected no error but got %v", err)
+	}
+
+	if !bytes.Equal(decryptedData, data) {
+		t.Fatalf("DecryptMessage(): expected %q but got %q", data, decryptedData)
+	}
+}
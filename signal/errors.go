@@ -0,0 +1,31 @@
+package signal
+
+import (
+	"errors"
+)
+
+var (
+	// ErrDecodeHeader is the header decoding error.
+	ErrDecodeHeader = errors.New("decode header")
+
+	// ErrEncodeHeader is the header encoding error.
+	ErrEncodeHeader = errors.New("encode header")
+
+	// ErrGenerateIV is the initialization vector generation error.
+	ErrGenerateIV = errors.New("generate IV")
+
+	// ErrInvalidCiphertext is an error when the ciphertext is too short or not block aligned.
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+	// ErrInvalidKeySize is an error when the key has unexpected size.
+	ErrInvalidKeySize = errors.New("invalid key size")
+
+	// ErrInvalidMAC is an error when the message authentication code does not match.
+	ErrInvalidMAC = errors.New("invalid MAC")
+
+	// ErrInvalidPadding is an error when the PKCS#7 padding is malformed.
+	ErrInvalidPadding = errors.New("invalid padding")
+
+	// ErrKDF is the key derivation error.
+	ErrKDF = errors.New("KDF")
+)
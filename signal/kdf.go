@@ -0,0 +1,150 @@
+package signal
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"github.com/platform-source/aegis/keys"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// KeySize is the size of the root, chain, message and header keys.
+	KeySize = 32
+
+	// chainKeyByte and messageKeyByte are the HMAC inputs of the chain KDF.
+	chainKeyByte   = 0x02
+	messageKeyByte = 0x01
+)
+
+var (
+	rootKDFInfo        = []byte("WhisperRatchet")
+	messageKeysKDFInfo = []byte("WhisperMessageKeys")
+	headerKeysKDFInfo  = []byte("WhisperHeaderKeys")
+)
+
+// cipherKeys are the keys of AES-256-CBC with HMAC-SHA256.
+type cipherKeys struct {
+	cipherKey []byte
+	macKey    []byte
+	iv        []byte
+}
+
+// wipe zeroes the keys.
+func (k cipherKeys) wipe() {
+	clear(k.cipherKey)
+	clear(k.macKey)
+	clear(k.iv)
+}
+
+// advanceRootChain derives the new root key, the chain key and the next header key with
+// HKDF-SHA256, where the root key is the salt and the Diffie-Hellman output is the input
+// key material. The first 64 bytes are KDF_RK of the specification, the header key is
+// derived only with header encryption.
+func advanceRootChain(
+	rootKey keys.Root,
+	sharedKey keys.Shared,
+	withHeaderKey bool,
+) (keys.Root, keys.Master, keys.Header, error) {
+	outputLen := 2 * KeySize
+	if withHeaderKey {
+		outputLen += KeySize
+	}
+
+	kdfOutput := make([]byte, outputLen)
+
+	kdf := hkdf.New(sha256.New, sharedKey.Bytes, rootKey.Bytes, rootKDFInfo)
+
+	_, err := io.ReadFull(kdf, kdfOutput)
+	if err != nil {
+		return keys.Root{}, keys.Master{}, keys.Header{}, errors.Join(ErrKDF, err)
+	}
+
+	newRootKey := keys.Root{
+		Bytes: kdfOutput[:KeySize],
+	}
+
+	masterKey := keys.Master{
+		Bytes: kdfOutput[KeySize : 2*KeySize],
+	}
+
+	var nextHeaderKey keys.Header
+	if withHeaderKey {
+		nextHeaderKey.Bytes = kdfOutput[2*KeySize:]
+	}
+
+	return newRootKey, masterKey, nextHeaderKey, nil
+}
+
+// advanceChain derives the next chain key and the message key with HMAC-SHA256 of the
+// chain key with the constants of the Signal specification.
+func advanceChain(masterKey keys.Master) (keys.Master, keys.Message) {
+	newMasterKey := keys.Master{
+		Bytes: computeHMAC(masterKey.Bytes, []byte{chainKeyByte}),
+	}
+
+	messageKey := keys.Message{
+		Bytes: computeHMAC(masterKey.Bytes, []byte{messageKeyByte}),
+	}
+
+	return newMasterKey, messageKey
+}
+
+// deriveMessageKeys derives the cipher key, the MAC key and the IV of the message from
+// the message key with HKDF-SHA256 and the "WhisperMessageKeys" info, as the specification
+// recommends.
+func deriveMessageKeys(messageKey keys.Message) (cipherKeys, error) {
+	kdfOutput, err := expand(messageKey.Bytes, messageKeysKDFInfo, 2*KeySize+ivSize)
+	if err != nil {
+		return cipherKeys{}, err
+	}
+
+	derivedKeys := cipherKeys{
+		cipherKey: kdfOutput[:KeySize],
+		macKey:    kdfOutput[KeySize : 2*KeySize],
+		iv:        kdfOutput[2*KeySize:],
+	}
+
+	return derivedKeys, nil
+}
+
+// deriveHeaderKeys derives the cipher key and the MAC key of the headers from the header
+// key. The header key is used for many headers, so the IV is random.
+func deriveHeaderKeys(headerKey keys.Header) (cipherKeys, error) {
+	kdfOutput, err := expand(headerKey.Bytes, headerKeysKDFInfo, 2*KeySize)
+	if err != nil {
+		return cipherKeys{}, err
+	}
+
+	derivedKeys := cipherKeys{
+		cipherKey: kdfOutput[:KeySize],
+		macKey:    kdfOutput[KeySize:],
+	}
+
+	return derivedKeys, nil
+}
+
+// expand derives outputLen bytes from passed key with HKDF-SHA256 and zero salt.
+func expand(key []byte, info []byte, outputLen int) ([]byte, error) {
+	kdfOutput := make([]byte, outputLen)
+
+	_, err := io.ReadFull(hkdf.New(sha256.New, key, nil, info), kdfOutput)
+	if err != nil {
+		return nil, errors.Join(ErrKDF, err)
+	}
+
+	return kdfOutput, nil
+}
+
+// computeHMAC returns HMAC-SHA256 of the concatenated message parts.
+func computeHMAC(key []byte, messageParts ...[]byte) []byte {
+	mac := hmac.New(sha256.New, key)
+
+	for _, part := range messageParts {
+		_, _ = mac.Write(part)
+	}
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,150 @@
+package signal
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/platform-source/aegis/keys"
+)
+
+func newTestBytes(start byte, size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = start + byte(i)
+	}
+
+	return data
+}
+
+func decodeTestHex(t *testing.T, data string) []byte {
+	t.Helper()
+
+	decoded, err := hex.DecodeString(data)
+	if err != nil {
+		t.Fatalf("DecodeString(): expected no error but got %v", err)
+	}
+
+	return decoded
+}
+
+func TestAdvanceRootChain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		withHeaderKey     bool
+		expectedHeaderKey string
+	}{
+		{
+			"with header key",
+			true,
+			"fc41bb463e95e2c4c0067b9acdf4861639c6ce19970495147399c04cc5fbe206",
+		},
+		{"without header key", false, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rootKey, masterKey, headerKey, err := advanceRootChain(
+				keys.Root{Bytes: newTestBytes(0x00, KeySize)},
+				keys.Shared{Bytes: newTestBytes(0x20, KeySize)},
+				test.withHeaderKey,
+			)
+			if err != nil {
+				t.Fatalf("advanceRootChain(): expected no error but got %v", err)
+			}
+
+			expectedRootKey := "62ffc77945c7aae74572869ac8a9522d96bc75a79cf3863ae7335004186255b3"
+			if hex.EncodeToString(rootKey.Bytes) != expectedRootKey {
+				t.Fatalf(
+					"advanceRootChain(): expected root key %s but got %x",
+					expectedRootKey,
+					rootKey.Bytes,
+				)
+			}
+
+			expectedMasterKey := "2de7be8dc5a58c68bcb5db2e71cb88157ed10ab4f7ea97ba5606e49733da2b94"
+			if hex.EncodeToString(masterKey.Bytes) != expectedMasterKey {
+				t.Fatalf(
+					"advanceRootChain(): expected chain key %s but got %x",
+					expectedMasterKey,
+					masterKey.Bytes,
+				)
+			}
+
+			if hex.EncodeToString(headerKey.Bytes) != test.expectedHeaderKey {
+				t.Fatalf(
+					"advanceRootChain(): expected header key %s but got %x",
+					test.expectedHeaderKey,
+					headerKey.Bytes,
+				)
+			}
+		})
+	}
+}
+
+func TestAdvanceChain(t *testing.T) {
+	t.Parallel()
+
+	expectedMessageKeys := []string{
+		"01156abd78ef59f755f1e6945e5d1e2d7ddd1f1e5fe0a164b2f5fa8ad500bfba",
+		"cc8aa93e9d19721c0c40f310be4d0383b891ee9f4b44bd608370dff83ed74819",
+		"bb8124f922de66d1ca2d1de11076871f812e9e3ebf6672e0c511d5cbb331af09",
+	}
+
+	masterKey := keys.Master{Bytes: newTestBytes(0x40, KeySize)}
+
+	for i, expectedMessageKey := range expectedMessageKeys {
+		var messageKey keys.Message
+
+		masterKey, messageKey = advanceChain(masterKey)
+
+		if hex.EncodeToString(messageKey.Bytes) != expectedMessageKey {
+			t.Fatalf(
+				"advanceChain(): expected message key %d %s but got %x",
+				i,
+				expectedMessageKey,
+				messageKey.Bytes,
+			)
+		}
+	}
+
+	expectedMasterKey := "060a465a37bf5c74f63b3030b95d77e55cd168a2256a36a2192aa458121e3d53"
+	if hex.EncodeToString(masterKey.Bytes) != expectedMasterKey {
+		t.Fatalf(
+			"advanceChain(): expected chain key %s but got %x",
+			expectedMasterKey,
+			masterKey.Bytes,
+		)
+	}
+}
+
+func TestDeriveMessageKeys(t *testing.T) {
+	t.Parallel()
+
+	derivedKeys, err := deriveMessageKeys(keys.Message{Bytes: newTestBytes(0x60, KeySize)})
+	if err != nil {
+		t.Fatalf("deriveMessageKeys(): expected no error but got %v", err)
+	}
+
+	expectedKeys := cipherKeys{
+		cipherKey: decodeTestHex(
+			t,
+			"1c920e16101f1af649e607b66684083bc4bcdbc742ed688bb84a74f935e02654",
+		),
+		macKey: decodeTestHex(
+			t,
+			"87732d054ad2b758fcb6884ae216ac5a0ce21409679d3298b224ed8c4d0ec56c",
+		),
+		iv: decodeTestHex(t, "8402afef1def4fda903f870b479a9ea2"),
+	}
+
+	if !bytes.Equal(derivedKeys.cipherKey, expectedKeys.cipherKey) ||
+		!bytes.Equal(derivedKeys.macKey, expectedKeys.macKey) ||
+		!bytes.Equal(derivedKeys.iv, expectedKeys.iv) {
+		t.Fatalf("deriveMessageKeys(): expected %x but got %x", expectedKeys, derivedKeys)
+	}
+}
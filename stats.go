@@ -0,0 +1,48 @@
+package ratchet
+
+import (
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/receivingchain"
+	"github.com/platform-source/aegis/sendingchain"
+)
+
+// Stats are the statistics of the ratchet. They contain no secrets, so they may be logged
+// to diagnose the sessions.
+type Stats struct {
+	// Epochs is the count of the Diffie-Hellman ratchet steps of both participants.
+	Epochs uint64
+
+	// Receiving are the statistics of the receiving chain.
+	Receiving receivingchain.Stats
+
+	// Sending are the statistics of the sending chain.
+	Sending sendingchain.Stats
+
+	// SendingRatchetPending reports whether the sending chain is ratcheted before the next
+	// message is encrypted.
+	SendingRatchetPending bool
+
+	// SecureMemory are the statistics of the secure allocator set with WithSecureMemory.
+	SecureMemory keys.SecureAllocatorStats
+
+	// SecureMemoryFallback reports whether the secure allocator has held any keys in the heap,
+	// because the memory could not be locked.
+	SecureMemoryFallback bool
+}
+
+// Stats returns the statistics of the ratchet.
+func (r *Ratchet) Stats() Stats {
+	stats := Stats{
+		Epochs:                r.epochs,
+		Receiving:             r.receivingChain.Stats(),
+		Sending:               r.sendingChain.Stats(),
+		SendingRatchetPending: r.needSendingChainRatchet,
+	}
+
+	if r.cfg.secureAllocator != nil {
+		stats.SecureMemory = r.cfg.secureAllocator.Stats()
+		stats.SecureMemoryFallback = stats.SecureMemory.FallbackAllocs > 0
+	}
+
+	return stats
+}
@@ -0,0 +1,141 @@
+package ratchet
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/receivingchain"
+	"github.com/platform-source/aegis/sendingchain"
+	"github.com/platform-source/tools/convert"
+)
+
+func TestRatchetStatsWithoutSecureMemory(t *testing.T) {
+	t.Parallel()
+
+	sender, _ := newTestRatchets(t)
+
+	stats := sender.Stats()
+	if stats.SecureMemory != (keys.SecureAllocatorStats{}) || stats.SecureMemoryFallback {
+		t.Fatalf("Stats(): expected empty secure memory stats but got %+v", stats)
+	}
+}
+
+func TestRatchetStats(t *testing.T) {
+	t.Parallel()
+
+	sender, recipient := newTestRatchets(t)
+
+	if recipient.RemotePublicKey() != nil {
+		t.Fatalf("RemotePublicKey(): expected nil but got %v", recipient.RemotePublicKey())
+	}
+
+	checkTestStats := func(name string, ratchet *Ratchet, expectedStats Stats) {
+		t.Helper()
+
+		stats := ratchet.Stats()
+		if !reflect.DeepEqual(stats, expectedStats) {
+			t.Fatalf("Stats(): expected %s stats %+v but got %+v", name, expectedStats, stats)
+		}
+	}
+
+	checkTestStats("new sender", &sender, Stats{
+		Epochs:  1,
+		Sending: sendingchain.Stats{Active: true},
+	})
+	checkTestStats("new recipient", &recipient, Stats{})
+
+	messages := make([]testMessage, 3)
+	for i := range messages {
+		messages[i] = encryptTestMessage(t, &sender, []byte("message"))
+	}
+
+	decryptTestMessage(t, &recipient, messages[2])
+
+	checkTestStats("recipient", &recipient, Stats{
+		Epochs: 1,
+		Receiving: receivingchain.Stats{
+			Active:              true,
+			NextMessageNumber:   3,
+			SkippedKeys:         2,
+			SkippedKeysPerEpoch: []uint64{2},
+		},
+		SendingRatchetPending: true,
+	})
+
+	if !reflect.DeepEqual(recipient.RemotePublicKey(), convert.ToPtr(sender.LocalPublicKey())) {
+		t.Fatalf(
+			"RemotePublicKey(): expected %v but got %v",
+			sender.LocalPublicKey(),
+			recipient.RemotePublicKey(),
+		)
+	}
+
+	decryptTestMessage(t, &sender, encryptTestMessage(t, &recipient, []byte("reply")))
+	decryptTestMessage(t, &recipient, messages[0])
+
+	checkTestStats("replied recipient", &recipient, Stats{
+		Epochs: 2,
+		Receiving: receivingchain.Stats{
+			Active:              true,
+			NextMessageNumber:   3,
+			SkippedKeys:         1,
+			SkippedKeysPerEpoch: []uint64{1},
+		},
+		Sending: sendingchain.Stats{Active: true, NextMessageNumber: 1},
+	})
+	checkTestStats("sender", &sender, Stats{
+		Epochs: 2,
+		Receiving: receivingchain.Stats{
+			Active:            true,
+			NextMessageNumber: 1,
+		},
+		Sending:               sendingchain.Stats{Active: true, NextMessageNumber: 3},
+		SendingRatchetPending: true,
+	})
+
+	senderBytes, err := sender.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary(): expected no error but got %v", err)
+	}
+
+	sender, err = Unmarshal(senderBytes)
+	if err != nil {
+		t.Fatalf("Unmarshal(): expected no error but got %v", err)
+	}
+
+	if sender.Stats().Epochs != 2 {
+		t.Fatalf("Unmarshal(): expected 2 epochs but got %d", sender.Stats().Epochs)
+	}
+}
+
+func TestRatchetSecureMemory(t *testing.T) {
+	t.Parallel()
+
+	allocator := keys.NewSecureAllocator()
+	t.Cleanup(func() {
+		err := allocator.Close()
+		if err != nil {
+			t.Errorf("Close(): expected no error but got %v", err)
+		}
+	})
+
+	sender, recipient := newTestRatchets(t, WithSecureMemory(allocator))
+
+	for range 3 {
+		decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("ping")))
+		decryptTestMessage(t, &sender, encryptTestMessage(t, &recipient, []byte("pong")))
+	}
+
+	stats := sender.Stats()
+	if stats.SecureMemoryFallback {
+		t.Skipf("Stats(): secure memory is not available: %v", stats.SecureMemory.FallbackErr)
+	}
+
+	if stats.SecureMemory.LockedBytes == 0 {
+		t.Fatal("Stats(): expected locked memory but got none")
+	}
+
+	sender.Destroy()
+	recipient.Destroy()
+}
@@ -0,0 +1,353 @@
+package ratchet
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/platform-source/aegis/aead"
+	"github.com/platform-source/aegis/chainscommon"
+	"github.com/platform-source/aegis/header"
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/receivingchain"
+	"github.com/platform-source/aegis/sendingchain"
+	"github.com/platform-source/tools/slices"
+)
+
+const (
+	streamChunkSize = 64 * 1024
+
+	// streamEncryptedHeaderSizeLen is the size of the encrypted header length, which
+	// precedes the encrypted header in the stream.
+	streamEncryptedHeaderSizeLen = 2
+
+	streamLastChunkFlag = 0x01
+)
+
+// EncryptStream encrypts the data read from src until EOF, authenticates it with auth and
+// writes the encrypted stream to dst.
+//
+// The stream is encrypted with one message key of the sending chain. The key is used to
+// derive the stream key, and the data is split into chunks, which are encrypted with the
+// chunk counter and the last chunk flag in the nonce. So the memory usage is bounded, and
+// truncated or reordered streams fail to decrypt.
+//
+// Please note that the ratchet state is advanced before the data is read, so the stream
+// is lost if reading or writing fails.
+func (r *Ratchet) EncryptStream(dst io.Writer, src io.Reader, auth []byte) error {
+	encryptedHeader, stream, err := r.sealStream(auth)
+	if err != nil {
+		return err
+	}
+
+	return stream.encrypt(dst, src, encryptedHeader)
+}
+
+// DecryptStream decrypts the stream read from src, which was encrypted with EncryptStream,
+// authenticates it with auth and writes the decrypted data to dst.
+//
+// The ratchet state is advanced once the first chunk of the stream is decrypted. Please
+// note that the decrypted chunks are written to dst as soon as they are authenticated, so
+// all written data must be discarded if an error is returned.
+func (r *Ratchet) DecryptStream(dst io.Writer, src io.Reader, auth []byte) error {
+	stream, err := newStreamDecrypter(src, auth, r.cfg.suite.streamAEAD)
+	if err != nil {
+		return err
+	}
+
+	firstChunk, skippedKeysErr := r.openWithSkippedKeys(stream.encryptedHeader, stream.open)
+	if errors.Is(skippedKeysErr, receivingchain.ErrOpenMessage) {
+		return r.decryptFailed(skippedKeysErr)
+	}
+
+	if skippedKeysErr != nil {
+		firstChunk, err = r.openWithChainKeys(stream.encryptedHeader, stream.open)
+		if err != nil {
+			return r.decryptFailed(errors.Join(skippedKeysErr, err))
+		}
+	}
+
+	return stream.decrypt(dst, firstChunk)
+}
+
+// sealStream advances the sending chain and returns the encrypted header and the stream
+// cipher derived from the message key.
+func (r *Ratchet) sealStream(auth []byte) ([]byte, *streamCipher, error) {
+	var stream *streamCipher
+
+	encryptedHeader, _, err := r.encryptWithSendingChain(
+		func(chain *sendingchain.Chain, head header.Header) ([]byte, []byte, error) {
+			seal := func(messageKey keys.Message, encryptedHeader []byte) ([]byte, error) {
+				var err error
+
+				stream, err = newStreamCipher(
+					r.cfg.suite.streamAEAD,
+					messageKey,
+					slices.ConcatBytes(encryptedHeader, auth),
+				)
+
+				return nil, err
+			}
+
+			return chain.Seal(head, seal)
+		},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return encryptedHeader, stream, nil
+}
+
+// streamCipher is the STREAM construction of the chunked AEAD. The nonce of the chunk is
+// the nonce prefix, the big-endian chunk counter and the last chunk flag.
+type streamCipher struct {
+	aead    cipher.AEAD
+	nonce   []byte
+	auth    []byte
+	counter uint64
+}
+
+func newStreamCipher(
+	suite aead.Suite,
+	messageKey keys.Message,
+	auth []byte,
+) (*streamCipher, error) {
+	key, noncePrefix, err := chainscommon.DeriveStreamCipherKeyAndNoncePrefix(suite, messageKey)
+	if err != nil {
+		return nil, errors.Join(ErrDeriveStreamCipherKeyAndNoncePrefix, err)
+	}
+
+	defer clear(key)
+
+	streamAEAD, err := suite.NewCipher(key)
+	if err != nil {
+		return nil, errors.Join(ErrNewCipher, err)
+	}
+
+	stream := &streamCipher{
+		aead:  streamAEAD,
+		nonce: make([]byte, suite.NonceSize()),
+		auth:  auth,
+	}
+	copy(stream.nonce, noncePrefix)
+
+	return stream, nil
+}
+
+// encrypt writes the encrypted header and the encrypted chunks of the data read from src
+// to dst.
+func (c *streamCipher) encrypt(dst io.Writer, src io.Reader, encryptedHeader []byte) error {
+	if len(encryptedHeader) > math.MaxUint16 {
+		return ErrEncryptedHeaderTooLong
+	}
+
+	headerSize := binary.BigEndian.AppendUint16(nil, uint16(len(encryptedHeader)))
+
+	_, err := dst.Write(slices.ConcatBytes(headerSize, encryptedHeader))
+	if err != nil {
+		return errors.Join(ErrWriteStream, err)
+	}
+
+	var (
+		reader         = bufio.NewReader(src)
+		chunk          = make([]byte, streamChunkSize)
+		encryptedChunk = make([]byte, 0, streamChunkSize+c.aead.Overhead())
+	)
+
+	for {
+		chunkSize, last, err := readStreamChunk(reader, chunk)
+		if err != nil {
+			return errors.Join(ErrReadStream, err)
+		}
+
+		encryptedChunk, err = c.seal(encryptedChunk[:0], chunk[:chunkSize], last)
+		if err != nil {
+			return err
+		}
+
+		_, err = dst.Write(encryptedChunk)
+		if err != nil {
+			return errors.Join(ErrWriteStream, err)
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+func (c *streamCipher) open(dst, encryptedChunk []byte, last bool) ([]byte, error) {
+	nonce, err := c.nextNonce(last)
+	if err != nil {
+		return nil, err
+	}
+
+	chunk, err := c.aead.Open(dst, nonce, encryptedChunk, c.auth)
+	if err != nil {
+		return nil, errors.Join(ErrOpenStreamChunk, err)
+	}
+
+	return chunk, nil
+}
+
+func (c *streamCipher) seal(dst, chunk []byte, last bool) ([]byte, error) {
+	nonce, err := c.nextNonce(last)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.aead.Seal(dst, nonce, chunk, c.auth), nil
+}
+
+func (c *streamCipher) nextNonce(last bool) ([]byte, error) {
+	if c.counter > math.MaxUint32 {
+		return nil, ErrStreamTooLong
+	}
+
+	nonceSuffix := c.nonce[len(c.nonce)-chainscommon.StreamNonceSuffixSize:]
+	binary.BigEndian.PutUint32(nonceSuffix, uint32(c.counter))
+	nonceSuffix[4] = 0
+
+	if last {
+		nonceSuffix[4] = streamLastChunkFlag
+	}
+
+	c.counter++
+
+	return c.nonce, nil
+}
+
+// streamDecrypter reads the encrypted header and the first encrypted chunk of the stream
+// in advance, so the message key may be checked without reading the whole stream.
+type streamDecrypter struct {
+	reader          *bufio.Reader
+	auth            []byte
+	encryptedHeader []byte
+	encryptedChunk  []byte
+	firstChunkSize  int
+	firstChunkLast  bool
+	suite           aead.Suite
+	cipher          *streamCipher
+}
+
+func newStreamDecrypter(
+	src io.Reader,
+	auth []byte,
+	suite aead.Suite,
+) (*streamDecrypter, error) {
+	stream := &streamDecrypter{
+		reader:         bufio.NewReader(src),
+		auth:           auth,
+		encryptedChunk: make([]byte, streamChunkSize+suite.Overhead()),
+		suite:          suite,
+	}
+
+	var headerSize [streamEncryptedHeaderSizeLen]byte
+
+	_, err := io.ReadFull(stream.reader, headerSize[:])
+	if err != nil {
+		return nil, errors.Join(ErrReadStream, err)
+	}
+
+	stream.encryptedHeader = make([]byte, binary.BigEndian.Uint16(headerSize[:]))
+
+	_, err = io.ReadFull(stream.reader, stream.encryptedHeader)
+	if err != nil {
+		return nil, errors.Join(ErrReadStream, err)
+	}
+
+	stream.firstChunkSize, stream.firstChunkLast, err = readStreamChunk(
+		stream.reader,
+		stream.encryptedChunk,
+	)
+	if err != nil {
+		return nil, errors.Join(ErrReadStream, err)
+	}
+
+	return stream, nil
+}
+
+// open is the receiving chain open callback, which decrypts the first chunk with the stream
+// cipher derived from passed message key.
+func (d *streamDecrypter) open(messageKey keys.Message) ([]byte, error) {
+	stream, err := newStreamCipher(
+		d.suite,
+		messageKey,
+		slices.ConcatBytes(d.encryptedHeader, d.auth),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	chunk, err := stream.open(nil, d.encryptedChunk[:d.firstChunkSize], d.firstChunkLast)
+	if err != nil {
+		return nil, err
+	}
+
+	d.cipher = stream
+
+	return chunk, nil
+}
+
+// decrypt writes passed first chunk and the rest of decrypted chunks to dst.
+func (d *streamDecrypter) decrypt(dst io.Writer, firstChunk []byte) error {
+	_, err := dst.Write(firstChunk)
+	if err != nil {
+		return errors.Join(ErrWriteStream, err)
+	}
+
+	if d.firstChunkLast {
+		return nil
+	}
+
+	chunk := firstChunk[:0]
+
+	for {
+		encryptedChunkSize, last, err := readStreamChunk(d.reader, d.encryptedChunk)
+		if err != nil {
+			return errors.Join(ErrReadStream, err)
+		}
+
+		chunk, err = d.cipher.open(chunk[:0], d.encryptedChunk[:encryptedChunkSize], last)
+		if err != nil {
+			return err
+		}
+
+		_, err = dst.Write(chunk)
+		if err != nil {
+			return errors.Join(ErrWriteStream, err)
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// readStreamChunk fills passed buffer from the reader and reports whether the chunk is the
+// last one, which is the case when the reader has no more data after it.
+func readStreamChunk(reader *bufio.Reader, buffer []byte) (int, bool, error) {
+	chunkSize, err := io.ReadFull(reader, buffer)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return chunkSize, true, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	_, err = reader.Peek(1)
+	if errors.Is(err, io.EOF) {
+		return chunkSize, true, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	return chunkSize, false, nil
+}
@@ -0,0 +1,237 @@
+package ratchet
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+func newTestStreamData(t *testing.T, size int) []byte {
+	t.Helper()
+
+	data := make([]byte, size)
+
+	_, err := rand.Read(data)
+	if err != nil {
+		t.Fatalf("rand.Read(): expected no error but got %v", err)
+	}
+
+	return data
+}
+
+func encryptTestStream(t *testing.T, ratchet *Ratchet, data []byte) []byte {
+	t.Helper()
+
+	var encryptedStream bytes.Buffer
+
+	err := ratchet.EncryptStream(&encryptedStream, bytes.NewReader(data), []byte("auth"))
+	if err != nil {
+		t.Fatalf("EncryptStream(): expected no error but got %v", err)
+	}
+
+	return encryptedStream.Bytes()
+}
+
+func decryptTestStream(t *testing.T, ratchet *Ratchet, encryptedStream, expected []byte) {
+	t.Helper()
+
+	var data bytes.Buffer
+
+	err := ratchet.DecryptStream(&data, bytes.NewReader(encryptedStream), []byte("auth"))
+	if err != nil {
+		t.Fatalf("DecryptStream(): expected no error but got %v", err)
+	}
+
+	if !bytes.Equal(data.Bytes(), expected) {
+		t.Fatalf(
+			"DecryptStream(): expected %d bytes but got other %d bytes",
+			len(expected),
+			data.Len(),
+		)
+	}
+}
+
+// testStreamChunksOffset returns the offset of the first encrypted chunk in the stream.
+func testStreamChunksOffset(encryptedStream []byte) int {
+	return streamEncryptedHeaderSizeLen + int(binary.BigEndian.Uint16(encryptedStream))
+}
+
+var ratchetStreamTests = []struct {
+	name string
+	size int
+}{
+	{
+		"empty data",
+		0,
+	},
+	{
+		"one byte",
+		1,
+	},
+	{
+		"one full chunk",
+		streamChunkSize,
+	},
+	{
+		"one full chunk and one byte",
+		streamChunkSize + 1,
+	},
+	{
+		"several chunks",
+		3*streamChunkSize + 5,
+	},
+}
+
+func TestRatchetStream(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range ratchetStreamTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			sender, recipient := newTestRatchets(t)
+			data := newTestStreamData(t, test.size)
+
+			encryptedStream := encryptTestStream(t, &sender, data)
+			decryptTestStream(t, &recipient, encryptedStream, data)
+
+			reply := encryptTestMessage(t, &recipient, []byte("reply"))
+			decryptTestMessage(t, &sender, reply)
+		})
+	}
+}
+
+func TestRatchetStreamOutOfOrder(t *testing.T) {
+	t.Parallel()
+
+	sender, recipient := newTestRatchets(t)
+	firstData := newTestStreamData(t, streamChunkSize+10)
+	secondData := newTestStreamData(t, 10)
+
+	first := encryptTestStream(t, &sender, firstData)
+	second := encryptTestStream(t, &sender, secondData)
+
+	decryptTestStream(t, &recipient, second, secondData)
+	decryptTestStream(t, &recipient, first, firstData)
+}
+
+var ratchetDecryptStreamForgedTests = []struct {
+	name   string
+	forge  func(encryptedStream []byte) []byte
+	errors []error
+}{
+	{
+		"truncated at chunk boundary",
+		func(encryptedStream []byte) []byte {
+			offset := testStreamChunksOffset(encryptedStream)
+
+			return encryptedStream[:offset+streamChunkSize+chacha20poly1305.Overhead]
+		},
+		[]error{ErrOpenStreamChunk},
+	},
+	{
+		"truncated last chunk",
+		func(encryptedStream []byte) []byte {
+			return encryptedStream[:len(encryptedStream)-1]
+		},
+		[]error{ErrOpenStreamChunk},
+	},
+	{
+		"reordered chunks",
+		func(encryptedStream []byte) []byte {
+			offset := testStreamChunksOffset(encryptedStream)
+			chunkSize := streamChunkSize + chacha20poly1305.Overhead
+			first := bytes.Clone(encryptedStream[offset : offset+chunkSize])
+
+			copy(encryptedStream[offset:], encryptedStream[offset+chunkSize:offset+2*chunkSize])
+			copy(encryptedStream[offset+chunkSize:], first)
+
+			return encryptedStream
+		},
+		[]error{ErrOpenStreamChunk},
+	},
+	{
+		"truncated header",
+		func(encryptedStream []byte) []byte {
+			return encryptedStream[:1]
+		},
+		[]error{ErrReadStream},
+	},
+}
+
+func TestRatchetDecryptStreamForged(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range ratchetDecryptStreamForgedTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			sender, recipient := newTestRatchets(t)
+			data := newTestStreamData(t, 2*streamChunkSize+10)
+
+			encryptedStream := test.forge(encryptTestStream(t, &sender, data))
+
+			err := recipient.DecryptStream(
+				&bytes.Buffer{},
+				bytes.NewReader(encryptedStream),
+				[]byte("auth"),
+			)
+			for _, expectedErr := range test.errors {
+				if !errors.Is(err, expectedErr) {
+					t.Fatalf("DecryptStream(): expected error %v but got %v", expectedErr, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRatchetDecryptStreamForgedFirstChunk(t *testing.T) {
+	t.Parallel()
+
+	sender, recipient := newTestRatchets(t)
+	data := newTestStreamData(t, 10)
+
+	encryptedStream := encryptTestStream(t, &sender, data)
+	forgedStream := bytes.Clone(encryptedStream)
+	forgedStream[len(forgedStream)-1] ^= 0xFF
+
+	err := recipient.DecryptStream(&bytes.Buffer{}, bytes.NewReader(forgedStream), []byte("auth"))
+	if !errors.Is(err, ErrOpenStreamChunk) {
+		t.Fatalf("DecryptStream(): expected open stream chunk error but got %v", err)
+	}
+
+	decryptTestStream(t, &recipient, encryptedStream, data)
+}
+
+func TestSyncRatchetStream(t *testing.T) {
+	t.Parallel()
+
+	sender, recipient := newTestRatchets(t)
+	syncSender := NewConcurrent(sender)
+	syncRecipient := NewConcurrent(recipient)
+	data := newTestStreamData(t, streamChunkSize+10)
+
+	var encryptedStream, decryptedData bytes.Buffer
+
+	err := syncSender.EncryptStream(&encryptedStream, bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatalf("EncryptStream(): expected no error but got %v", err)
+	}
+
+	err = syncRecipient.DecryptStream(&decryptedData, &encryptedStream, nil)
+	if err != nil {
+		t.Fatalf("DecryptStream(): expected no error but got %v", err)
+	}
+
+	if !bytes.Equal(decryptedData.Bytes(), data) {
+		t.Fatalf(
+			"DecryptStream(): expected %d bytes but got other %d bytes",
+			len(data),
+			decryptedData.Len(),
+		)
+	}
+}
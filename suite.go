@@ -0,0 +1,177 @@
+package ratchet
+
+import (
+	"github.com/platform-source/aegis/aead"
+	"github.com/platform-source/aegis/receivingchain"
+	"github.com/platform-source/aegis/rootchain"
+	"github.com/platform-source/aegis/sendingchain"
+	"github.com/platform-source/aegis/signal"
+	"github.com/platform-source/tools/check"
+)
+
+// SuiteID is the stable identifier of the suite, which is embedded in the encoded state.
+type SuiteID uint8
+
+const (
+	// SuiteIDXChaCha20Poly1305 is the identifier of the default suite.
+	SuiteIDXChaCha20Poly1305 SuiteID = 1
+
+	// SuiteIDAES256GCM is the identifier of the AES-256-GCM suite.
+	SuiteIDAES256GCM SuiteID = 2
+
+	// SuiteIDAES256GCMSIV is the identifier of the AES-256-GCM-SIV suite.
+	SuiteIDAES256GCMSIV SuiteID = 3
+
+	// SuiteIDSignal is the identifier of the Signal suite.
+	SuiteIDSignal SuiteID = 4
+
+	// SuiteIDSignalPlaintextHeaders is the identifier of the Signal suite without header
+	// encryption.
+	SuiteIDSignalPlaintextHeaders SuiteID = 5
+
+	// MinCustomSuiteID is the min identifier of custom suites. The lower identifiers are
+	// reserved for the suites of the package.
+	MinCustomSuiteID SuiteID = 0x80
+)
+
+// Suite bundles all crypto of the ratchet: the Diffie-Hellman crypto, the root chain KDF,
+// the sending and receiving chains crypto, which are the chain KDF with the header and
+// message AEAD, and the AEAD of the streams. The parts of one suite are always compatible
+// with each other.
+//
+// The zero value is not a valid suite.
+type Suite struct {
+	id               SuiteID
+	crypto           Crypto
+	rootCrypto       rootchain.Crypto
+	sendingCrypto    sendingchain.Crypto
+	receivingCrypto  receivingchain.Crypto
+	streamAEAD       aead.Suite
+	plaintextHeaders bool
+}
+
+// SuiteParts are the crypto parts of a custom suite.
+type SuiteParts struct {
+	// Crypto is the Diffie-Hellman crypto.
+	Crypto Crypto
+
+	// RootCrypto is the root chain KDF.
+	RootCrypto rootchain.Crypto
+
+	// SendingCrypto is the sending chain KDF with the header and message encryption.
+	SendingCrypto sendingchain.Crypto
+
+	// ReceivingCrypto is the receiving chain KDF with the header and message decryption,
+	// which must match the sending crypto.
+	ReceivingCrypto receivingchain.Crypto
+
+	// StreamAEAD is the AEAD of the streams.
+	StreamAEAD aead.Suite
+}
+
+// DefaultSuite returns the default suite, which is X25519, HKDF-BLAKE2b root chain KDF,
+// HMAC-BLAKE2b chain KDF and XChaCha20-Poly1305.
+func DefaultSuite() Suite {
+	return newAEADSuite(SuiteIDXChaCha20Poly1305, aead.XChaCha20Poly1305())
+}
+
+// AES256GCMSuite returns the default suite with AES-256-GCM instead of XChaCha20-Poly1305.
+func AES256GCMSuite() Suite {
+	return newAEADSuite(SuiteIDAES256GCM, aead.AES256GCM())
+}
+
+// AES256GCMSIVSuite returns the default suite with AES-256-GCM-SIV instead of
+// XChaCha20-Poly1305.
+func AES256GCMSIVSuite() Suite {
+	return newAEADSuite(SuiteIDAES256GCMSIV, aead.AES256GCMSIV())
+}
+
+// SignalSuite returns the suite of the Signal Double Ratchet specification with header
+// encryption: X25519, HKDF-SHA256 root chain KDF, HMAC-SHA256 chain KDF and AES-256-CBC with
+// HMAC-SHA256, see the signal package. The streams are not a part of the specification, so
+// they are encrypted with AES-256-GCM.
+//
+// Only the algorithms follow the specification, the messages are encoded by this package.
+func SignalSuite() Suite {
+	suite := Suite{
+		id:              SuiteIDSignal,
+		crypto:          newDefaultCrypto(),
+		rootCrypto:      signal.NewRootCrypto(),
+		sendingCrypto:   signal.NewSendingCrypto(),
+		receivingCrypto: signal.NewReceivingCrypto(),
+		streamAEAD:      aead.AES256GCM(),
+	}
+
+	return suite
+}
+
+// SignalPlaintextHeadersSuite returns SignalSuite without header encryption. The root chain
+// KDF derives no header keys and the suite enables WithPlaintextHeaders.
+func SignalPlaintextHeadersSuite() Suite {
+	suite := SignalSuite()
+	suite.id = SuiteIDSignalPlaintextHeaders
+	suite.rootCrypto = signal.NewPlaintextHeadersRootCrypto()
+	suite.plaintextHeaders = true
+
+	return suite
+}
+
+// NewSuite creates a new custom suite with passed identifier, which must be at least
+// MinCustomSuiteID.
+func NewSuite(id SuiteID, parts SuiteParts) (Suite, error) {
+	if id < MinCustomSuiteID {
+		return Suite{}, ErrReservedSuiteID
+	}
+
+	if check.IsNil(parts.Crypto) ||
+		check.IsNil(parts.RootCrypto) ||
+		check.IsNil(parts.SendingCrypto) ||
+		check.IsNil(parts.ReceivingCrypto) {
+		return Suite{}, ErrCryptoIsNil
+	}
+
+	if !parts.StreamAEAD.Valid() {
+		return Suite{}, ErrInvalidAEAD
+	}
+
+	suite := Suite{
+		id:              id,
+		crypto:          parts.Crypto,
+		rootCrypto:      parts.RootCrypto,
+		sendingCrypto:   parts.SendingCrypto,
+		receivingCrypto: parts.ReceivingCrypto,
+		streamAEAD:      parts.StreamAEAD,
+	}
+
+	return suite, nil
+}
+
+// suiteForAEAD returns the suite of the package with passed AEAD.
+func suiteForAEAD(aeadSuite aead.Suite) Suite {
+	switch aeadSuite.ID() {
+	case aead.IDAES256GCM:
+		return AES256GCMSuite()
+	case aead.IDAES256GCMSIV:
+		return AES256GCMSIVSuite()
+	default:
+		return DefaultSuite()
+	}
+}
+
+func newAEADSuite(id SuiteID, aeadSuite aead.Suite) Suite {
+	suite := Suite{
+		id:              id,
+		crypto:          newDefaultCrypto(),
+		rootCrypto:      rootchain.NewDefaultCrypto(aeadSuite),
+		sendingCrypto:   sendingchain.NewDefaultCrypto(aeadSuite),
+		receivingCrypto: receivingchain.NewDefaultCrypto(aeadSuite),
+		streamAEAD:      aeadSuite,
+	}
+
+	return suite
+}
+
+// ID returns the stable identifier of the suite.
+func (s Suite) ID() SuiteID {
+	return s.id
+}
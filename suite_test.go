@@ -0,0 +1,414 @@
+package ratchet
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/platform-source/aegis/aead"
+	"github.com/platform-source/aegis/header"
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/receivingchain"
+	"github.com/platform-source/aegis/rootchain"
+	"github.com/platform-source/aegis/sendingchain"
+	"github.com/platform-source/aegis/signal"
+)
+
+func newTestSuiteParts() SuiteParts {
+	suite := aead.AES256GCMSIV()
+
+	return SuiteParts{
+		Crypto:          newDefaultCrypto(),
+		RootCrypto:      rootchain.NewDefaultCrypto(suite),
+		SendingCrypto:   sendingchain.NewDefaultCrypto(suite),
+		ReceivingCrypto: receivingchain.NewDefaultCrypto(suite),
+		StreamAEAD:      suite,
+	}
+}
+
+func TestNewSuite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		id          SuiteID
+		modifyParts func(parts *SuiteParts)
+		expectedErr error
+	}{
+		{"success", MinCustomSuiteID, func(_ *SuiteParts) {}, nil},
+		{"reserved ID", SuiteIDAES256GCM, func(_ *SuiteParts) {}, ErrReservedSuiteID},
+		{
+			"nil crypto",
+			MinCustomSuiteID,
+			func(parts *SuiteParts) { parts.Crypto = nil },
+			ErrCryptoIsNil,
+		},
+		{
+			"nil receiving crypto",
+			MinCustomSuiteID,
+			func(parts *SuiteParts) { parts.ReceivingCrypto = nil },
+			ErrCryptoIsNil,
+		},
+		{
+			"invalid stream AEAD",
+			MinCustomSuiteID,
+			func(parts *SuiteParts) { parts.StreamAEAD = aead.Suite{} },
+			ErrInvalidAEAD,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			parts := newTestSuiteParts()
+			test.modifyParts(&parts)
+
+			suite, err := NewSuite(test.id, parts)
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("NewSuite(): expected error %v but got %v", test.expectedErr, err)
+			}
+
+			if err == nil && suite.ID() != test.id {
+				t.Fatalf("ID(): expected %d but got %d", test.id, suite.ID())
+			}
+		})
+	}
+}
+
+func TestRatchetSuite(t *testing.T) {
+	t.Parallel()
+
+	suite, err := NewSuite(MinCustomSuiteID, newTestSuiteParts())
+	if err != nil {
+		t.Fatalf("NewSuite(): expected no error but got %v", err)
+	}
+
+	sender, recipient := newTestRatchets(t, WithSuite(suite))
+
+	decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("first")))
+	decryptTestMessage(t, &sender, encryptTestMessage(t, &recipient, []byte("reply")))
+
+	data := newTestStreamData(t, streamChunkSize+1)
+	decryptTestStream(t, &recipient, encryptTestStream(t, &sender, data), data)
+
+	senderBytes, err := sender.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary(): expected no error but got %v", err)
+	}
+
+	_, err = Unmarshal(senderBytes, WithAEAD(aead.AES256GCMSIV()))
+	if !errors.Is(err, ErrSuiteMismatch) {
+		t.Fatalf("Unmarshal(): expected error %v but got %v", ErrSuiteMismatch, err)
+	}
+
+	sender, err = Unmarshal(senderBytes, WithSuite(suite))
+	if err != nil {
+		t.Fatalf("Unmarshal(): expected no error but got %v", err)
+	}
+
+	decryptTestMessage(t, &recipient, encryptTestMessage(t, &sender, []byte("second")))
+}
+
+func TestRatchetSuiteMixedChainCrypto(t *testing.T) {
+	t.Parallel()
+
+	_, publicKey, err := newDefaultCrypto().GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): expected no error but got %v", err)
+	}
+
+	_, err = NewSender(
+		publicKey,
+		keys.Root{Bytes: newTestKey(t)},
+		keys.Header{Bytes: newTestKey(t)},
+		keys.Header{Bytes: newTestKey(t)},
+		WithSuite(AES256GCMSuite()),
+		WithSendingChainOptions(sendingchain.WithAEAD(aead.XChaCha20Poly1305())),
+	)
+	if !errors.Is(err, sendingchain.ErrMixedCrypto) {
+		t.Fatalf("NewSender(): expected error %v but got %v", sendingchain.ErrMixedCrypto, err)
+	}
+}
+
+// testKeyPairsCrypto is X25519 with predefined private keys, so the ratchet is deterministic.
+type testKeyPairsCrypto struct {
+	defaultCrypto
+
+	privateKeys [][]byte
+}
+
+func (c *testKeyPairsCrypto) GenerateKeyPair() (keys.Private, keys.Public, error) {
+	privateKey, err := c.curve.NewPrivateKey(c.privateKeys[0])
+	if err != nil {
+		return keys.Private{}, keys.Public{}, err
+	}
+
+	c.privateKeys = c.privateKeys[1:]
+
+	publicKey := keys.Public{
+		Bytes: privateKey.PublicKey().Bytes(),
+	}
+
+	return keys.Private{Bytes: privateKey.Bytes()}, publicKey, nil
+}
+
+func newTestSignalSuite(
+	t *testing.T,
+	rootCrypto rootchain.Crypto,
+	privateKeys ...[]byte,
+) Suite {
+	t.Helper()
+
+	crypto := &testKeyPairsCrypto{
+		defaultCrypto: newDefaultCrypto(),
+		privateKeys:   privateKeys,
+	}
+
+	suite, err := NewSuite(MinCustomSuiteID, SuiteParts{
+		Crypto:          crypto,
+		RootCrypto:      rootCrypto,
+		SendingCrypto:   signal.NewSendingCrypto(),
+		ReceivingCrypto: signal.NewReceivingCrypto(),
+		StreamAEAD:      aead.AES256GCM(),
+	})
+	if err != nil {
+		t.Fatalf("NewSuite(): expected no error but got %v", err)
+	}
+
+	return suite
+}
+
+var ratchetSignalSuiteTests = []struct {
+	name  string
+	suite Suite
+}{
+	{"header encryption", SignalSuite()},
+	{"plaintext headers", SignalPlaintextHeadersSuite()},
+}
+
+func TestRatchetSignalSuite(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range ratchetSignalSuiteTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			sender, recipient := newTestRatchets(t, WithSuite(test.suite))
+
+			first := encryptTestMessage(t, &sender, []byte("first"))
+			decryptTestMessage(t, &recipient, first)
+			decryptTestMessage(t, &sender, encryptTestMessage(t, &recipient, []byte("reply")))
+
+			_, err := header.Decode(first.encryptedHeader)
+			if (err == nil) != test.suite.plaintextHeaders {
+				t.Fatalf("Encrypt(): expected plaintext header %t", test.suite.plaintextHeaders)
+			}
+
+			data := newTestStreamData(t, streamChunkSize+1)
+			decryptTestStream(t, &recipient, encryptTestStream(t, &sender, data), data)
+		})
+	}
+}
+
+// signalVectors are the encoded header, the AES-256-CBC ciphertext and the HMAC-SHA256 of
+// the messages of TestRatchetSignalSuiteVectors. They are computed by an independent
+// implementation of the recommended algorithms of the Signal specification.
+var signalVectors = map[string]struct {
+	header     string
+	ciphertext string
+	mac        string
+}{
+	"A1": {
+		"00000000000000000000000000000000" +
+			"f0b4fd8be480349293ab61f0505ebb5bafccdf8a4127de221e6ef3db20e03d29",
+		"92d87753a8a4b5ab2f394fed1e907157",
+		"b51bcabcec519fa111c3bcf90c28e959dbc81934b8172c29f33b4049ee44fbe6",
+	},
+	"A2": {
+		"01000000000000000000000000000000" +
+			"f0b4fd8be480349293ab61f0505ebb5bafccdf8a4127de221e6ef3db20e03d29",
+		"b5ca2d16aecb48b18a7c8f213a487cb4",
+		"96735478737c3cc720f162d7c3bc90cd29c26e15f45f72755e11b4cf9cc457cb",
+	},
+	"A3": {
+		"02000000000000000000000000000000" +
+			"f0b4fd8be480349293ab61f0505ebb5bafccdf8a4127de221e6ef3db20e03d29",
+		"bb10028a3de47ebb8d05a19185117ba4",
+		"6921c4c86da75a1e809dbac22c26a3bfafdef90fa3296a112ad6a1254b3788b8",
+	},
+	"B1": {
+		"00000000000000000000000000000000" +
+			"d3337e4d4ee503a66976feb1fadf5bd21ba96fc2b1571b3e980d87cf49797510",
+		"60c97f1d91a679781767d110337db0c1",
+		"419402476cab6701ae57c58048c6fc4e6cb467bc56d2d38f070347c6779fd38b",
+	},
+	"B2": {
+		"01000000000000000000000000000000" +
+			"d3337e4d4ee503a66976feb1fadf5bd21ba96fc2b1571b3e980d87cf49797510",
+		"cfb3d508bc287a64887fb293a35372de",
+		"09010c0ae46247bf7574973e24d9e186cf30357f2929bdc267a4b0002dafb5d8",
+	},
+	"A4": {
+		"00000000000000000300000000000000" +
+			"c306fb0ef2bf8b7f93bad98155fa37daec74db0c4cbeda6c6f1dba9d36558252",
+		"1a577f3d50566fb234ac09d94c79b441",
+		"0943c53ede166f9983c5239e7e3d83059b67bf52293a08d9e5e1087e7b49a708",
+	},
+	"B3": {
+		"00000000000000000200000000000000" +
+			"db48257e1237976a74ad8cfedca00213408fe89ac6251f1b930245f242b5c31a",
+		"dd6506dbfe1351dfdc14a9c6bd3d8bec",
+		"cafb9f31a1b165fc2b0baac438c31b766f88f8a162a5005770355a20e481cbc4",
+	},
+}
+
+var ratchetSignalSuiteVectorsTests = []struct {
+	name             string
+	rootCrypto       rootchain.Crypto
+	plaintextHeaders bool
+}{
+	{"header encryption", signal.NewRootCrypto(), false},
+	{"plaintext headers", signal.NewPlaintextHeadersRootCrypto(), true},
+}
+
+func TestRatchetSignalSuiteVectors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range ratchetSignalSuiteVectorsTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			testRatchetSignalSuiteVectors(t, test.rootCrypto, test.plaintextHeaders)
+		})
+	}
+}
+
+// testRatchetSignalSuiteVectors exchanges the messages out of order with the skipped keys
+// of the current and previous epochs and the Diffie-Hellman ratchet steps. The header keys
+// do not affect the messages, but the encrypted headers have a random IV, so only
+// the ciphertexts are compared with header encryption.
+func testRatchetSignalSuiteVectors(
+	t *testing.T,
+	rootCrypto rootchain.Crypto,
+	plaintextHeaders bool,
+) {
+	t.Helper()
+
+	newPrivateKey := func(keyByte byte) []byte {
+		return bytes.Repeat([]byte{keyByte}, signal.KeySize)
+	}
+
+	rootKey := make([]byte, signal.KeySize)
+	for i := range rootKey {
+		rootKey[i] = byte(i)
+	}
+
+	aliceHeaderKey := bytes.Repeat([]byte{0xc0}, signal.KeySize)
+	bobNextHeaderKey := bytes.Repeat([]byte{0xc1}, signal.KeySize)
+	bobCrypto := newDefaultCrypto()
+
+	bobPrivateKey, err := bobCrypto.curve.NewPrivateKey(newPrivateKey(0xb0))
+	if err != nil {
+		t.Fatalf("NewPrivateKey(): expected no error but got %v", err)
+	}
+
+	var options []Option
+	if plaintextHeaders {
+		options = append(options, WithPlaintextHeaders())
+	}
+
+	alice, err := NewSender(
+		keys.Public{Bytes: bobPrivateKey.PublicKey().Bytes()},
+		keys.Root{Bytes: bytes.Clone(rootKey)},
+		keys.Header{Bytes: bytes.Clone(aliceHeaderKey)},
+		keys.Header{Bytes: bytes.Clone(bobNextHeaderKey)},
+		append(
+			options,
+			WithSuite(newTestSignalSuite(t, rootCrypto, newPrivateKey(0xa0), newPrivateKey(0xa1))),
+		)...,
+	)
+	if err != nil {
+		t.Fatalf("NewSender(): expected no error but got %v", err)
+	}
+
+	bob, err := NewRecipient(
+		keys.Private{Bytes: bobPrivateKey.Bytes()},
+		keys.Public{Bytes: bobPrivateKey.PublicKey().Bytes()},
+		keys.Root{Bytes: bytes.Clone(rootKey)},
+		keys.Header{Bytes: bytes.Clone(bobNextHeaderKey)},
+		keys.Header{Bytes: bytes.Clone(aliceHeaderKey)},
+		append(
+			options,
+			WithSuite(newTestSignalSuite(t, rootCrypto, newPrivateKey(0xb1), newPrivateKey(0xb2))),
+		)...,
+	)
+	if err != nil {
+		t.Fatalf("NewRecipient(): expected no error but got %v", err)
+	}
+
+	messages := make(map[string]testMessage)
+
+	steps := []struct {
+		name    string
+		encrypt bool
+		ratchet *Ratchet
+	}{
+		{"A1", true, &alice},
+		{"A2", true, &alice},
+		{"A3", true, &alice},
+		{"A3", false, &bob},
+		{"A1", false, &bob},
+		{"B1", true, &bob},
+		{"B2", true, &bob},
+		{"B2", false, &alice},
+		{"B1", false, &alice},
+		{"A4", true, &alice},
+		{"A4", false, &bob},
+		{"A2", false, &bob},
+		{"B3", true, &bob},
+		{"B3", false, &alice},
+	}
+
+	for _, step := range steps {
+		if !step.encrypt {
+			decryptTestMessage(t, step.ratchet, messages[step.name])
+
+			continue
+		}
+
+		message := encryptTestMessage(t, step.ratchet, []byte("message "+step.name))
+		messages[step.name] = message
+		vector := signalVectors[step.name]
+
+		ciphertext := message.encryptedData[:len(message.encryptedData)-sha256.Size]
+		if hex.EncodeToString(ciphertext) != vector.ciphertext {
+			t.Fatalf(
+				"Encrypt(): expected %s ciphertext %s but got %x",
+				step.name,
+				vector.ciphertext,
+				ciphertext,
+			)
+		}
+
+		if !plaintextHeaders {
+			continue
+		}
+
+		if hex.EncodeToString(message.encryptedHeader) != vector.header {
+			t.Fatalf(
+				"Encrypt(): expected %s header %s but got %x",
+				step.name,
+				vector.header,
+				message.encryptedHeader,
+			)
+		}
+
+		mac := message.encryptedData[len(ciphertext):]
+		if hex.EncodeToString(mac) != vector.mac {
+			t.Fatalf("Encrypt(): expected %s MAC %s but got %x", step.name, vector.mac, mac)
+		}
+	}
+}
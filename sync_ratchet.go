@@ -0,0 +1,243 @@
+package ratchet
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/receivingchain"
+)
+
+// SyncRatchet is the participant of the conversation, which is safe for concurrent programs.
+//
+// Encrypt and Decrypt calls are serialized, while introspection methods may be called
+// concurrently. Encryption also proceeds while decryption looks up skipped keys, because
+// the lookup touches nothing but the skipped keys storage of the receiving chain.
+type SyncRatchet struct {
+	// receivingMu guards the receiving chain. It is always locked before mu.
+	receivingMu sync.RWMutex
+	mu          sync.RWMutex
+	ratchet     Ratchet
+}
+
+// NewConcurrent wraps passed ratchet to make it safe for concurrent programs. The ratchet
+// must not be used directly after the call.
+func NewConcurrent(ratchet Ratchet) *SyncRatchet {
+	return &SyncRatchet{
+		ratchet: ratchet,
+	}
+}
+
+// Clone returns a clone of the wrapped ratchet.
+func (r *SyncRatchet) Clone() Ratchet {
+	r.receivingMu.RLock()
+	defer r.receivingMu.RUnlock()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.ratchet.Clone()
+}
+
+// Decrypt decrypts passed encrypted header and encrypted data and authenticates them with auth.
+func (r *SyncRatchet) Decrypt(
+	encryptedHeader []byte,
+	encryptedData []byte,
+	auth []byte,
+) ([]byte, error) {
+	r.receivingMu.Lock()
+	defer r.receivingMu.Unlock()
+
+	return r.decrypt(encryptedHeader, encryptedData, auth)
+}
+
+// DecryptAppend decrypts passed encrypted header and encrypted data, authenticates them with
+// auth and appends the decrypted data to dst. The next message of the current receiving chain
+// is decrypted without locking the rest of the ratchet.
+func (r *SyncRatchet) DecryptAppend(
+	dst []byte,
+	encryptedHeader []byte,
+	encryptedData []byte,
+	auth []byte,
+) ([]byte, error) {
+	r.receivingMu.Lock()
+	defer r.receivingMu.Unlock()
+
+	decryptedData, err := r.ratchet.decryptNextAppend(dst, encryptedHeader, encryptedData, auth)
+	if !errors.Is(err, receivingchain.ErrNotNextMessage) {
+		return decryptedData, err
+	}
+
+	decryptedData, err = r.decrypt(encryptedHeader, encryptedData, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(dst, decryptedData...), nil
+}
+
+// DecryptStream decrypts the stream read from src, authenticates it with auth and writes
+// the decrypted data to dst. The ratchet is locked only while the first chunk is opened.
+func (r *SyncRatchet) DecryptStream(dst io.Writer, src io.Reader, auth []byte) error {
+	r.mu.RLock()
+	suite := r.ratchet.cfg.suite.streamAEAD
+	r.mu.RUnlock()
+
+	stream, err := newStreamDecrypter(src, auth, suite)
+	if err != nil {
+		return err
+	}
+
+	firstChunk, err := r.openStream(stream)
+	if err != nil {
+		return err
+	}
+
+	return stream.decrypt(dst, firstChunk)
+}
+
+// Destroy wipes all keys of the wrapped ratchet and resets it. The ratchet must not be used
+// after the call.
+func (r *SyncRatchet) Destroy() {
+	r.receivingMu.Lock()
+	defer r.receivingMu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ratchet.Destroy()
+}
+
+// Encrypt encrypts passed data and authenticates it with auth.
+func (r *SyncRatchet) Encrypt(
+	data []byte,
+	auth []byte,
+) (encryptedHeader []byte, encryptedData []byte, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.ratchet.Encrypt(data, auth)
+}
+
+// EncryptStream encrypts the data read from src until EOF, authenticates it with auth and
+// writes the encrypted stream to dst. The ratchet is locked only while the stream key is
+// derived.
+func (r *SyncRatchet) EncryptStream(dst io.Writer, src io.Reader, auth []byte) error {
+	r.mu.Lock()
+	encryptedHeader, stream, err := r.ratchet.sealStream(auth)
+	r.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return stream.encrypt(dst, src, encryptedHeader)
+}
+
+// EncryptAppend encrypts passed data, authenticates it with auth and appends the encrypted
+// header to dstHeader and the encrypted data to dstData.
+func (r *SyncRatchet) EncryptAppend(
+	dstHeader []byte,
+	dstData []byte,
+	data []byte,
+	auth []byte,
+) (encryptedHeader []byte, encryptedData []byte, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.ratchet.EncryptAppend(dstHeader, dstData, data, auth)
+}
+
+// LocalPublicKey returns a clone of the local public key of the wrapped ratchet.
+func (r *SyncRatchet) LocalPublicKey() keys.Public {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.ratchet.LocalPublicKey()
+}
+
+// MarshalBinary encodes the wrapped ratchet state into bytes.
+func (r *SyncRatchet) MarshalBinary() ([]byte, error) {
+	// Note that the receiving chain is locked exclusively, because the skipped keys
+	// storage may purge expired keys while iterating.
+	r.receivingMu.Lock()
+	defer r.receivingMu.Unlock()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.ratchet.MarshalBinary()
+}
+
+// RemotePublicKey returns a clone of the remote public key of the wrapped ratchet.
+func (r *SyncRatchet) RemotePublicKey() *keys.Public {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.ratchet.RemotePublicKey()
+}
+
+// Stats returns the statistics of the wrapped ratchet.
+func (r *SyncRatchet) Stats() Stats {
+	// Note that the receiving chain is locked exclusively, because the skipped keys
+	// storage may purge expired keys while iterating.
+	r.receivingMu.Lock()
+	defer r.receivingMu.Unlock()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.ratchet.Stats()
+}
+
+// decrypt decrypts passed message with skipped keys, and then with chain keys. The receiving
+// mutex must be locked by the caller.
+func (r *SyncRatchet) decrypt(encryptedHeader, encryptedData, auth []byte) ([]byte, error) {
+	decryptedData, skippedKeysErr := r.ratchet.decryptWithSkippedKeys(
+		encryptedHeader,
+		encryptedData,
+		auth,
+	)
+	if skippedKeysErr == nil {
+		return decryptedData, nil
+	}
+
+	if errors.Is(skippedKeysErr, receivingchain.ErrOpenMessage) {
+		return nil, r.ratchet.decryptFailed(skippedKeysErr)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	decryptedData, err := r.ratchet.decryptWithChainKeys(encryptedHeader, encryptedData, auth)
+	if err != nil {
+		return nil, r.ratchet.decryptFailed(errors.Join(skippedKeysErr, err))
+	}
+
+	return decryptedData, nil
+}
+
+func (r *SyncRatchet) openStream(stream *streamDecrypter) ([]byte, error) {
+	r.receivingMu.Lock()
+	defer r.receivingMu.Unlock()
+
+	firstChunk, skippedKeysErr := r.ratchet.openWithSkippedKeys(stream.encryptedHeader, stream.open)
+	if skippedKeysErr == nil {
+		return firstChunk, nil
+	}
+
+	if errors.Is(skippedKeysErr, receivingchain.ErrOpenMessage) {
+		return nil, r.ratchet.decryptFailed(skippedKeysErr)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	firstChunk, err := r.ratchet.openWithChainKeys(stream.encryptedHeader, stream.open)
+	if err != nil {
+		return nil, r.ratchet.decryptFailed(errors.Join(skippedKeysErr, err))
+	}
+
+	return firstChunk, nil
+}
@@ -0,0 +1,148 @@
+package ratchet
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/receivingchain"
+)
+
+type blockingSkippedKeysStorage struct {
+	block   atomic.Bool
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (*blockingSkippedKeysStorage) Add(_ keys.Header, _ uint64, _ keys.Message) error {
+	return nil
+}
+
+func (s *blockingSkippedKeysStorage) Clone() receivingchain.SkippedKeysStorage {
+	return s
+}
+
+func (*blockingSkippedKeysStorage) Delete(_ keys.Header, _ uint64) error {
+	return nil
+}
+
+func (s *blockingSkippedKeysStorage) GetIter() (receivingchain.SkippedKeysIter, error) {
+	if s.block.Load() {
+		close(s.entered)
+		<-s.release
+	}
+
+	return func(_ receivingchain.SkippedKeysYield) {}, nil
+}
+
+func TestSyncRatchetEncryptDuringSkippedKeysLookup(t *testing.T) {
+	t.Parallel()
+
+	storage := &blockingSkippedKeysStorage{
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	sender, recipient := newTestRatchets(
+		t,
+		WithReceivingChainOptions(receivingchain.WithSkippedKeysStorage(storage)),
+	)
+	syncRecipient := NewConcurrent(recipient)
+
+	first := encryptTestMessage(t, &sender, []byte("first"))
+
+	_, err := syncRecipient.Decrypt(first.encryptedHeader, first.encryptedData, nil)
+	if err != nil {
+		t.Fatalf("Decrypt(): expected no error but got %v", err)
+	}
+
+	second := encryptTestMessage(t, &sender, []byte("second"))
+	storage.block.Store(true)
+
+	decryptErrs := make(chan error)
+
+	go func() {
+		_, err := syncRecipient.Decrypt(second.encryptedHeader, second.encryptedData, nil)
+		decryptErrs <- err
+	}()
+
+	<-storage.entered
+
+	_, _, err = syncRecipient.Encrypt([]byte("reply"), nil)
+	if err != nil {
+		t.Fatalf("Encrypt(): expected no error but got %v", err)
+	}
+
+	close(storage.release)
+
+	err = <-decryptErrs
+	if err != nil {
+		t.Fatalf("Decrypt(): expected no error but got %v", err)
+	}
+}
+
+func TestSyncRatchetConcurrentConversation(t *testing.T) {
+	t.Parallel()
+
+	const messagesCount = 50
+
+	sender, recipient := newTestRatchets(t)
+	syncSender := NewConcurrent(sender)
+	syncRecipient := NewConcurrent(recipient)
+
+	messages := make(chan testMessage, messagesCount)
+
+	var wg sync.WaitGroup
+
+	for range messagesCount {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			encryptedHeader, encryptedData, err := syncSender.Encrypt([]byte("data"), nil)
+			if err != nil {
+				t.Errorf("Encrypt(): expected no error but got %v", err)
+			}
+
+			messages <- testMessage{
+				encryptedHeader: encryptedHeader,
+				encryptedData:   encryptedData,
+				data:            []byte("data"),
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(messages)
+
+	for message := range messages {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			data, err := syncRecipient.Decrypt(message.encryptedHeader, message.encryptedData, nil)
+			if err != nil {
+				t.Errorf("Decrypt(): expected no error but got %v", err)
+			}
+
+			if !bytes.Equal(data, message.data) {
+				t.Errorf("Decrypt(): expected %v but got %v", message.data, data)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			_, err := syncRecipient.MarshalBinary()
+			if err != nil {
+				t.Errorf("MarshalBinary(): expected no error but got %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,105 @@
+package treekem
+
+import (
+	"errors"
+
+	"github.com/platform-source/aegis/keys"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+var (
+	commitPathContext       = []byte("treekem commit path")
+	commitTranscriptContext = []byte("treekem commit transcript")
+)
+
+// Ciphertext is the data sealed to the public key of the tree node.
+type Ciphertext struct {
+	EphemeralPublicKey keys.Public
+	Data               []byte
+}
+
+// UpdatePathNode is the new public key of the node from the committer's direct path and
+// its path secret sealed to every node of the copath resolution.
+type UpdatePathNode struct {
+	PublicKey            keys.Public
+	EncryptedPathSecrets []Ciphertext
+}
+
+// Commit moves the group to the next epoch. It applies the proposals and refreshes the
+// keys of the committer's direct path, which gives post-compromise security to the group.
+type Commit struct {
+	// Epoch is the new epoch of the group.
+	Epoch              uint64
+	CommitterLeafIndex uint32
+	Proposals          []Proposal
+	LeafPublicKey      keys.Public
+	Path               []UpdatePathNode
+	// ConfirmationTag proves that the committer knows the new epoch secret.
+	ConfirmationTag []byte
+}
+
+// pathAuth returns the data authenticated by every encrypted path secret.
+func (c Commit) pathAuth() ([]byte, error) {
+	builder := cryptobyte.NewBuilder(nil)
+	builder.AddBytes(commitPathContext)
+	c.addHead(builder)
+
+	data, err := builder.Bytes()
+	if err != nil {
+		return nil, errors.Join(ErrBuildBytes, err)
+	}
+
+	return data, nil
+}
+
+// transcript returns the data covered by the confirmation tag.
+func (c Commit) transcript() ([]byte, error) {
+	builder := cryptobyte.NewBuilder(nil)
+	builder.AddBytes(commitTranscriptContext)
+	c.addHead(builder)
+	builder.AddUint8LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(c.LeafPublicKey.Bytes)
+	})
+	builder.AddUint32LengthPrefixed(func(builder *cryptobyte.Builder) {
+		for _, node := range c.Path {
+			builder.AddUint8LengthPrefixed(func(builder *cryptobyte.Builder) {
+				builder.AddBytes(node.PublicKey.Bytes)
+			})
+			builder.AddUint32LengthPrefixed(func(builder *cryptobyte.Builder) {
+				for _, ciphertext := range node.EncryptedPathSecrets {
+					addCiphertext(builder, ciphertext)
+				}
+			})
+		}
+	})
+
+	data, err := builder.Bytes()
+	if err != nil {
+		return nil, errors.Join(ErrBuildBytes, err)
+	}
+
+	return data, nil
+}
+
+func (c Commit) addHead(builder *cryptobyte.Builder) {
+	builder.AddUint64(c.Epoch)
+	builder.AddUint32(c.CommitterLeafIndex)
+	builder.AddUint32LengthPrefixed(func(builder *cryptobyte.Builder) {
+		for _, proposal := range c.Proposals {
+			builder.AddUint8(uint8(proposal.Type))
+			builder.AddUint8LengthPrefixed(func(builder *cryptobyte.Builder) {
+				builder.AddBytes(proposal.InitPublicKey.Bytes)
+			})
+			builder.AddUint32(proposal.LeafIndex)
+		}
+	})
+}
+
+func addCiphertext(builder *cryptobyte.Builder, ciphertext Ciphertext) {
+	builder.AddUint8LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(ciphertext.EphemeralPublicKey.Bytes)
+	})
+	builder.AddUint32LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(ciphertext.Data)
+	})
+}
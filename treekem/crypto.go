@@ -0,0 +1,22 @@
+package treekem
+
+import (
+	"github.com/platform-source/aegis/keys"
+)
+
+// Crypto is the crypto interface for the ratchet tree.
+type Crypto interface {
+	ComputeConfirmationTag(epochSecret keys.Root, transcript []byte) ([]byte, error)
+	DeriveEpochSecret(
+		initSecret keys.Root,
+		commitSecret keys.Shared,
+		epoch uint64,
+	) (keys.Root, error)
+	DeriveKeyPair(pathSecret keys.Shared) (keys.Private, keys.Public, error)
+	DerivePathSecret(pathSecret keys.Shared) (keys.Shared, error)
+	ExportSecret(epochSecret keys.Root, label []byte, length int) ([]byte, error)
+	GenerateKeyPair() (keys.Private, keys.Public, error)
+	GenerateSecret() (keys.Shared, error)
+	Open(privateKey keys.Private, ciphertext Ciphertext, auth []byte) ([]byte, error)
+	Seal(publicKey keys.Public, plaintext []byte, auth []byte) (Ciphertext, error)
+}
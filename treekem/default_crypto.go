@@ -0,0 +1,284 @@
+package treekem
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/tools/slices"
+	"golang.org/x/crypto/blake2b"
+	cipher "golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+const defaultCryptoSecretSize = 32
+
+var (
+	defaultCryptoConfirmLabel = []byte("confirm")
+	defaultCryptoEpochInfo    = []byte("treekem epoch")
+	defaultCryptoExportInfo   = []byte("treekem export ")
+	defaultCryptoNodeInfo     = []byte("treekem node")
+	defaultCryptoPathInfo     = []byte("treekem path")
+	defaultCryptoSealInfo     = []byte("treekem seal")
+)
+
+type defaultCrypto struct {
+	curve ecdh.Curve
+}
+
+func newDefaultCrypto() defaultCrypto {
+	crypto := defaultCrypto{
+		curve: ecdh.X25519(),
+	}
+
+	return crypto
+}
+
+func (c defaultCrypto) ComputeConfirmationTag(
+	epochSecret keys.Root,
+	transcript []byte,
+) ([]byte, error) {
+	confirmationKey, err := c.ExportSecret(epochSecret, defaultCryptoConfirmLabel, blake2b.Size256)
+	if err != nil {
+		return nil, errors.Join(ErrExportSecret, err)
+	}
+
+	mac, err := blake2b.New256(confirmationKey)
+	if err != nil {
+		return nil, errors.Join(ErrNewHasher, err)
+	}
+
+	_, err = mac.Write(transcript)
+	if err != nil {
+		return nil, errors.Join(ErrWriteToMAC, err)
+	}
+
+	return mac.Sum(nil), nil
+}
+
+func (c defaultCrypto) DeriveEpochSecret(
+	initSecret keys.Root,
+	commitSecret keys.Shared,
+	epoch uint64,
+) (keys.Root, error) {
+	info := binary.BigEndian.AppendUint64(slices.CloneBytes(defaultCryptoEpochInfo), epoch)
+
+	epochSecretBytes, err := c.kdf(
+		commitSecret.Bytes,
+		initSecret.Bytes,
+		info,
+		defaultCryptoSecretSize,
+	)
+	if err != nil {
+		return keys.Root{}, err
+	}
+
+	epochSecret := keys.Root{
+		Bytes: epochSecretBytes,
+	}
+
+	return epochSecret, nil
+}
+
+func (c defaultCrypto) DeriveKeyPair(pathSecret keys.Shared) (keys.Private, keys.Public, error) {
+	privateKeyBytes, err := c.kdf(
+		pathSecret.Bytes,
+		nil,
+		defaultCryptoNodeInfo,
+		defaultCryptoSecretSize,
+	)
+	if err != nil {
+		return keys.Private{}, keys.Public{}, err
+	}
+
+	foreignPrivateKey, err := c.curve.NewPrivateKey(privateKeyBytes)
+	if err != nil {
+		return keys.Private{}, keys.Public{}, errors.Join(ErrNewPrivateKey, err)
+	}
+
+	privateKey := keys.Private{
+		Bytes: foreignPrivateKey.Bytes(),
+	}
+
+	publicKey := keys.Public{
+		Bytes: foreignPrivateKey.PublicKey().Bytes(),
+	}
+
+	return privateKey, publicKey, nil
+}
+
+func (c defaultCrypto) DerivePathSecret(pathSecret keys.Shared) (keys.Shared, error) {
+	nextPathSecretBytes, err := c.kdf(
+		pathSecret.Bytes,
+		nil,
+		defaultCryptoPathInfo,
+		defaultCryptoSecretSize,
+	)
+	if err != nil {
+		return keys.Shared{}, err
+	}
+
+	nextPathSecret := keys.Shared{
+		Bytes: nextPathSecretBytes,
+	}
+
+	return nextPathSecret, nil
+}
+
+func (c defaultCrypto) ExportSecret(
+	epochSecret keys.Root,
+	label []byte,
+	length int,
+) ([]byte, error) {
+	return c.kdf(epochSecret.Bytes, nil, slices.ConcatBytes(defaultCryptoExportInfo, label), length)
+}
+
+func (c defaultCrypto) GenerateKeyPair() (keys.Private, keys.Public, error) {
+	foreignPrivateKey, err := c.curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return keys.Private{}, keys.Public{}, errors.Join(ErrGeneratePrivateKey, err)
+	}
+
+	privateKey := keys.Private{
+		Bytes: foreignPrivateKey.Bytes(),
+	}
+
+	publicKey := keys.Public{
+		Bytes: foreignPrivateKey.PublicKey().Bytes(),
+	}
+
+	return privateKey, publicKey, nil
+}
+
+func (defaultCrypto) GenerateSecret() (keys.Shared, error) {
+	secretBytes := make([]byte, defaultCryptoSecretSize)
+
+	_, err := io.ReadFull(rand.Reader, secretBytes)
+	if err != nil {
+		return keys.Shared{}, errors.Join(ErrReadRandom, err)
+	}
+
+	secret := keys.Shared{
+		Bytes: secretBytes,
+	}
+
+	return secret, nil
+}
+
+func (c defaultCrypto) Open(
+	privateKey keys.Private,
+	ciphertext Ciphertext,
+	auth []byte,
+) ([]byte, error) {
+	key, nonce, err := c.deriveSealKey(privateKey, ciphertext.EphemeralPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewX(key)
+	if err != nil {
+		return nil, errors.Join(ErrNewCipher, err)
+	}
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext.Data, auth)
+	if err != nil {
+		return nil, errors.Join(ErrDecrypt, err)
+	}
+
+	return plaintext, nil
+}
+
+func (c defaultCrypto) Seal(
+	publicKey keys.Public,
+	plaintext []byte,
+	auth []byte,
+) (Ciphertext, error) {
+	foreignEphemeralKey, err := c.curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return Ciphertext{}, errors.Join(ErrGeneratePrivateKey, err)
+	}
+
+	ephemeralPrivateKey := keys.Private{
+		Bytes: foreignEphemeralKey.Bytes(),
+	}
+
+	key, nonce, err := c.deriveSealKey(ephemeralPrivateKey, publicKey)
+	if err != nil {
+		return Ciphertext{}, err
+	}
+
+	aead, err := cipher.NewX(key)
+	if err != nil {
+		return Ciphertext{}, errors.Join(ErrNewCipher, err)
+	}
+
+	ciphertext := Ciphertext{
+		EphemeralPublicKey: keys.Public{
+			Bytes: foreignEphemeralKey.PublicKey().Bytes(),
+		},
+		Data: aead.Seal(nil, nonce, plaintext, auth),
+	}
+
+	return ciphertext, nil
+}
+
+func (defaultCrypto) kdf(secret, salt, info []byte, length int) ([]byte, error) {
+	var newHashErr error
+
+	kdf := hkdf.New(
+		func() hash.Hash {
+			hasher, err := blake2b.New512(nil)
+			newHashErr = err
+
+			return hasher
+		},
+		secret,
+		salt,
+		info,
+	)
+	kdfOutput := make([]byte, length)
+
+	_, err := io.ReadFull(kdf, kdfOutput)
+	if err != nil {
+		return nil, errors.Join(ErrKDF, err)
+	}
+
+	if newHashErr != nil {
+		return nil, errors.Join(ErrNewHasher, newHashErr)
+	}
+
+	return kdfOutput, nil
+}
+
+// deriveSealKey derives the cipher key and the nonce from the Diffie-Hellman of passed keys.
+// Note that the result is the same for the ephemeral and the recipient key pairs.
+func (c defaultCrypto) deriveSealKey(
+	privateKey keys.Private,
+	publicKey keys.Public,
+) ([]byte, []byte, error) {
+	foreignPrivateKey, err := c.curve.NewPrivateKey(privateKey.Bytes)
+	if err != nil {
+		return nil, nil, errors.Join(ErrNewPrivateKey, err)
+	}
+
+	foreignPublicKey, err := c.curve.NewPublicKey(publicKey.Bytes)
+	if err != nil {
+		return nil, nil, errors.Join(ErrNewPublicKey, err)
+	}
+
+	sharedKey, err := foreignPrivateKey.ECDH(foreignPublicKey)
+	if err != nil {
+		return nil, nil, errors.Join(ErrDiffieHellman, err)
+	}
+
+	kdfOutput, err := c.kdf(sharedKey, nil, defaultCryptoSealInfo, cipher.KeySize+cipher.NonceSizeX)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return kdfOutput[:cipher.KeySize], kdfOutput[cipher.KeySize:], nil
+}
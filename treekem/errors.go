@@ -0,0 +1,145 @@
+package treekem
+
+import (
+	"errors"
+)
+
+var (
+	// ErrAdvanceEpoch is the epoch advance error.
+	ErrAdvanceEpoch = errors.New("advance epoch")
+
+	// ErrApplyOptions is the config options apply error.
+	ErrApplyOptions = errors.New("apply options")
+
+	// ErrApplyProposals is the proposals apply error.
+	ErrApplyProposals = errors.New("apply proposals")
+
+	// ErrBuildBytes is the bytes building error.
+	ErrBuildBytes = errors.New("build bytes")
+
+	// ErrCommitterRemoved is an error when the commit removes its committer.
+	ErrCommitterRemoved = errors.New("committer removed")
+
+	// ErrComputeConfirmationTag is the confirmation tag computation error.
+	ErrComputeConfirmationTag = errors.New("compute confirmation tag")
+
+	// ErrCryptoIsNil is an error when nil crypto was passed.
+	ErrCryptoIsNil = errors.New("crypto is nil")
+
+	// ErrDecrypt is the decryption error.
+	ErrDecrypt = errors.New("decrypt")
+
+	// ErrDeriveEpochSecret is the epoch secret derivation error.
+	ErrDeriveEpochSecret = errors.New("derive epoch secret")
+
+	// ErrDeriveKeyPair is the key pair derivation error.
+	ErrDeriveKeyPair = errors.New("derive key pair")
+
+	// ErrDerivePath is the path keys derivation error.
+	ErrDerivePath = errors.New("derive path")
+
+	// ErrDerivePathSecret is the path secret derivation error.
+	ErrDerivePathSecret = errors.New("derive path secret")
+
+	// ErrDiffieHellman is the Diffie-Hellman error.
+	ErrDiffieHellman = errors.New("diffie-hellman")
+
+	// ErrExportSecret is the secret export error.
+	ErrExportSecret = errors.New("export secret")
+
+	// ErrGenerateKeyPair is the key pair generation error.
+	ErrGenerateKeyPair = errors.New("generate key pair")
+
+	// ErrGeneratePrivateKey is the private key generation error.
+	ErrGeneratePrivateKey = errors.New("generate private key")
+
+	// ErrGenerateSecret is the secret generation error.
+	ErrGenerateSecret = errors.New("generate secret")
+
+	// ErrInitPublicKeyIsEmpty is an error when the add proposal has empty init public key.
+	ErrInitPublicKeyIsEmpty = errors.New("init public key is empty")
+
+	// ErrInvalidCommitter is an error when the commit has unknown committer or the member
+	// processes its own commit.
+	ErrInvalidCommitter = errors.New("invalid committer")
+
+	// ErrInvalidConfirmationTag is an error when the confirmation tag of the commit does not
+	// match the new epoch secret.
+	ErrInvalidConfirmationTag = errors.New("invalid confirmation tag")
+
+	// ErrInvalidEncoding is an error when encoded data is malformed.
+	ErrInvalidEncoding = errors.New("invalid encoding")
+
+	// ErrInvalidLeafIndex is an error when the leaf is out of the tree or blank.
+	ErrInvalidLeafIndex = errors.New("invalid leaf index")
+
+	// ErrInvalidPathLength is an error when the update path does not match the tree.
+	ErrInvalidPathLength = errors.New("invalid path length")
+
+	// ErrInvalidWelcome is an error when the welcome has malformed tree or leaf indices.
+	ErrInvalidWelcome = errors.New("invalid welcome")
+
+	// ErrKDF is the KDF error.
+	ErrKDF = errors.New("kdf")
+
+	// ErrMarshalWelcomeSecrets is the welcome secrets marshal error.
+	ErrMarshalWelcomeSecrets = errors.New("marshal welcome secrets")
+
+	// ErrNewCipher is the new cipher creation error.
+	ErrNewCipher = errors.New("new cipher")
+
+	// ErrNewConfig is the new config creation error.
+	ErrNewConfig = errors.New("new config")
+
+	// ErrNewHasher is the new hasher creation error.
+	ErrNewHasher = errors.New("new hasher")
+
+	// ErrNewPrivateKey is the new private key creation error.
+	ErrNewPrivateKey = errors.New("new private key")
+
+	// ErrNewPublicKey is the new public key creation error.
+	ErrNewPublicKey = errors.New("new public key")
+
+	// ErrOpen is the sealed data opening error.
+	ErrOpen = errors.New("open")
+
+	// ErrOpenPathSecret is the path secret opening error.
+	ErrOpenPathSecret = errors.New("open path secret")
+
+	// ErrPrepareAuth is the auth data preparation error.
+	ErrPrepareAuth = errors.New("prepare auth")
+
+	// ErrPrepareTranscript is the commit transcript preparation error.
+	ErrPrepareTranscript = errors.New("prepare transcript")
+
+	// ErrPrepareWelcome is the welcome preparation error.
+	ErrPrepareWelcome = errors.New("prepare welcome")
+
+	// ErrPrivateKeyNotFound is an error when the member has no private key to open the path
+	// secret.
+	ErrPrivateKeyNotFound = errors.New("private key not found")
+
+	// ErrPublicKeyMismatch is an error when the derived public key does not match the tree.
+	ErrPublicKeyMismatch = errors.New("public key mismatch")
+
+	// ErrReadRandom is the random bytes read error.
+	ErrReadRandom = errors.New("read random")
+
+	// ErrRemovedFromGroup is an error when the commit removes the member.
+	ErrRemovedFromGroup = errors.New("removed from group")
+
+	// ErrSeal is the data sealing error.
+	ErrSeal = errors.New("seal")
+
+	// ErrUnexpectedEpoch is an error when the commit is not for the next epoch.
+	ErrUnexpectedEpoch = errors.New("unexpected epoch")
+
+	// ErrUnknownProposalType is an error when the proposal has unknown type.
+	ErrUnknownProposalType = errors.New("unknown proposal type")
+
+	// ErrUnmarshalWelcomeSecrets is the welcome secrets unmarshal error.
+	ErrUnmarshalWelcomeSecrets = errors.New("unmarshal welcome secrets")
+
+	// ErrWriteToMAC is the MAC write error.
+	ErrWriteToMAC = errors.New("write to mac")
+)
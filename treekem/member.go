@@ -0,0 +1,573 @@
+package treekem
+
+import (
+	"crypto/subtle"
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/platform-source/aegis/keys"
+)
+
+const exportedChainKeySize = 32
+
+var (
+	exportChainKeysLabel = []byte("chain keys ")
+	exportInitLabel      = []byte("init")
+)
+
+// Member is the participant of the group. It keeps the ratchet tree, the private keys of
+// its direct path and the secret of the current epoch.
+//
+// Please note that the structure is not safe for concurrent programs.
+type Member struct {
+	leafIndex uint32
+	tree      tree
+	// privateKeys maps the node index to its private key.
+	privateKeys map[uint32]keys.Private
+	epoch       uint64
+	epochSecret keys.Root
+	cfg         config
+}
+
+// NewGroup creates a new group with a single member.
+func NewGroup(options ...Option) (Member, error) {
+	var (
+		member Member
+		err    error
+	)
+
+	member.cfg, err = newConfig(options...)
+	if err != nil {
+		return Member{}, errors.Join(ErrNewConfig, err)
+	}
+
+	privateKey, publicKey, err := member.cfg.crypto.GenerateKeyPair()
+	if err != nil {
+		return Member{}, errors.Join(ErrGenerateKeyPair, err)
+	}
+
+	epochSecret, err := member.cfg.crypto.GenerateSecret()
+	if err != nil {
+		return Member{}, errors.Join(ErrGenerateSecret, err)
+	}
+
+	member.tree = newTree(1)
+	member.tree.nodes[0] = publicKey
+	member.privateKeys = map[uint32]keys.Private{0: privateKey}
+	member.epochSecret = keys.Root{
+		Bytes: epochSecret.Bytes,
+	}
+
+	return member, nil
+}
+
+// NewInitKeyPair creates the key pair, which is used to add the member to the group. The
+// public key is sent to the committer and the private key is passed to Join.
+func NewInitKeyPair(options ...Option) (keys.Private, keys.Public, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return keys.Private{}, keys.Public{}, errors.Join(ErrNewConfig, err)
+	}
+
+	privateKey, publicKey, err := cfg.crypto.GenerateKeyPair()
+	if err != nil {
+		return keys.Private{}, keys.Public{}, errors.Join(ErrGenerateKeyPair, err)
+	}
+
+	return privateKey, publicKey, nil
+}
+
+// Join creates the member from the welcome of the commit, which added it to the group.
+func Join(initPrivateKey keys.Private, welcome Welcome, options ...Option) (Member, error) {
+	var (
+		member Member
+		err    error
+	)
+
+	member.cfg, err = newConfig(options...)
+	if err != nil {
+		return Member{}, errors.Join(ErrNewConfig, err)
+	}
+
+	member.leafIndex = welcome.LeafIndex
+	member.epoch = welcome.Epoch
+	member.tree = tree{
+		nodes: make([]keys.Public, len(welcome.Tree)),
+	}
+
+	for index, node := range welcome.Tree {
+		member.tree.nodes[index] = node.Clone()
+	}
+
+	if !member.tree.isValid() ||
+		!member.tree.isLeafValid(welcome.LeafIndex) ||
+		!member.tree.isLeafValid(welcome.CommitterLeafIndex) ||
+		welcome.LeafIndex == welcome.CommitterLeafIndex {
+		return Member{}, ErrInvalidWelcome
+	}
+
+	auth, err := welcome.auth()
+	if err != nil {
+		return Member{}, errors.Join(ErrPrepareAuth, err)
+	}
+
+	data, err := member.cfg.crypto.Open(initPrivateKey, welcome.EncryptedSecrets, auth)
+	if err != nil {
+		return Member{}, errors.Join(ErrOpen, err)
+	}
+
+	var pathSecret keys.Shared
+
+	member.epochSecret, pathSecret, err = unmarshalWelcomeSecrets(data)
+	if err != nil {
+		return Member{}, errors.Join(ErrUnmarshalWelcomeSecrets, err)
+	}
+
+	member.privateKeys = map[uint32]keys.Private{
+		leafNode(welcome.LeafIndex): initPrivateKey.Clone(),
+	}
+
+	ancestor := commonAncestor(leafNode(welcome.LeafIndex), leafNode(welcome.CommitterLeafIndex))
+
+	path := append([]uint32{ancestor}, member.tree.directPath(ancestor)...)
+
+	_, err = member.deriveSharedPath(path, pathSecret)
+	if err != nil {
+		return Member{}, errors.Join(ErrDerivePath, err)
+	}
+
+	return member, nil
+}
+
+// Clone clones member.
+func (m Member) Clone() Member {
+	m.tree = m.tree.clone()
+	m.privateKeys = maps.Clone(m.privateKeys)
+	m.epochSecret = m.epochSecret.Clone()
+
+	for node, privateKey := range m.privateKeys {
+		m.privateKeys[node] = privateKey.Clone()
+	}
+
+	return m
+}
+
+// Commit applies passed proposals, refreshes the keys of the member's direct path and
+// moves the group to the next epoch. The commit is sent to every other member and the
+// welcomes are sent to the added members.
+//
+// Please note that removals are applied before additions, and the added members take
+// the leftmost blank leaves.
+func (m *Member) Commit(proposals ...Proposal) (Commit, []Welcome, error) {
+	dirty := m.Clone()
+
+	addedLeaves, err := dirty.applyProposals(proposals)
+	if err != nil {
+		return Commit{}, nil, errors.Join(ErrApplyProposals, err)
+	}
+
+	commit := Commit{
+		Epoch:              m.epoch + 1,
+		CommitterLeafIndex: m.leafIndex,
+		Proposals:          slices.Clone(proposals),
+	}
+
+	leaf := leafNode(m.leafIndex)
+	directPath := dirty.tree.directPath(leaf)
+	copath := dirty.tree.copath(leaf)
+
+	if dirty.tree.isBlank(leaf) {
+		return Commit{}, nil, ErrCommitterRemoved
+	}
+
+	leafSecret, err := dirty.cfg.crypto.GenerateSecret()
+	if err != nil {
+		return Commit{}, nil, errors.Join(ErrGenerateSecret, err)
+	}
+
+	// Note that the keys of the old direct path are forgotten.
+	dirty.tree.blankPath(leaf)
+	dirty.privateKeys = make(map[uint32]keys.Private, len(directPath)+1)
+
+	pathSecrets, err := dirty.deriveSharedPath(append([]uint32{leaf}, directPath...), leafSecret)
+	if err != nil {
+		return Commit{}, nil, errors.Join(ErrDerivePath, err)
+	}
+
+	commit.LeafPublicKey = dirty.tree.nodes[leaf].Clone()
+
+	auth, err := commit.pathAuth()
+	if err != nil {
+		return Commit{}, nil, errors.Join(ErrPrepareAuth, err)
+	}
+
+	addedNodes := make([]uint32, 0, len(addedLeaves))
+	for _, leafIndex := range addedLeaves {
+		addedNodes = append(addedNodes, leafNode(leafIndex))
+	}
+
+	commit.Path = make([]UpdatePathNode, len(directPath))
+
+	for index, node := range directPath {
+		commit.Path[index].PublicKey = dirty.tree.nodes[node].Clone()
+
+		for _, recipient := range dirty.tree.resolution(copath[index], addedNodes) {
+			ciphertext, err := dirty.cfg.crypto.Seal(
+				dirty.tree.nodes[recipient],
+				pathSecrets[index+1].Bytes,
+				auth,
+			)
+			if err != nil {
+				return Commit{}, nil, errors.Join(ErrSeal, err)
+			}
+
+			commit.Path[index].EncryptedPathSecrets = append(
+				commit.Path[index].EncryptedPathSecrets,
+				ciphertext,
+			)
+		}
+	}
+
+	err = dirty.advanceEpoch(pathSecrets[len(pathSecrets)-1])
+	if err != nil {
+		return Commit{}, nil, errors.Join(ErrAdvanceEpoch, err)
+	}
+
+	commit.ConfirmationTag, err = dirty.computeConfirmationTag(commit)
+	if err != nil {
+		return Commit{}, nil, errors.Join(ErrComputeConfirmationTag, err)
+	}
+
+	welcomes := make([]Welcome, 0, len(addedLeaves))
+
+	for _, leafIndex := range addedLeaves {
+		ancestor := commonAncestor(leafNode(leafIndex), leaf)
+
+		welcome, err := dirty.welcome(leafIndex, pathSecrets[slices.Index(directPath, ancestor)+1])
+		if err != nil {
+			return Commit{}, nil, errors.Join(ErrPrepareWelcome, err)
+		}
+
+		welcomes = append(welcomes, welcome)
+	}
+
+	*m = dirty
+
+	return commit, welcomes, nil
+}
+
+// Epoch returns the current epoch of the group.
+func (m Member) Epoch() uint64 {
+	return m.epoch
+}
+
+// ExportChainKeys derives the master key and the header key for the symmetric message
+// chain of the current epoch. Passed label separates the chains of different purposes,
+// for example the chains of different senders.
+func (m Member) ExportChainKeys(label []byte) (keys.Master, keys.Header, error) {
+	output, err := m.ExportSecret(
+		slices.Concat(exportChainKeysLabel, label),
+		2*exportedChainKeySize,
+	)
+	if err != nil {
+		return keys.Master{}, keys.Header{}, err
+	}
+
+	masterKey := keys.Master{
+		Bytes: output[:exportedChainKeySize],
+	}
+
+	headerKey := keys.Header{
+		Bytes: output[exportedChainKeySize:],
+	}
+
+	return masterKey, headerKey, nil
+}
+
+// ExportSecret derives the secret of passed length from the current epoch secret.
+func (m Member) ExportSecret(label []byte, length int) ([]byte, error) {
+	secret, err := m.cfg.crypto.ExportSecret(m.epochSecret, label, length)
+	if err != nil {
+		return nil, errors.Join(ErrExportSecret, err)
+	}
+
+	return secret, nil
+}
+
+// LeafIndex returns the index of the member's leaf in the tree.
+func (m Member) LeafIndex() uint32 {
+	return m.leafIndex
+}
+
+// ProcessCommit applies the commit of another member and moves the group to the next epoch.
+// ErrRemovedFromGroup is returned when the commit removes the member.
+func (m *Member) ProcessCommit(commit Commit) error {
+	if commit.Epoch != m.epoch+1 {
+		return ErrUnexpectedEpoch
+	}
+
+	if commit.CommitterLeafIndex == m.leafIndex || !m.tree.isLeafValid(commit.CommitterLeafIndex) {
+		return ErrInvalidCommitter
+	}
+
+	dirty := m.Clone()
+
+	addedLeaves, err := dirty.applyProposals(commit.Proposals)
+	if err != nil {
+		return errors.Join(ErrApplyProposals, err)
+	}
+
+	if dirty.tree.isBlank(leafNode(m.leafIndex)) {
+		return ErrRemovedFromGroup
+	}
+
+	committerLeaf := leafNode(commit.CommitterLeafIndex)
+	directPath := dirty.tree.directPath(committerLeaf)
+
+	if dirty.tree.isBlank(committerLeaf) {
+		return ErrCommitterRemoved
+	}
+
+	if len(commit.Path) != len(directPath) {
+		return ErrInvalidPathLength
+	}
+
+	dirty.tree.nodes[committerLeaf] = commit.LeafPublicKey.Clone()
+
+	for index, node := range directPath {
+		dirty.tree.nodes[node] = commit.Path[index].PublicKey.Clone()
+	}
+
+	ancestor := commonAncestor(leafNode(m.leafIndex), committerLeaf)
+	ancestorIndex := slices.Index(directPath, ancestor)
+
+	pathSecret, err := dirty.openPathSecret(commit, addedLeaves, ancestorIndex)
+	if err != nil {
+		return errors.Join(ErrOpenPathSecret, err)
+	}
+
+	pathSecrets, err := dirty.deriveSharedPath(directPath[ancestorIndex:], pathSecret)
+	if err != nil {
+		return errors.Join(ErrDerivePath, err)
+	}
+
+	err = dirty.advanceEpoch(pathSecrets[len(pathSecrets)-1])
+	if err != nil {
+		return errors.Join(ErrAdvanceEpoch, err)
+	}
+
+	confirmationTag, err := dirty.computeConfirmationTag(commit)
+	if err != nil {
+		return errors.Join(ErrComputeConfirmationTag, err)
+	}
+
+	if subtle.ConstantTimeCompare(confirmationTag, commit.ConfirmationTag) != 1 {
+		return ErrInvalidConfirmationTag
+	}
+
+	*m = dirty
+
+	return nil
+}
+
+func (m *Member) advanceEpoch(commitSecret keys.Shared) error {
+	initSecretBytes, err := m.cfg.crypto.ExportSecret(
+		m.epochSecret,
+		exportInitLabel,
+		len(m.epochSecret.Bytes),
+	)
+	if err != nil {
+		return errors.Join(ErrExportSecret, err)
+	}
+
+	initSecret := keys.Root{
+		Bytes: initSecretBytes,
+	}
+
+	m.epoch++
+
+	m.epochSecret, err = m.cfg.crypto.DeriveEpochSecret(initSecret, commitSecret, m.epoch)
+	if err != nil {
+		return errors.Join(ErrDeriveEpochSecret, err)
+	}
+
+	return nil
+}
+
+// applyProposals applies removals and then additions to the tree. It returns the leaves
+// of the added members.
+func (m *Member) applyProposals(proposals []Proposal) ([]uint32, error) {
+	for _, proposal := range proposals {
+		switch proposal.Type {
+		case ProposalTypeAdd:
+			if len(proposal.InitPublicKey.Bytes) == 0 {
+				return nil, ErrInitPublicKeyIsEmpty
+			}
+		case ProposalTypeRemove:
+			if !m.tree.isLeafValid(proposal.LeafIndex) {
+				return nil, ErrInvalidLeafIndex
+			}
+
+			m.blankPath(leafNode(proposal.LeafIndex))
+		default:
+			return nil, ErrUnknownProposalType
+		}
+	}
+
+	var addedLeaves []uint32
+
+	for _, proposal := range proposals {
+		if proposal.Type != ProposalTypeAdd {
+			continue
+		}
+
+		leafIndex := m.tree.leftmostBlankLeaf()
+		if leafIndex == m.tree.leavesCount() {
+			m.tree.extend()
+		}
+
+		m.blankPath(leafNode(leafIndex))
+		m.tree.nodes[leafNode(leafIndex)] = proposal.InitPublicKey.Clone()
+
+		addedLeaves = append(addedLeaves, leafIndex)
+	}
+
+	return addedLeaves, nil
+}
+
+func (m *Member) blankPath(leaf uint32) {
+	m.tree.blankPath(leaf)
+
+	delete(m.privateKeys, leaf)
+
+	for _, node := range m.tree.directPath(leaf) {
+		delete(m.privateKeys, node)
+	}
+}
+
+func (m Member) computeConfirmationTag(commit Commit) ([]byte, error) {
+	transcript, err := commit.transcript()
+	if err != nil {
+		return nil, errors.Join(ErrPrepareTranscript, err)
+	}
+
+	confirmationTag, err := m.cfg.crypto.ComputeConfirmationTag(m.epochSecret, transcript)
+	if err != nil {
+		return nil, err
+	}
+
+	return confirmationTag, nil
+}
+
+// deriveSharedPath derives key pairs of passed path nodes starting from passed path secret.
+// The derived public keys fill the blank nodes and must match the keys of the other nodes.
+// It returns the path secrets of the nodes followed by the commit secret.
+func (m *Member) deriveSharedPath(path []uint32, pathSecret keys.Shared) ([]keys.Shared, error) {
+	pathSecrets := make([]keys.Shared, 0, len(path)+1)
+	pathSecrets = append(pathSecrets, pathSecret)
+
+	for _, node := range path {
+		privateKey, publicKey, err := m.cfg.crypto.DeriveKeyPair(pathSecret)
+		if err != nil {
+			return nil, errors.Join(ErrDeriveKeyPair, err)
+		}
+
+		if m.tree.isBlank(node) {
+			m.tree.nodes[node] = publicKey
+		} else if subtle.ConstantTimeCompare(m.tree.nodes[node].Bytes, publicKey.Bytes) != 1 {
+			return nil, ErrPublicKeyMismatch
+		}
+
+		m.privateKeys[node] = privateKey
+
+		pathSecret, err = m.cfg.crypto.DerivePathSecret(pathSecret)
+		if err != nil {
+			return nil, errors.Join(ErrDerivePathSecret, err)
+		}
+
+		pathSecrets = append(pathSecrets, pathSecret)
+	}
+
+	return pathSecrets, nil
+}
+
+// openPathSecret opens the path secret of the committer's direct path node with passed
+// index using the private key of the member's node from the copath resolution.
+func (m Member) openPathSecret(
+	commit Commit,
+	addedLeaves []uint32,
+	pathIndex int,
+) (keys.Shared, error) {
+	addedNodes := make([]uint32, 0, len(addedLeaves))
+	for _, leafIndex := range addedLeaves {
+		addedNodes = append(addedNodes, leafNode(leafIndex))
+	}
+
+	copath := m.tree.copath(leafNode(commit.CommitterLeafIndex))
+	resolution := m.tree.resolution(copath[pathIndex], addedNodes)
+	encryptedPathSecrets := commit.Path[pathIndex].EncryptedPathSecrets
+
+	if len(resolution) != len(encryptedPathSecrets) {
+		return keys.Shared{}, ErrInvalidPathLength
+	}
+
+	for index, node := range resolution {
+		if !isAncestor(node, leafNode(m.leafIndex)) {
+			continue
+		}
+
+		privateKey, ok := m.privateKeys[node]
+		if !ok {
+			return keys.Shared{}, ErrPrivateKeyNotFound
+		}
+
+		auth, err := commit.pathAuth()
+		if err != nil {
+			return keys.Shared{}, errors.Join(ErrPrepareAuth, err)
+		}
+
+		pathSecretBytes, err := m.cfg.crypto.Open(privateKey, encryptedPathSecrets[index], auth)
+		if err != nil {
+			return keys.Shared{}, errors.Join(ErrOpen, err)
+		}
+
+		pathSecret := keys.Shared{
+			Bytes: pathSecretBytes,
+		}
+
+		return pathSecret, nil
+	}
+
+	return keys.Shared{}, ErrPrivateKeyNotFound
+}
+
+func (m Member) welcome(leafIndex uint32, pathSecret keys.Shared) (Welcome, error) {
+	welcome := Welcome{
+		Epoch:              m.epoch,
+		LeafIndex:          leafIndex,
+		CommitterLeafIndex: m.leafIndex,
+		Tree:               m.tree.clone().nodes,
+	}
+
+	auth, err := welcome.auth()
+	if err != nil {
+		return Welcome{}, errors.Join(ErrPrepareAuth, err)
+	}
+
+	data, err := marshalWelcomeSecrets(m.epochSecret, pathSecret)
+	if err != nil {
+		return Welcome{}, errors.Join(ErrMarshalWelcomeSecrets, err)
+	}
+
+	welcome.EncryptedSecrets, err = m.cfg.crypto.Seal(
+		m.tree.nodes[leafNode(leafIndex)],
+		data,
+		auth,
+	)
+	if err != nil {
+		return Welcome{}, errors.Join(ErrSeal, err)
+	}
+
+	return welcome, nil
+}
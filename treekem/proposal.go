@@ -0,0 +1,44 @@
+package treekem
+
+import (
+	"github.com/platform-source/aegis/keys"
+)
+
+// ProposalType is the type of the group membership change.
+type ProposalType uint8
+
+const (
+	// ProposalTypeAdd adds a new member to the group.
+	ProposalTypeAdd ProposalType = iota + 1
+	// ProposalTypeRemove removes the member from the group.
+	ProposalTypeRemove
+)
+
+// Proposal is the group membership change, which is applied by the commit.
+type Proposal struct {
+	Type ProposalType
+	// InitPublicKey is the public key of the added member. It is set only for additions.
+	InitPublicKey keys.Public
+	// LeafIndex is the leaf of the removed member. It is set only for removals.
+	LeafIndex uint32
+}
+
+// NewAddProposal creates a proposal to add the member with passed init public key.
+func NewAddProposal(initPublicKey keys.Public) Proposal {
+	proposal := Proposal{
+		Type:          ProposalTypeAdd,
+		InitPublicKey: initPublicKey.Clone(),
+	}
+
+	return proposal
+}
+
+// NewRemoveProposal creates a proposal to remove the member with passed leaf index.
+func NewRemoveProposal(leafIndex uint32) Proposal {
+	proposal := Proposal{
+		Type:      ProposalTypeRemove,
+		LeafIndex: leafIndex,
+	}
+
+	return proposal
+}
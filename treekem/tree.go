@@ -0,0 +1,180 @@
+package treekem
+
+import (
+	"slices"
+
+	"github.com/platform-source/aegis/keys"
+)
+
+// tree is the array representation of the full left-balanced binary tree. Leaves
+// have even indices and parents have odd indices. Blank nodes have empty public keys.
+type tree struct {
+	nodes []keys.Public
+}
+
+func newTree(leavesCount uint32) tree {
+	return tree{
+		nodes: make([]keys.Public, 2*leavesCount-1),
+	}
+}
+
+func (t tree) clone() tree {
+	nodes := make([]keys.Public, len(t.nodes))
+
+	for index, node := range t.nodes {
+		nodes[index] = node.Clone()
+	}
+
+	return tree{
+		nodes: nodes,
+	}
+}
+
+// blankPath blanks the leaf node and all nodes of its direct path.
+func (t tree) blankPath(leafNode uint32) {
+	t.nodes[leafNode] = keys.Public{}
+
+	for _, node := range t.directPath(leafNode) {
+		t.nodes[node] = keys.Public{}
+	}
+}
+
+func (t tree) copath(node uint32) []uint32 {
+	path := append([]uint32{node}, t.directPath(node)...)
+	path = path[:len(path)-1]
+
+	copath := make([]uint32, 0, len(path))
+	for _, pathNode := range path {
+		copath = append(copath, sibling(pathNode))
+	}
+
+	return copath
+}
+
+func (t tree) directPath(node uint32) []uint32 {
+	root := t.root()
+
+	var path []uint32
+
+	for node != root {
+		node = parent(node)
+		path = append(path, node)
+	}
+
+	return path
+}
+
+// extend doubles the count of leaves. Indices of existing nodes are not changed.
+func (t *tree) extend() {
+	t.nodes = append(t.nodes, make([]keys.Public, len(t.nodes)+1)...)
+}
+
+func (t tree) isBlank(node uint32) bool {
+	return len(t.nodes[node].Bytes) == 0
+}
+
+// isLeafValid reports whether the leaf belongs to the tree and is not blank.
+func (t tree) isLeafValid(leafIndex uint32) bool {
+	return leafIndex < t.leavesCount() && !t.isBlank(leafNode(leafIndex))
+}
+
+// isValid reports whether the count of nodes matches the full tree.
+func (t tree) isValid() bool {
+	leavesCount := t.leavesCount()
+
+	return len(t.nodes)%2 == 1 && leavesCount&(leavesCount-1) == 0
+}
+
+func (t tree) leavesCount() uint32 {
+	return (uint32(len(t.nodes)) + 1) / 2
+}
+
+// leftmostBlankLeaf returns the index of the leftmost blank leaf or the count of leaves when
+// there are no blank leaves.
+func (t tree) leftmostBlankLeaf() uint32 {
+	leavesCount := t.leavesCount()
+
+	for leafIndex := range leavesCount {
+		if t.isBlank(leafNode(leafIndex)) {
+			return leafIndex
+		}
+	}
+
+	return leavesCount
+}
+
+// resolution returns the minimal set of non-blank nodes covering all non-blank
+// leaves of the subtree, excluding passed nodes.
+func (t tree) resolution(node uint32, excluded []uint32) []uint32 {
+	if !t.isBlank(node) {
+		if slices.Contains(excluded, node) {
+			return nil
+		}
+
+		return []uint32{node}
+	}
+
+	if level(node) == 0 {
+		return nil
+	}
+
+	return append(t.resolution(left(node), excluded), t.resolution(right(node), excluded)...)
+}
+
+func (t tree) root() uint32 {
+	return t.leavesCount() - 1
+}
+
+// isAncestor reports whether the node belongs to the subtree of the ancestor.
+func isAncestor(ancestor, node uint32) bool {
+	k := level(ancestor)
+
+	return level(node) <= k && node>>(k+1) == ancestor>>(k+1)
+}
+
+func commonAncestor(first, second uint32) uint32 {
+	ancestor := first
+	for !isAncestor(ancestor, second) {
+		ancestor = parent(ancestor)
+	}
+
+	return ancestor
+}
+
+func leafNode(leafIndex uint32) uint32 {
+	return 2 * leafIndex
+}
+
+func left(node uint32) uint32 {
+	return node ^ (1 << (level(node) - 1))
+}
+
+func level(node uint32) uint32 {
+	var k uint32
+
+	for (node>>k)&1 == 1 {
+		k++
+	}
+
+	return k
+}
+
+func parent(node uint32) uint32 {
+	k := level(node)
+	b := (node >> (k + 1)) & 1
+
+	return (node | (1 << k)) ^ (b << (k + 1))
+}
+
+func right(node uint32) uint32 {
+	return node ^ (3 << (level(node) - 1))
+}
+
+func sibling(node uint32) uint32 {
+	parentNode := parent(node)
+	if node < parentNode {
+		return right(parentNode)
+	}
+
+	return left(parentNode)
+}
@@ -0,0 +1,124 @@
+package treekem
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/platform-source/aegis/keys"
+)
+
+var treeDirectPathTests = []struct {
+	name             string
+	leavesCount      uint32
+	node             uint32
+	expectedPath     []uint32
+	expectedCopath   []uint32
+	expectedAncestor uint32
+}{
+	{
+		"single leaf",
+		1,
+		0,
+		nil,
+		[]uint32{},
+		0,
+	},
+	{
+		"first leaf",
+		8,
+		0,
+		[]uint32{1, 3, 7},
+		[]uint32{2, 5, 11},
+		7,
+	},
+	{
+		"last leaf",
+		8,
+		14,
+		[]uint32{13, 11, 7},
+		[]uint32{12, 9, 3},
+		11,
+	},
+	{
+		"parent node",
+		8,
+		9,
+		[]uint32{11, 7},
+		[]uint32{13, 3},
+		9,
+	},
+}
+
+func TestTreeDirectPath(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range treeDirectPathTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			tree := newTree(test.leavesCount)
+
+			path := tree.directPath(test.node)
+			if !reflect.DeepEqual(path, test.expectedPath) {
+				t.Fatalf(
+					"directPath(%d): expected %v but got %v",
+					test.node,
+					test.expectedPath,
+					path,
+				)
+			}
+
+			copath := tree.copath(test.node)
+			if !reflect.DeepEqual(copath, test.expectedCopath) {
+				t.Fatalf(
+					"copath(%d): expected %v but got %v",
+					test.node,
+					test.expectedCopath,
+					copath,
+				)
+			}
+
+			ancestor := commonAncestor(test.node, 10)
+			if test.leavesCount > 1 && ancestor != test.expectedAncestor {
+				t.Fatalf(
+					"commonAncestor(%d, 10): expected %d but got %d",
+					test.node,
+					test.expectedAncestor,
+					ancestor,
+				)
+			}
+		})
+	}
+}
+
+func TestTreeResolution(t *testing.T) {
+	t.Parallel()
+
+	tree := newTree(4)
+	key := keys.Public{Bytes: []byte{1}}
+
+	tree.nodes[0] = key
+	tree.nodes[4] = key
+	tree.nodes[5] = key
+	tree.nodes[6] = key
+
+	resolution := tree.resolution(tree.root(), nil)
+	if expected := []uint32{0, 5}; !reflect.DeepEqual(resolution, expected) {
+		t.Fatalf("resolution(%d): expected %v but got %v", tree.root(), expected, resolution)
+	}
+
+	resolution = tree.resolution(tree.root(), []uint32{5})
+	if len(resolution) != 1 || resolution[0] != 0 {
+		t.Fatalf("resolution(%d): expected [0] but got %v", tree.root(), resolution)
+	}
+
+	tree.extend()
+
+	if leafIndex := tree.leftmostBlankLeaf(); leafIndex != 1 {
+		t.Fatalf("leftmostBlankLeaf(): expected 1 but got %d", leafIndex)
+	}
+
+	if leavesCount := tree.leavesCount(); leavesCount != 8 {
+		t.Fatalf("extend(): expected 8 leaves but got %d", leavesCount)
+	}
+}
@@ -0,0 +1,280 @@
+package treekem
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/aegis/receivingchain"
+	"github.com/platform-source/aegis/sendingchain"
+	"github.com/platform-source/tools/convert"
+)
+
+var testExportLabel = []byte("test")
+
+func newTestGroup(t *testing.T) Member {
+	t.Helper()
+
+	member, err := NewGroup()
+	if err != nil {
+		t.Fatalf("NewGroup(): expected no error but got %v", err)
+	}
+
+	return member
+}
+
+func newTestInitKeyPair(t *testing.T) (keys.Private, keys.Public) {
+	t.Helper()
+
+	privateKey, publicKey, err := NewInitKeyPair()
+	if err != nil {
+		t.Fatalf("NewInitKeyPair(): expected no error but got %v", err)
+	}
+
+	return privateKey, publicKey
+}
+
+func commitTestProposals(
+	t *testing.T,
+	committer *Member,
+	proposals ...Proposal,
+) (Commit, []Welcome) {
+	t.Helper()
+
+	commit, welcomes, err := committer.Commit(proposals...)
+	if err != nil {
+		t.Fatalf("Commit(): expected no error but got %v", err)
+	}
+
+	return commit, welcomes
+}
+
+func processTestCommit(t *testing.T, commit Commit, members ...*Member) {
+	t.Helper()
+
+	for _, member := range members {
+		err := member.ProcessCommit(commit)
+		if err != nil {
+			t.Fatalf("ProcessCommit(): expected no error but got %v", err)
+		}
+	}
+}
+
+func joinTestMember(t *testing.T, initPrivateKey keys.Private, welcome Welcome) Member {
+	t.Helper()
+
+	member, err := Join(initPrivateKey, welcome)
+	if err != nil {
+		t.Fatalf("Join(): expected no error but got %v", err)
+	}
+
+	return member
+}
+
+func checkTestSecrets(t *testing.T, members ...*Member) []byte {
+	t.Helper()
+
+	var expected []byte
+
+	for _, member := range members {
+		secret, err := member.ExportSecret(testExportLabel, 32)
+		if err != nil {
+			t.Fatalf("ExportSecret(): expected no error but got %v", err)
+		}
+
+		if expected == nil {
+			expected = secret
+		}
+
+		if !bytes.Equal(secret, expected) {
+			t.Fatalf(
+				"ExportSecret(): expected %v but got %v for leaf %d",
+				expected,
+				secret,
+				member.LeafIndex(),
+			)
+		}
+
+		if member.Epoch() != members[0].Epoch() {
+			t.Fatalf("Epoch(): expected %d but got %d", members[0].Epoch(), member.Epoch())
+		}
+	}
+
+	return expected
+}
+
+func TestGroupLifecycle(t *testing.T) {
+	t.Parallel()
+
+	alice := newTestGroup(t)
+	bobPrivateKey, bobPublicKey := newTestInitKeyPair(t)
+
+	_, welcomes := commitTestProposals(t, &alice, NewAddProposal(bobPublicKey))
+	bob := joinTestMember(t, bobPrivateKey, welcomes[0])
+	firstSecret := checkTestSecrets(t, &alice, &bob)
+
+	carolPrivateKey, carolPublicKey := newTestInitKeyPair(t)
+	davePrivateKey, davePublicKey := newTestInitKeyPair(t)
+
+	commit, welcomes := commitTestProposals(
+		t,
+		&alice,
+		NewAddProposal(carolPublicKey),
+		NewAddProposal(davePublicKey),
+	)
+	processTestCommit(t, commit, &bob)
+	carol := joinTestMember(t, carolPrivateKey, welcomes[0])
+	dave := joinTestMember(t, davePrivateKey, welcomes[1])
+	checkTestSecrets(t, &alice, &bob, &carol, &dave)
+
+	commit, _ = commitTestProposals(t, &bob)
+	processTestCommit(t, commit, &alice, &carol, &dave)
+	checkTestSecrets(t, &alice, &bob, &carol, &dave)
+
+	commit, _ = commitTestProposals(t, &carol, NewRemoveProposal(bob.LeafIndex()))
+	processTestCommit(t, commit, &alice, &dave)
+
+	err := bob.ProcessCommit(commit)
+	if !errors.Is(err, ErrRemovedFromGroup) {
+		t.Fatalf("ProcessCommit(): expected removed from group error but got %v", err)
+	}
+
+	lastSecret := checkTestSecrets(t, &alice, &carol, &dave)
+	if bytes.Equal(firstSecret, lastSecret) {
+		t.Fatal("ExportSecret(): expected different secrets for different epochs")
+	}
+
+	evePrivateKey, evePublicKey := newTestInitKeyPair(t)
+
+	commit, welcomes = commitTestProposals(t, &dave, NewAddProposal(evePublicKey))
+	processTestCommit(t, commit, &alice, &carol)
+	eve := joinTestMember(t, evePrivateKey, welcomes[0])
+
+	if eve.LeafIndex() != 1 {
+		t.Fatalf("Join(): expected leaf index 1 of the removed member but got %d", eve.LeafIndex())
+	}
+
+	commit, _ = commitTestProposals(t, &eve)
+	processTestCommit(t, commit, &alice, &carol, &dave)
+	checkTestSecrets(t, &alice, &carol, &dave, &eve)
+}
+
+func TestMemberProcessCommitInvalidConfirmationTag(t *testing.T) {
+	t.Parallel()
+
+	alice := newTestGroup(t)
+	bobPrivateKey, bobPublicKey := newTestInitKeyPair(t)
+
+	_, welcomes := commitTestProposals(t, &alice, NewAddProposal(bobPublicKey))
+	bob := joinTestMember(t, bobPrivateKey, welcomes[0])
+
+	commit, _ := commitTestProposals(t, &alice)
+	commit.ConfirmationTag[0] ^= 0xFF
+
+	err := bob.ProcessCommit(commit)
+	if !errors.Is(err, ErrInvalidConfirmationTag) {
+		t.Fatalf("ProcessCommit(): expected invalid confirmation tag error but got %v", err)
+	}
+
+	err = bob.ProcessCommit(commit)
+	if !errors.Is(err, ErrInvalidConfirmationTag) {
+		t.Fatalf("ProcessCommit(): expected unchanged state but got %v", err)
+	}
+
+	commit.ConfirmationTag[0] ^= 0xFF
+	processTestCommit(t, commit, &bob)
+	checkTestSecrets(t, &alice, &bob)
+
+	err = bob.ProcessCommit(commit)
+	if !errors.Is(err, ErrUnexpectedEpoch) {
+		t.Fatalf("ProcessCommit(): expected unexpected epoch error but got %v", err)
+	}
+}
+
+func TestMemberCommitInvalidProposals(t *testing.T) {
+	t.Parallel()
+
+	alice := newTestGroup(t)
+
+	_, _, err := alice.Commit(NewRemoveProposal(alice.LeafIndex()))
+	if !errors.Is(err, ErrCommitterRemoved) {
+		t.Fatalf("Commit(): expected committer removed error but got %v", err)
+	}
+
+	_, _, err = alice.Commit(NewRemoveProposal(5))
+	if !errors.Is(err, ErrInvalidLeafIndex) {
+		t.Fatalf("Commit(): expected invalid leaf index error but got %v", err)
+	}
+
+	_, _, err = alice.Commit(NewAddProposal(keys.Public{}))
+	if !errors.Is(err, ErrInitPublicKeyIsEmpty) {
+		t.Fatalf("Commit(): expected init public key is empty error but got %v", err)
+	}
+
+	if alice.Epoch() != 0 {
+		t.Fatalf("Commit(): expected epoch 0 after errors but got %d", alice.Epoch())
+	}
+}
+
+func TestMemberExportChainKeys(t *testing.T) {
+	t.Parallel()
+
+	alice := newTestGroup(t)
+	bobPrivateKey, bobPublicKey := newTestInitKeyPair(t)
+
+	_, welcomes := commitTestProposals(t, &alice, NewAddProposal(bobPublicKey))
+	bob := joinTestMember(t, bobPrivateKey, welcomes[0])
+
+	sendingMasterKey, sendingHeaderKey, err := alice.ExportChainKeys([]byte("alice"))
+	if err != nil {
+		t.Fatalf("ExportChainKeys(): expected no error but got %v", err)
+	}
+
+	sending, err := sendingchain.New(&sendingMasterKey, &sendingHeaderKey, keys.Header{}, 0, 0)
+	if err != nil {
+		t.Fatalf("sendingchain.New(): expected no error but got %v", err)
+	}
+
+	receivingMasterKey, receivingHeaderKey, err := bob.ExportChainKeys([]byte("alice"))
+	if err != nil {
+		t.Fatalf("ExportChainKeys(): expected no error but got %v", err)
+	}
+
+	receiving, err := receivingchain.New(
+		convert.ToPtr(receivingMasterKey),
+		convert.ToPtr(receivingHeaderKey),
+		keys.Header{},
+		0,
+	)
+	if err != nil {
+		t.Fatalf("receivingchain.New(): expected no error but got %v", err)
+	}
+
+	data := []byte("group message")
+
+	encryptedHeader, encryptedData, err := sending.Encrypt(
+		sending.PrepareHeader(keys.Public{}),
+		data,
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("Encrypt(): expected no error but got %v", err)
+	}
+
+	decryptedData, err := receiving.Decrypt(
+		encryptedHeader,
+		encryptedData,
+		nil,
+		func(_ keys.Public) error {
+			return errors.New("unexpected ratchet")
+		},
+	)
+	if err != nil {
+		t.Fatalf("Decrypt(): expected no error but got %v", err)
+	}
+
+	if !bytes.Equal(decryptedData, data) {
+		t.Fatalf("Decrypt(): expected %v but got %v", data, decryptedData)
+	}
+}
@@ -0,0 +1,85 @@
+package treekem
+
+import (
+	"errors"
+
+	"github.com/platform-source/aegis/keys"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+var welcomeContext = []byte("treekem welcome")
+
+// Welcome lets the added member join the group in the epoch of the commit, which added it.
+type Welcome struct {
+	Epoch              uint64
+	LeafIndex          uint32
+	CommitterLeafIndex uint32
+	// Tree is the public keys of the tree nodes. Blank nodes have empty keys.
+	Tree []keys.Public
+	// EncryptedSecrets is the epoch secret and the path secret of the common ancestor of
+	// the committer and the added member sealed to the init public key.
+	EncryptedSecrets Ciphertext
+}
+
+// auth returns the data authenticated by the encrypted secrets.
+func (w Welcome) auth() ([]byte, error) {
+	builder := cryptobyte.NewBuilder(nil)
+	builder.AddBytes(welcomeContext)
+	builder.AddUint64(w.Epoch)
+	builder.AddUint32(w.LeafIndex)
+	builder.AddUint32(w.CommitterLeafIndex)
+	builder.AddUint32LengthPrefixed(func(builder *cryptobyte.Builder) {
+		for _, node := range w.Tree {
+			builder.AddUint8LengthPrefixed(func(builder *cryptobyte.Builder) {
+				builder.AddBytes(node.Bytes)
+			})
+		}
+	})
+
+	data, err := builder.Bytes()
+	if err != nil {
+		return nil, errors.Join(ErrBuildBytes, err)
+	}
+
+	return data, nil
+}
+
+func marshalWelcomeSecrets(epochSecret keys.Root, pathSecret keys.Shared) ([]byte, error) {
+	builder := cryptobyte.NewBuilder(nil)
+	builder.AddUint8LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(epochSecret.Bytes)
+	})
+	builder.AddUint8LengthPrefixed(func(builder *cryptobyte.Builder) {
+		builder.AddBytes(pathSecret.Bytes)
+	})
+
+	data, err := builder.Bytes()
+	if err != nil {
+		return nil, errors.Join(ErrBuildBytes, err)
+	}
+
+	return data, nil
+}
+
+func unmarshalWelcomeSecrets(data []byte) (keys.Root, keys.Shared, error) {
+	var (
+		input                             = cryptobyte.String(data)
+		epochSecretBytes, pathSecretBytes cryptobyte.String
+	)
+
+	if !input.ReadUint8LengthPrefixed(&epochSecretBytes) ||
+		!input.ReadUint8LengthPrefixed(&pathSecretBytes) ||
+		!input.Empty() {
+		return keys.Root{}, keys.Shared{}, ErrInvalidEncoding
+	}
+
+	epochSecret := keys.Root{
+		Bytes: []byte(epochSecretBytes),
+	}
+
+	pathSecret := keys.Shared{
+		Bytes: []byte(pathSecretBytes),
+	}
+
+	return epochSecret, pathSecret, nil
+}
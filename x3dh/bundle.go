@@ -0,0 +1,82 @@
+package x3dh
+
+import (
+	"crypto/ed25519"
+
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/tools/convert"
+)
+
+// Bundle is the set of recipient public keys, which is published to the server
+// and fetched by the sender to start a conversation.
+//
+// The bundle with KEM prekey enables the post-quantum hybrid key agreement.
+type Bundle struct {
+	IdentityKey           keys.Public
+	IdentitySigningKey    ed25519.PublicKey
+	SignedPreKey          keys.Public
+	SignedPreKeySignature []byte
+	OneTimePreKey         *keys.Public
+	KEMPreKey             *keys.Public
+	KEMPreKeySignature    []byte
+}
+
+// NewBundle creates a new bundle from recipient keys. One-time prekey is optional.
+func NewBundle(identityKey IdentityKey, signedPreKey PreKey, oneTimePreKey *PreKey) Bundle {
+	bundle := Bundle{
+		IdentityKey:           identityKey.Public.Clone(),
+		IdentitySigningKey:    identityKey.SigningPublic,
+		SignedPreKey:          signedPreKey.Public.Clone(),
+		SignedPreKeySignature: identityKey.Sign(signedPreKey.Public),
+	}
+
+	if oneTimePreKey != nil {
+		bundle.OneTimePreKey = convert.ToPtr(oneTimePreKey.Public.Clone())
+	}
+
+	return bundle
+}
+
+// NewHybridBundle creates a new bundle with the signed KEM prekey for the
+// post-quantum hybrid key agreement. One-time prekey is optional.
+func NewHybridBundle(
+	identityKey IdentityKey,
+	signedPreKey PreKey,
+	oneTimePreKey *PreKey,
+	kemPreKey PreKey,
+) Bundle {
+	bundle := NewBundle(identityKey, signedPreKey, oneTimePreKey)
+	bundle.KEMPreKey = convert.ToPtr(kemPreKey.Public.Clone())
+	bundle.KEMPreKeySignature = identityKey.Sign(kemPreKey.Public)
+
+	return bundle
+}
+
+// Verify verifies the signed prekey and KEM prekey signatures.
+func (b Bundle) Verify() error {
+	if len(b.IdentitySigningKey) != ed25519.PublicKeySize {
+		return ErrInvalidIdentitySigningKey
+	}
+
+	if !ed25519.Verify(b.IdentitySigningKey, b.SignedPreKey.Bytes, b.SignedPreKeySignature) {
+		return ErrInvalidSignedPreKeySignature
+	}
+
+	if b.KEMPreKey != nil &&
+		!ed25519.Verify(b.IdentitySigningKey, b.KEMPreKey.Bytes, b.KEMPreKeySignature) {
+		return ErrInvalidKEMPreKeySignature
+	}
+
+	return nil
+}
+
+// InitialMessage is the key agreement data, which the sender passes to the
+// recipient along with the first ratchet message.
+//
+// KEM ciphertext is set only in the post-quantum hybrid mode.
+type InitialMessage struct {
+	IdentityKey   keys.Public
+	EphemeralKey  keys.Public
+	OneTimePreKey *keys.Public
+	KEMCiphertext []byte
+}
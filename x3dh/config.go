@@ -0,0 +1,89 @@
+package x3dh
+
+import (
+	"errors"
+
+	ratchet "github.com/platform-source/aegis"
+	"github.com/platform-source/tools/check"
+)
+
+type config struct {
+	crypto         Crypto
+	kem            KEM
+	kemRequired    bool
+	ratchetOptions []ratchet.Option
+}
+
+func newConfig(options ...Option) (config, error) {
+	cfg := config{
+		crypto: newDefaultCrypto(),
+		kem:    newDefaultKEM(),
+	}
+
+	err := cfg.applyOptions(options...)
+	if err != nil {
+		return config{}, errors.Join(ErrApplyOptions, err)
+	}
+
+	return cfg, nil
+}
+
+func (cfg *config) applyOptions(options ...Option) error {
+	for _, option := range options {
+		err := option(cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Option is the way to modify config default values.
+type Option func(cfg *config) error
+
+// WithCrypto sets passed crypto to the config.
+func WithCrypto(crypto Crypto) Option {
+	return func(cfg *config) error {
+		if check.IsNil(crypto) {
+			return ErrCryptoIsNil
+		}
+
+		cfg.crypto = crypto
+
+		return nil
+	}
+}
+
+// WithKEM sets passed key encapsulation mechanism to the config.
+func WithKEM(kem KEM) Option {
+	return func(cfg *config) error {
+		if check.IsNil(kem) {
+			return ErrKEMIsNil
+		}
+
+		cfg.kem = kem
+
+		return nil
+	}
+}
+
+// WithKEMRequired makes the sender reject bundles without the KEM prekey and the
+// recipient reject initial messages without the KEM ciphertext, so the hybrid
+// mode can not be downgraded to the classical one.
+func WithKEMRequired() Option {
+	return func(cfg *config) error {
+		cfg.kemRequired = true
+
+		return nil
+	}
+}
+
+// WithRatchetOptions sets passed options to the created ratchet.
+func WithRatchetOptions(options ...ratchet.Option) Option {
+	return func(cfg *config) error {
+		cfg.ratchetOptions = options
+
+		return nil
+	}
+}
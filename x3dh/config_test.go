@@ -0,0 +1,141 @@
+package x3dh
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	ratchet "github.com/platform-source/aegis"
+	"github.com/platform-source/aegis/keys"
+)
+
+type testCrypto struct{}
+
+func (testCrypto) ComputeSharedKey(_ keys.Private, _ keys.Public) (keys.Shared, error) {
+	return keys.Shared{}, nil
+}
+
+func (testCrypto) DeriveKeys(_ keys.Shared) (keys.Root, keys.Header, keys.Header, error) {
+	return keys.Root{}, keys.Header{}, keys.Header{}, nil
+}
+
+func (testCrypto) GenerateKeyPair() (keys.Private, keys.Public, error) {
+	return keys.Private{}, keys.Public{}, nil
+}
+
+type testKEM struct{}
+
+func (testKEM) Decapsulate(_ keys.Private, _ []byte) (keys.Shared, error) {
+	return keys.Shared{}, nil
+}
+
+func (testKEM) Encapsulate(_ keys.Public) (keys.Shared, []byte, error) {
+	return keys.Shared{}, nil, nil
+}
+
+func (testKEM) GenerateKeyPair() (keys.Private, keys.Public, error) {
+	return keys.Private{}, keys.Public{}, nil
+}
+
+var newConfigTests = []struct {
+	name                      string
+	options                   []Option
+	errCategories             []error
+	expectedCrypto            Crypto
+	expectedKEM               KEM
+	expectedKEMRequired       bool
+	expectedRatchetOptionsLen int
+}{
+	{
+		"default",
+		nil,
+		nil,
+		defaultCrypto{},
+		defaultKEM{},
+		false,
+		0,
+	},
+	{
+		"all options success",
+		[]Option{
+			WithCrypto(testCrypto{}),
+			WithKEM(testKEM{}),
+			WithKEMRequired(),
+			WithRatchetOptions(ratchet.WithSendingChainOptions()),
+		},
+		nil,
+		testCrypto{},
+		testKEM{},
+		true,
+		1,
+	},
+	{
+		"nil crypto",
+		[]Option{
+			WithCrypto(nil),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrCryptoIsNil,
+		},
+		nil,
+		nil,
+		false,
+		0,
+	},
+	{
+		"nil KEM",
+		[]Option{
+			WithKEM(nil),
+		},
+		[]error{
+			ErrApplyOptions,
+			ErrKEMIsNil,
+		},
+		nil,
+		nil,
+		false,
+		0,
+	},
+}
+
+func TestNewConfig(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range newConfigTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := newConfig(test.options...)
+			if err != nil && len(test.errCategories) == 0 {
+				t.Fatalf("newConfig() expected no error but got %v", err)
+			}
+
+			for _, errCategory := range test.errCategories {
+				if !errors.Is(err, errCategory) {
+					t.Fatalf("newConfig() expected error %v but got %v", errCategory, err)
+				}
+			}
+
+			if err != nil {
+				return
+			}
+
+			if reflect.TypeOf(cfg.crypto) != reflect.TypeOf(test.expectedCrypto) {
+				t.Fatal("WithCrypto() option did not set passed crypto")
+			}
+
+			if reflect.TypeOf(cfg.kem) != reflect.TypeOf(test.expectedKEM) {
+				t.Fatal("WithKEM() option did not set passed KEM")
+			}
+
+			if cfg.kemRequired != test.expectedKEMRequired {
+				t.Fatal("WithKEMRequired() option did not set KEM requirement")
+			}
+
+			if len(cfg.ratchetOptions) != test.expectedRatchetOptionsLen {
+				t.Fatal("WithRatchetOptions() option did not set passed options")
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package x3dh
+
+import (
+	"github.com/platform-source/aegis/keys"
+)
+
+// Crypto is the crypto interface for the initial key agreement.
+type Crypto interface {
+	ComputeSharedKey(privateKey keys.Private, publicKey keys.Public) (keys.Shared, error)
+	DeriveKeys(sharedKey keys.Shared) (keys.Root, keys.Header, keys.Header, error)
+	GenerateKeyPair() (keys.Private, keys.Public, error)
+}
+
+// KEM is the key encapsulation mechanism interface for the post-quantum hybrid
+// key agreement.
+type KEM interface {
+	Decapsulate(privateKey keys.Private, ciphertext []byte) (keys.Shared, error)
+	Encapsulate(publicKey keys.Public) (keys.Shared, []byte, error)
+	GenerateKeyPair() (keys.Private, keys.Public, error)
+}
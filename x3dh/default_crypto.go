@@ -0,0 +1,114 @@
+package x3dh
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/platform-source/aegis/keys"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/hkdf"
+)
+
+var defaultCryptoKDFInfo = []byte("x3dh")
+
+type defaultCrypto struct {
+	curve ecdh.Curve
+}
+
+func newDefaultCrypto() defaultCrypto {
+	crypto := defaultCrypto{
+		curve: ecdh.X25519(),
+	}
+
+	return crypto
+}
+
+func (c defaultCrypto) ComputeSharedKey(
+	privateKey keys.Private,
+	publicKey keys.Public,
+) (keys.Shared, error) {
+	foreignPrivateKey, err := c.curve.NewPrivateKey(privateKey.Bytes)
+	if err != nil {
+		return keys.Shared{}, errors.Join(ErrNewPrivateKey, err)
+	}
+
+	foreignPublicKey, err := c.curve.NewPublicKey(publicKey.Bytes)
+	if err != nil {
+		return keys.Shared{}, errors.Join(ErrNewPublicKey, err)
+	}
+
+	sharedKeyBytes, err := foreignPrivateKey.ECDH(foreignPublicKey)
+	if err != nil {
+		return keys.Shared{}, errors.Join(ErrDiffieHellman, err)
+	}
+
+	sharedKey := keys.Shared{
+		Bytes: sharedKeyBytes,
+	}
+
+	return sharedKey, nil
+}
+
+func (defaultCrypto) DeriveKeys(
+	sharedKey keys.Shared,
+) (keys.Root, keys.Header, keys.Header, error) {
+	const kdfOutputKeySize = 32
+
+	var newHashErr error
+
+	kdf := hkdf.New(
+		func() hash.Hash {
+			hasher, err := blake2b.New512(nil)
+			newHashErr = err
+
+			return hasher
+		},
+		sharedKey.Bytes,
+		nil,
+		defaultCryptoKDFInfo,
+	)
+	kdfOutput := make([]byte, 3*kdfOutputKeySize)
+
+	_, err := io.ReadFull(kdf, kdfOutput)
+	if err != nil {
+		return keys.Root{}, keys.Header{}, keys.Header{}, errors.Join(ErrKDF, err)
+	}
+
+	if newHashErr != nil {
+		return keys.Root{}, keys.Header{}, keys.Header{}, errors.Join(ErrNewHasher, newHashErr)
+	}
+
+	rootKey := keys.Root{
+		Bytes: kdfOutput[:kdfOutputKeySize],
+	}
+
+	sharedHeaderKey := keys.Header{
+		Bytes: kdfOutput[kdfOutputKeySize : 2*kdfOutputKeySize],
+	}
+
+	nextHeaderKey := keys.Header{
+		Bytes: kdfOutput[2*kdfOutputKeySize : 3*kdfOutputKeySize],
+	}
+
+	return rootKey, sharedHeaderKey, nextHeaderKey, nil
+}
+
+func (c defaultCrypto) GenerateKeyPair() (keys.Private, keys.Public, error) {
+	foreignPrivateKey, err := c.curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return keys.Private{}, keys.Public{}, errors.Join(ErrGeneratePrivateKey, err)
+	}
+
+	privateKey := keys.Private{
+		Bytes: foreignPrivateKey.Bytes(),
+	}
+
+	publicKey := keys.Public{
+		Bytes: foreignPrivateKey.PublicKey().Bytes(),
+	}
+
+	return privateKey, publicKey, nil
+}
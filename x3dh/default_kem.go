@@ -0,0 +1,13 @@
+package x3dh
+
+import (
+	"github.com/platform-source/aegis/internal/kem"
+)
+
+// defaultKEM is the ML-KEM-768 key encapsulation mechanism. Private keys are
+// stored as seeds.
+type defaultKEM = kem.MLKEM768
+
+func newDefaultKEM() defaultKEM {
+	return defaultKEM{}
+}
@@ -0,0 +1,93 @@
+package x3dh
+
+import (
+	"errors"
+
+	"github.com/platform-source/aegis/internal/kem"
+)
+
+var (
+	// ErrApplyOptions is the config options apply error.
+	ErrApplyOptions = errors.New("apply options")
+
+	// ErrComputeSharedKey is the shared key compute error.
+	ErrComputeSharedKey = errors.New("compute shared key")
+
+	// ErrCryptoIsNil is an error when nil crypto was passed.
+	ErrCryptoIsNil = errors.New("crypto is nil")
+
+	// ErrDecapsulate is the KEM decapsulation error.
+	ErrDecapsulate = errors.New("decapsulate")
+
+	// ErrDeriveKeys is the keys derivation error.
+	ErrDeriveKeys = errors.New("derive keys")
+
+	// ErrDiffieHellman is the diffie hellman algorithm error.
+	ErrDiffieHellman = errors.New("Diffie-Hellman")
+
+	// ErrEncapsulate is the KEM encapsulation error.
+	ErrEncapsulate = errors.New("encapsulate")
+
+	// ErrGenerateDecapsulationKey is the KEM decapsulation key generation error.
+	ErrGenerateDecapsulationKey = kem.ErrGenerateDecapsulationKey
+
+	// ErrGenerateKEMKeyPair is the KEM key pair generation error.
+	ErrGenerateKEMKeyPair = errors.New("generate KEM key pair")
+
+	// ErrGenerateKeyPair is the key pair generation error.
+	ErrGenerateKeyPair = errors.New("generate key pair")
+
+	// ErrGeneratePrivateKey is the private key generation error.
+	ErrGeneratePrivateKey = errors.New("generate private key")
+
+	// ErrGenerateSigningKey is the signing key generation error.
+	ErrGenerateSigningKey = errors.New("generate signing key")
+
+	// ErrInvalidIdentitySigningKey is an error when bundle identity signing key is malformed.
+	ErrInvalidIdentitySigningKey = errors.New("invalid identity signing key")
+
+	// ErrInvalidKEMPreKeySignature is an error when KEM prekey signature is not valid.
+	ErrInvalidKEMPreKeySignature = errors.New("invalid KEM prekey signature")
+
+	// ErrInvalidSignedPreKeySignature is an error when signed prekey signature is not valid.
+	ErrInvalidSignedPreKeySignature = errors.New("invalid signed prekey signature")
+
+	// ErrKDF is the key derivation error.
+	ErrKDF = errors.New("KDF")
+
+	// ErrKEMCiphertextIsEmpty is an error when required KEM ciphertext is empty.
+	ErrKEMCiphertextIsEmpty = errors.New("KEM ciphertext is empty")
+
+	// ErrKEMIsNil is an error when nil KEM was passed.
+	ErrKEMIsNil = errors.New("KEM is nil")
+
+	// ErrKEMPreKeyIsNil is an error when required KEM prekey is nil.
+	ErrKEMPreKeyIsNil = errors.New("KEM prekey is nil")
+
+	// ErrNewConfig is the config initialization error.
+	ErrNewConfig = errors.New("new config")
+
+	// ErrNewDecapsulationKey is the KEM decapsulation key initialization error.
+	ErrNewDecapsulationKey = kem.ErrNewDecapsulationKey
+
+	// ErrNewEncapsulationKey is the KEM encapsulation key initialization error.
+	ErrNewEncapsulationKey = kem.ErrNewEncapsulationKey
+
+	// ErrNewHasher is the hasher initialization error.
+	ErrNewHasher = errors.New("new hasher")
+
+	// ErrNewPrivateKey is the private key initialization error.
+	ErrNewPrivateKey = errors.New("new private key")
+
+	// ErrNewPublicKey is the public key initialization error.
+	ErrNewPublicKey = errors.New("new public key")
+
+	// ErrNewRatchet is the ratchet initialization error.
+	ErrNewRatchet = errors.New("new ratchet")
+
+	// ErrOneTimePreKeyMismatch is an error when initial message one-time prekey is unknown.
+	ErrOneTimePreKeyMismatch = errors.New("one-time prekey mismatch")
+
+	// ErrVerifyBundle is the bundle verification error.
+	ErrVerifyBundle = errors.New("verify bundle")
+)
@@ -0,0 +1,89 @@
+package x3dh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+
+	"github.com/platform-source/aegis/keys"
+)
+
+// IdentityKey is the long-term participant identity.
+//
+// Diffie-Hellman keys take part in the key agreement, signing keys sign prekeys
+// published in the bundle.
+type IdentityKey struct {
+	Private        keys.Private
+	Public         keys.Public
+	SigningPrivate ed25519.PrivateKey
+	SigningPublic  ed25519.PublicKey
+}
+
+// GenerateIdentityKey generates a new identity key.
+func GenerateIdentityKey(options ...Option) (IdentityKey, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return IdentityKey{}, errors.Join(ErrNewConfig, err)
+	}
+
+	var identityKey IdentityKey
+
+	identityKey.Private, identityKey.Public, err = cfg.crypto.GenerateKeyPair()
+	if err != nil {
+		return IdentityKey{}, errors.Join(ErrGenerateKeyPair, err)
+	}
+
+	identityKey.SigningPublic, identityKey.SigningPrivate, err = ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return IdentityKey{}, errors.Join(ErrGenerateSigningKey, err)
+	}
+
+	return identityKey, nil
+}
+
+// Sign signs passed prekey with the identity signing key.
+func (ik IdentityKey) Sign(preKey keys.Public) []byte {
+	signature := ed25519.Sign(ik.SigningPrivate, preKey.Bytes)
+
+	return signature
+}
+
+// PreKey is the key pair published in the bundle.
+type PreKey struct {
+	Private keys.Private
+	Public  keys.Public
+}
+
+// GeneratePreKey generates a new signed or one-time prekey.
+func GeneratePreKey(options ...Option) (PreKey, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return PreKey{}, errors.Join(ErrNewConfig, err)
+	}
+
+	var preKey PreKey
+
+	preKey.Private, preKey.Public, err = cfg.crypto.GenerateKeyPair()
+	if err != nil {
+		return PreKey{}, errors.Join(ErrGenerateKeyPair, err)
+	}
+
+	return preKey, nil
+}
+
+// GenerateKEMPreKey generates a new signed post-quantum KEM prekey.
+func GenerateKEMPreKey(options ...Option) (PreKey, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return PreKey{}, errors.Join(ErrNewConfig, err)
+	}
+
+	var preKey PreKey
+
+	preKey.Private, preKey.Public, err = cfg.kem.GenerateKeyPair()
+	if err != nil {
+		return PreKey{}, errors.Join(ErrGenerateKEMKeyPair, err)
+	}
+
+	return preKey, nil
+}
@@ -0,0 +1,229 @@
+package x3dh
+
+import (
+	"bytes"
+	"errors"
+
+	ratchet "github.com/platform-source/aegis"
+	"github.com/platform-source/aegis/keys"
+	"github.com/platform-source/tools/slices"
+)
+
+// kdfPrefix is prepended to the key material to separate it from the other
+// protocols using the same curve keys.
+var kdfPrefix = bytes.Repeat([]byte{0xFF}, 32)
+
+// NewSender performs the key agreement with the recipient bundle and creates a
+// ready sending ratchet. Returned initial message must be passed to the recipient.
+func NewSender(
+	identityKey IdentityKey,
+	bundle Bundle,
+	options ...Option,
+) (ratchet.Ratchet, InitialMessage, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return ratchet.Ratchet{}, InitialMessage{}, errors.Join(ErrNewConfig, err)
+	}
+
+	err = bundle.Verify()
+	if err != nil {
+		return ratchet.Ratchet{}, InitialMessage{}, errors.Join(ErrVerifyBundle, err)
+	}
+
+	ephemeralPrivateKey, ephemeralPublicKey, err := cfg.crypto.GenerateKeyPair()
+	if err != nil {
+		return ratchet.Ratchet{}, InitialMessage{}, errors.Join(ErrGenerateKeyPair, err)
+	}
+
+	pairs := []keyPair{
+		{identityKey.Private, bundle.SignedPreKey},
+		{ephemeralPrivateKey, bundle.IdentityKey},
+		{ephemeralPrivateKey, bundle.SignedPreKey},
+	}
+
+	if bundle.OneTimePreKey != nil {
+		pairs = append(pairs, keyPair{ephemeralPrivateKey, *bundle.OneTimePreKey})
+	}
+
+	var (
+		kemSharedKey  *keys.Shared
+		kemCiphertext []byte
+	)
+
+	switch {
+	case bundle.KEMPreKey != nil:
+		var sharedKey keys.Shared
+
+		sharedKey, kemCiphertext, err = cfg.kem.Encapsulate(*bundle.KEMPreKey)
+		if err != nil {
+			return ratchet.Ratchet{}, InitialMessage{}, errors.Join(ErrEncapsulate, err)
+		}
+
+		kemSharedKey = &sharedKey
+	case cfg.kemRequired:
+		return ratchet.Ratchet{}, InitialMessage{}, ErrKEMPreKeyIsNil
+	}
+
+	rootKey, sharedHeaderKey, nextHeaderKey, err := deriveKeys(cfg, pairs, kemSharedKey)
+	if err != nil {
+		return ratchet.Ratchet{}, InitialMessage{}, err
+	}
+
+	sender, err := ratchet.NewSender(
+		bundle.SignedPreKey.Clone(),
+		rootKey,
+		sharedHeaderKey,
+		nextHeaderKey,
+		cfg.ratchetOptions...,
+	)
+	if err != nil {
+		return ratchet.Ratchet{}, InitialMessage{}, errors.Join(ErrNewRatchet, err)
+	}
+
+	message := InitialMessage{
+		IdentityKey:   identityKey.Public.Clone(),
+		EphemeralKey:  ephemeralPublicKey,
+		OneTimePreKey: bundle.OneTimePreKey.ClonePtr(),
+		KEMCiphertext: kemCiphertext,
+	}
+
+	return sender, message, nil
+}
+
+// NewRecipient performs the key agreement with the sender initial message and
+// creates a ready receiving ratchet. One-time prekey must be passed if the initial
+// message refers to it.
+func NewRecipient(
+	identityKey IdentityKey,
+	signedPreKey PreKey,
+	oneTimePreKey *PreKey,
+	message InitialMessage,
+	options ...Option,
+) (ratchet.Ratchet, error) {
+	return newRecipient(identityKey, signedPreKey, oneTimePreKey, nil, message, options...)
+}
+
+// NewHybridRecipient performs the post-quantum hybrid key agreement with the
+// sender initial message and creates a ready receiving ratchet. One-time prekey
+// must be passed if the initial message refers to it.
+func NewHybridRecipient(
+	identityKey IdentityKey,
+	signedPreKey PreKey,
+	oneTimePreKey *PreKey,
+	kemPreKey PreKey,
+	message InitialMessage,
+	options ...Option,
+) (ratchet.Ratchet, error) {
+	return newRecipient(identityKey, signedPreKey, oneTimePreKey, &kemPreKey, message, options...)
+}
+
+func newRecipient(
+	identityKey IdentityKey,
+	signedPreKey PreKey,
+	oneTimePreKey *PreKey,
+	kemPreKey *PreKey,
+	message InitialMessage,
+	options ...Option,
+) (ratchet.Ratchet, error) {
+	cfg, err := newConfig(options...)
+	if err != nil {
+		return ratchet.Ratchet{}, errors.Join(ErrNewConfig, err)
+	}
+
+	pairs := []keyPair{
+		{signedPreKey.Private, message.IdentityKey},
+		{identityKey.Private, message.EphemeralKey},
+		{signedPreKey.Private, message.EphemeralKey},
+	}
+
+	if message.OneTimePreKey != nil {
+		if oneTimePreKey == nil ||
+			!bytes.Equal(oneTimePreKey.Public.Bytes, message.OneTimePreKey.Bytes) {
+			return ratchet.Ratchet{}, ErrOneTimePreKeyMismatch
+		}
+
+		pairs = append(pairs, keyPair{oneTimePreKey.Private, message.EphemeralKey})
+	}
+
+	var kemSharedKey *keys.Shared
+
+	switch {
+	case len(message.KEMCiphertext) > 0:
+		if kemPreKey == nil {
+			return ratchet.Ratchet{}, ErrKEMPreKeyIsNil
+		}
+
+		sharedKey, err := cfg.kem.Decapsulate(kemPreKey.Private, message.KEMCiphertext)
+		if err != nil {
+			return ratchet.Ratchet{}, errors.Join(ErrDecapsulate, err)
+		}
+
+		kemSharedKey = &sharedKey
+	case cfg.kemRequired:
+		return ratchet.Ratchet{}, ErrKEMCiphertextIsEmpty
+	}
+
+	rootKey, sharedHeaderKey, nextHeaderKey, err := deriveKeys(cfg, pairs, kemSharedKey)
+	if err != nil {
+		return ratchet.Ratchet{}, err
+	}
+
+	recipient, err := ratchet.NewRecipient(
+		signedPreKey.Private.Clone(),
+		signedPreKey.Public.Clone(),
+		rootKey,
+		nextHeaderKey,
+		sharedHeaderKey,
+		cfg.ratchetOptions...,
+	)
+	if err != nil {
+		return ratchet.Ratchet{}, errors.Join(ErrNewRatchet, err)
+	}
+
+	return recipient, nil
+}
+
+// AssociatedData returns data, which both participants should pass as auth to
+// bind messages to their identities.
+func AssociatedData(senderIdentityKey, recipientIdentityKey keys.Public) []byte {
+	data := slices.ConcatBytes(senderIdentityKey.Bytes, recipientIdentityKey.Bytes)
+
+	return data
+}
+
+type keyPair struct {
+	privateKey keys.Private
+	publicKey  keys.Public
+}
+
+// deriveKeys derives keys from the Diffie-Hellman outputs of passed key pairs and
+// the optional KEM shared key in the hybrid mode.
+func deriveKeys(
+	cfg config,
+	pairs []keyPair,
+	kemSharedKey *keys.Shared,
+) (rootKey keys.Root, sharedHeaderKey, nextHeaderKey keys.Header, err error) {
+	keyMaterial := slices.CloneBytes(kdfPrefix)
+
+	for _, pair := range pairs {
+		sharedKey, err := cfg.crypto.ComputeSharedKey(pair.privateKey, pair.publicKey)
+		if err != nil {
+			return keys.Root{}, keys.Header{}, keys.Header{}, errors.Join(ErrComputeSharedKey, err)
+		}
+
+		keyMaterial = append(keyMaterial, sharedKey.Bytes...)
+	}
+
+	if kemSharedKey != nil {
+		keyMaterial = append(keyMaterial, kemSharedKey.Bytes...)
+	}
+
+	rootKey, sharedHeaderKey, nextHeaderKey, err = cfg.crypto.DeriveKeys(
+		keys.Shared{Bytes: keyMaterial},
+	)
+	if err != nil {
+		return keys.Root{}, keys.Header{}, keys.Header{}, errors.Join(ErrDeriveKeys, err)
+	}
+
+	return rootKey, sharedHeaderKey, nextHeaderKey, nil
+}
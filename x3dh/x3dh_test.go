@@ -0,0 +1,270 @@
+package x3dh
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+var handshakeTests = []struct {
+	name             string
+	useOneTimePreKey bool
+	useKEMPreKey     bool
+}{
+	{
+		"without one-time prekey",
+		false,
+		false,
+	},
+	{
+		"with one-time prekey",
+		true,
+		false,
+	},
+	{
+		"hybrid without one-time prekey",
+		false,
+		true,
+	},
+	{
+		"hybrid with one-time prekey",
+		true,
+		true,
+	},
+}
+
+func TestHandshake(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range handshakeTests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			senderIdentityKey, err := GenerateIdentityKey()
+			if err != nil {
+				t.Fatalf("GenerateIdentityKey(): expected no error but got %v", err)
+			}
+
+			recipientIdentityKey, err := GenerateIdentityKey()
+			if err != nil {
+				t.Fatalf("GenerateIdentityKey(): expected no error but got %v", err)
+			}
+
+			signedPreKey, err := GeneratePreKey()
+			if err != nil {
+				t.Fatalf("GeneratePreKey(): expected no error but got %v", err)
+			}
+
+			var oneTimePreKey *PreKey
+
+			if test.useOneTimePreKey {
+				preKey, err := GeneratePreKey()
+				if err != nil {
+					t.Fatalf("GeneratePreKey(): expected no error but got %v", err)
+				}
+
+				oneTimePreKey = &preKey
+			}
+
+			kemPreKey, err := GenerateKEMPreKey()
+			if err != nil {
+				t.Fatalf("GenerateKEMPreKey(): expected no error but got %v", err)
+			}
+
+			bundle := NewBundle(recipientIdentityKey, signedPreKey, oneTimePreKey)
+			if test.useKEMPreKey {
+				bundle = NewHybridBundle(
+					recipientIdentityKey,
+					signedPreKey,
+					oneTimePreKey,
+					kemPreKey,
+				)
+			}
+
+			sender, message, err := NewSender(senderIdentityKey, bundle)
+			if err != nil {
+				t.Fatalf("NewSender(): expected no error but got %v", err)
+			}
+
+			if test.useKEMPreKey != (len(message.KEMCiphertext) > 0) {
+				t.Fatalf("NewSender(): unexpected KEM ciphertext %v", message.KEMCiphertext)
+			}
+
+			recipient, err := NewHybridRecipient(
+				recipientIdentityKey,
+				signedPreKey,
+				oneTimePreKey,
+				kemPreKey,
+				message,
+			)
+			if err != nil {
+				t.Fatalf("NewHybridRecipient(): expected no error but got %v", err)
+			}
+
+			auth := AssociatedData(senderIdentityKey.Public, recipientIdentityKey.Public)
+			data := []byte("hello")
+
+			encryptedHeader, encryptedData, err := sender.Encrypt(data, auth)
+			if err != nil {
+				t.Fatalf("Encrypt(): expected no error but got %v", err)
+			}
+
+			decryptedData, err := recipient.Decrypt(encryptedHeader, encryptedData, auth)
+			if err != nil {
+				t.Fatalf("Decrypt(): expected no error but got %v", err)
+			}
+
+			if !bytes.Equal(decryptedData, data) {
+				t.Fatalf("Decrypt(): expected %v but got %v", data, decryptedData)
+			}
+
+			encryptedHeader, encryptedData, err = recipient.Encrypt(data, auth)
+			if err != nil {
+				t.Fatalf("Encrypt(): expected no error but got %v", err)
+			}
+
+			decryptedData, err = sender.Decrypt(encryptedHeader, encryptedData, auth)
+			if err != nil {
+				t.Fatalf("Decrypt(): expected no error but got %v", err)
+			}
+
+			if !bytes.Equal(decryptedData, data) {
+				t.Fatalf("Decrypt(): expected %v but got %v", data, decryptedData)
+			}
+		})
+	}
+}
+
+func TestNewSenderInvalidSignature(t *testing.T) {
+	t.Parallel()
+
+	identityKey, err := GenerateIdentityKey()
+	if err != nil {
+		t.Fatalf("GenerateIdentityKey(): expected no error but got %v", err)
+	}
+
+	signedPreKey, err := GeneratePreKey()
+	if err != nil {
+		t.Fatalf("GeneratePreKey(): expected no error but got %v", err)
+	}
+
+	bundle := NewBundle(identityKey, signedPreKey, nil)
+	bundle.SignedPreKeySignature[0] ^= 0xFF
+
+	_, _, err = NewSender(identityKey, bundle)
+	if !errors.Is(err, ErrVerifyBundle) || !errors.Is(err, ErrInvalidSignedPreKeySignature) {
+		t.Fatalf("NewSender(): expected invalid signature error but got %v", err)
+	}
+}
+
+func TestNewRecipientOneTimePreKeyMismatch(t *testing.T) {
+	t.Parallel()
+
+	identityKey, err := GenerateIdentityKey()
+	if err != nil {
+		t.Fatalf("GenerateIdentityKey(): expected no error but got %v", err)
+	}
+
+	signedPreKey, err := GeneratePreKey()
+	if err != nil {
+		t.Fatalf("GeneratePreKey(): expected no error but got %v", err)
+	}
+
+	oneTimePreKey, err := GeneratePreKey()
+	if err != nil {
+		t.Fatalf("GeneratePreKey(): expected no error but got %v", err)
+	}
+
+	bundle := NewBundle(identityKey, signedPreKey, &oneTimePreKey)
+
+	_, message, err := NewSender(identityKey, bundle)
+	if err != nil {
+		t.Fatalf("NewSender(): expected no error but got %v", err)
+	}
+
+	_, err = NewRecipient(identityKey, signedPreKey, nil, message)
+	if !errors.Is(err, ErrOneTimePreKeyMismatch) {
+		t.Fatalf("NewRecipient(): expected one-time prekey mismatch error but got %v", err)
+	}
+}
+
+func TestKEMRequired(t *testing.T) {
+	t.Parallel()
+
+	identityKey, err := GenerateIdentityKey()
+	if err != nil {
+		t.Fatalf("GenerateIdentityKey(): expected no error but got %v", err)
+	}
+
+	signedPreKey, err := GeneratePreKey()
+	if err != nil {
+		t.Fatalf("GeneratePreKey(): expected no error but got %v", err)
+	}
+
+	kemPreKey, err := GenerateKEMPreKey()
+	if err != nil {
+		t.Fatalf("GenerateKEMPreKey(): expected no error but got %v", err)
+	}
+
+	_, _, err = NewSender(identityKey, NewBundle(identityKey, signedPreKey, nil), WithKEMRequired())
+	if !errors.Is(err, ErrKEMPreKeyIsNil) {
+		t.Fatalf("NewSender(): expected KEM prekey nil error but got %v", err)
+	}
+
+	_, message, err := NewSender(identityKey, NewBundle(identityKey, signedPreKey, nil))
+	if err != nil {
+		t.Fatalf("NewSender(): expected no error but got %v", err)
+	}
+
+	_, err = NewHybridRecipient(
+		identityKey,
+		signedPreKey,
+		nil,
+		kemPreKey,
+		message,
+		WithKEMRequired(),
+	)
+	if !errors.Is(err, ErrKEMCiphertextIsEmpty) {
+		t.Fatalf("NewHybridRecipient(): expected KEM ciphertext empty error but got %v", err)
+	}
+
+	_, message, err = NewSender(
+		identityKey,
+		NewHybridBundle(identityKey, signedPreKey, nil, kemPreKey),
+	)
+	if err != nil {
+		t.Fatalf("NewSender(): expected no error but got %v", err)
+	}
+
+	_, err = NewRecipient(identityKey, signedPreKey, nil, message)
+	if !errors.Is(err, ErrKEMPreKeyIsNil) {
+		t.Fatalf("NewRecipient(): expected KEM prekey nil error but got %v", err)
+	}
+}
+
+func TestNewSenderInvalidKEMSignature(t *testing.T) {
+	t.Parallel()
+
+	identityKey, err := GenerateIdentityKey()
+	if err != nil {
+		t.Fatalf("GenerateIdentityKey(): expected no error but got %v", err)
+	}
+
+	signedPreKey, err := GeneratePreKey()
+	if err != nil {
+		t.Fatalf("GeneratePreKey(): expected no error but got %v", err)
+	}
+
+	kemPreKey, err := GenerateKEMPreKey()
+	if err != nil {
+		t.Fatalf("GenerateKEMPreKey(): expected no error but got %v", err)
+	}
+
+	bundle := NewHybridBundle(identityKey, signedPreKey, nil, kemPreKey)
+	bundle.KEMPreKeySignature[0] ^= 0xFF
+
+	_, _, err = NewSender(identityKey, bundle)
+	if !errors.Is(err, ErrVerifyBundle) || !errors.Is(err, ErrInvalidKEMPreKeySignature) {
+		t.Fatalf("NewSender(): expected invalid KEM signature error but got %v", err)
+	}
+}